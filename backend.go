@@ -0,0 +1,280 @@
+package simlog
+
+import (
+    "fmt"
+    "os"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// Backend 共享的写日志后端：一个日志队列和一个写协程，
+// 应用按模块创建了几十个 SimLogger 时，可让它们通过 WithBackend 共用一个后端，
+// 而不是每个 SimLogger 各有一个写协程、日志队列和日志文件句柄。
+// 各 SimLogger 按各自的选项写日志文件和滚动，写同一个日志文件的 SimLogger 共用一个文件句柄，
+// 由后端的写协程串行地写和滚动，不会因多个 SimLogger 同时滚动同一个文件而互相干扰。
+//
+// 后端带引用计数：NewBackend 返回时计数为1（创建者持有），每个挂在其上的 SimLogger 在 Init 时加1、Close 时减1，
+// Backend.Close 释放创建者持有的计数，计数减为0时写完所有日志、关闭所有日志文件后停止，
+// 因此 Backend.Close 和各 SimLogger 的 Close 可按任意顺序调用。
+//
+// 用法：
+// backend := simlog.NewBackend(100000, 100)
+// defer backend.Close()
+// mylog.Init(simlog.WithBackend(backend), ...)
+type Backend struct {
+    queue       *ringQueue[backendLog]
+    batchNumber int
+    exit        chan struct{} // 写协程已退出
+
+    mutex    sync.Mutex
+    refs     int            // 引用计数
+    paths    map[string]int // 各日志文件被多少个 SimLogger 使用
+    released bool           // 创建者是否已释放
+    stopped  bool           // 引用计数已减为0
+}
+
+// 后端日志队列中的一条日志或一个关闭请求
+type backendLog struct {
+    logger *SimLogger
+    queuedLog
+    detached chan struct{} // 不为nil表示 logger 关闭，写协程写完其之前的日志后关闭 detached
+}
+
+// WithBackend 使用共享的写日志后端，此时总是异步写，
+// WithLogQueueSize、WithBatchNumber、EnableLockOSThread 和 WithOpenRetry 不再生效（打开日志文件失败时不重试，下一批日志时再打开）。
+// 后端已停止时 Init 返回 false。
+func WithBackend(backend *Backend) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.backend = backend
+    })
+}
+
+// NewBackend 创建共享的写日志后端，logQueueSize 和 batchNumber 同 WithLogQueueSize 和 WithBatchNumber
+func NewBackend(logQueueSize, batchNumber int32) *Backend {
+    if logQueueSize <= 0 {
+        logQueueSize = 1
+    }
+    if batchNumber <= 0 {
+        batchNumber = 1
+    }
+    backend := &Backend{
+        queue:       newRingQueue[backendLog](int(logQueueSize)),
+        batchNumber: int(batchNumber),
+        exit:        make(chan struct{}),
+        refs:        1,
+        paths:       make(map[string]int),
+    }
+    go backend.run()
+    registerBackend(backend)
+    return backend
+}
+
+// Close 释放创建者持有的引用，没有挂在其上的 SimLogger 时等待写完所有日志、关闭所有日志文件后返回，
+// 否则立即返回，在最后一个 SimLogger 关闭时停止。重复调用无副作用。
+func (this *Backend) Close() error {
+    this.mutex.Lock()
+    if this.released {
+        this.mutex.Unlock()
+        return nil
+    }
+    this.released = true
+    stopped := this.release()
+    this.mutex.Unlock()
+    unregisterBackend(this)
+
+    if stopped {
+        <-this.exit
+    }
+    return nil
+}
+
+// Refs 返回引用计数（创建者未释放时包括创建者），为0表示后端已停止
+func (this *Backend) Refs() int {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    return this.refs
+}
+
+// 释放 SimLogger 持有的引用（由 Close 在其日志都已写完后调用）
+func (this *Backend) unref() {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    if !this.stopped {
+        this.release()
+    }
+}
+
+// 减少一个引用，减为0时关闭日志队列（写协程写完剩余的日志后退出），返回是否减为了0，调用者需持有 mutex
+func (this *Backend) release() bool {
+    this.refs--
+    if this.refs == 0 {
+        this.stopped = true
+        this.queue.close()
+        return true
+    }
+    return false
+}
+
+// SimLogger 挂到后端上（由 Init 调用），后端已停止时返回 false
+func (this *Backend) attach(logger *SimLogger) bool {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    if this.stopped {
+        return false
+    }
+    this.refs++
+    this.paths[logger.baseFilepath]++
+    logger.setWriterState(writerRunning, nil)
+    return true
+}
+
+// SimLogger 从后端上摘下（由 Close 调用），返回的 channel 在其日志都已写完后关闭，之后由 unref 释放其引用
+func (this *Backend) detach(logger *SimLogger) <-chan struct{} {
+    detached := make(chan struct{})
+    if !this.queue.push(backendLog{logger: logger, detached: detached}) {
+        close(detached) // 后端已停止
+    }
+    return detached
+}
+
+// 放入后端的日志队列，后端已停止时返回false
+func (this *Backend) put(logger *SimLogger, queued queuedLog) bool {
+    return this.queue.push(backendLog{logger: logger, queuedLog: queued})
+}
+
+// 后端的写协程：取一批日志，按日志文件分组后写
+func (this *Backend) run() {
+    files := make(map[string]*logFile) // 已打开的日志文件，只在写协程中访问
+    defer close(this.exit)
+
+    for {
+        logs, ok := this.take()
+        for start := 0; start < len(logs); {
+            logger := logs[start].logger
+            path := logger.baseFilepath
+            if logs[start].detached != nil {
+                this.mutex.Lock()
+                if this.paths[path]--; this.paths[path] <= 0 {
+                    delete(this.paths, path)
+                    if file := files[path]; file != nil {
+                        file.Close()
+                        delete(files, path)
+                    }
+                }
+                this.mutex.Unlock()
+                logger.backendDetached = true
+                close(logs[start].detached)
+                start++
+                continue
+            }
+
+            // 同一个 SimLogger 的连续日志一起写
+            end := start + 1
+            for end < len(logs) && logs[end].detached == nil && logs[end].logger.loggerCore == logger.loggerCore {
+                end++
+            }
+            if file := this.write(logger, files[path], logs[start:end]); file != nil {
+                files[path] = file
+            } else {
+                delete(files, path)
+            }
+            start = end
+        }
+        if !ok {
+            break
+        }
+    }
+    for _, file := range files {
+        file.Close()
+    }
+}
+
+// 从后端的日志队列中取一批，第2个返回值为false表示日志队列已关闭
+func (this *Backend) take() ([]backendLog, bool) {
+    log, ok := this.queue.pop() // block
+    if !ok {
+        return nil, false
+    }
+
+    logs := make([]backendLog, 1, this.batchNumber)
+    logs[0] = log
+    for len(logs) < this.batchNumber {
+        if log, ok = this.queue.tryPop(); !ok {
+            return logs, true
+        }
+        logs = append(logs, log)
+    }
+    return logs, true
+}
+
+// 写一个 SimLogger 的一组日志，返回写之后的日志文件（可能因滚动而重新打开，为 nil 表示打开失败），
+// panic 不影响其它 SimLogger。
+func (this *Backend) write(logger *SimLogger, file *logFile, logs []backendLog) (newFile *logFile) {
+    var numPurged, numThrottled int
+    var dones []chan error
+    logLines := make([]string, 0, len(logs))
+
+    defer func() {
+        if r := recover(); r != nil {
+            err := fmt.Errorf("simlog: log writer panic: %v", r)
+            fmt.Fprintf(os.Stderr, "simlog: log writer of file://%s failed: %s\n", logger.getFilepath(), err.Error())
+            atomic.AddInt64(&logger.numDropped, int64(len(logLines)))
+            logger.addProcessed(len(logLines))
+            notifyDones(dones, err)
+            newFile = nil
+        }
+    }()
+
+    if logger.backendDetached {
+        // 与 Close 同时写的日志可能排在摘下之后，丢弃，以免重新打开已关闭的日志文件
+        atomic.AddInt64(&logger.numDropped, int64(len(logs)))
+        logger.addProcessed(len(logs))
+        return file
+    }
+    for _, log := range logs {
+        if logger.opts.retroactiveFilter && !logger.Enabled(log.level) {
+            numPurged++
+            notifyDone(log.done, nil)
+        } else if logger.throttledOnClose(log.level) {
+            numThrottled++
+            notifyDone(log.done, nil)
+        } else {
+            if logger.opts.writeTime {
+                log.line = logger.stampWriteTime(log.level, log.line, time.Now())
+            }
+            logLines = append(logLines, log.line)
+            if log.done != nil {
+                dones = append(dones, log.done)
+            }
+        }
+    }
+    logger.addPurged(numPurged)
+    logger.addThrottled(numThrottled)
+    if len(logLines) == 0 {
+        return file
+    }
+
+    logger.batchError = nil
+    file, _ = logger.writeLogLines(file, logLines)
+    if file != nil && logger.queueLen() == len(logLines) {
+        logger.flushLogFile(file, len(logLines)) // 本日志在后端中没有更多待写的了
+    }
+    numLines := len(logLines)
+    logLines = nil
+    logger.addProcessed(numLines)
+    notifyDones(dones, logger.batchError)
+    dones = nil
+    return file
+}
+
+// 日志队列中尚未写的日志条数，使用共享后端时为后端中尚未写的本日志的条数
+func (this *SimLogger) queueLen() int {
+    if this.opts.backend != nil {
+        return int(atomic.LoadInt64(&this.numQueued) - atomic.LoadInt64(&this.numProcessed))
+    }
+    if this.logQueue == nil {
+        return 0 // 同步写
+    }
+    return this.logQueue.len()
+}
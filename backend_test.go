@@ -0,0 +1,189 @@
+package simlog
+
+import (
+    "os"
+    "path/filepath"
+    "sync"
+    "testing"
+)
+
+func newBackendLogger(t *testing.T, backend *Backend, dir, filename string) *SimLogger {
+    t.Helper()
+    logger := new(SimLogger)
+    if !logger.Init(WithBackend(backend), WithLogdir(dir), WithFilename(filename), EnableLineFeed(true)) {
+        t.Fatal("Init failed")
+    }
+    return logger
+}
+
+// 日志关闭（从后端摘下）后写的日志被丢弃，不会重新打开其日志文件
+func TestBackendWriteAfterClose(t *testing.T) {
+    dir := t.TempDir()
+    backend := NewBackend(16, 4)
+    a := newBackendLogger(t, backend, dir, "a.log")
+    b := newBackendLogger(t, backend, dir, "b.log")
+
+    a.Infof("before close")
+    a.Close()
+    if n, err := a.Infof("after close"); n != 0 || err != nil {
+        t.Errorf("write after Close: got (%d, %v), want (0, nil)", n, err)
+    }
+    b.Infof("b")
+    b.Close()
+    backend.Close()
+
+    if n := countLines(t, filepath.Join(dir, "a.log")); n != 1 {
+        t.Errorf("a.log has %d lines, want 1", n)
+    }
+    if stats := a.Stats(); stats.Written != 1 || stats.Dropped != 1 {
+        t.Errorf("Written %d Dropped %d, want 1 and 1", stats.Written, stats.Dropped)
+    }
+}
+
+// 与 Close 同时写：Close 返回后日志文件不再变化，每条日志写入或计入丢弃
+func TestBackendConcurrentWriteAndClose(t *testing.T) {
+    const writers, lines = 4, 500
+    dir := t.TempDir()
+    backend := NewBackend(64, 8)
+    defer backend.Close()
+    a := newBackendLogger(t, backend, dir, "a.log")
+    b := newBackendLogger(t, backend, dir, "b.log")
+
+    a.Infof("first")
+    a.Flush()
+    var wg sync.WaitGroup
+    for i := 0; i < writers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < lines; j++ {
+                a.Infof("line %d", j)
+            }
+        }()
+    }
+    a.Close()
+    fi, err := os.Stat(filepath.Join(dir, "a.log"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    wg.Wait()
+    b.Infof("flush the backend queue")
+    b.Close() // b 的日志在 a 的日志之后写，b 关闭时 a 的日志都已处理
+
+    if fi2, err := os.Stat(filepath.Join(dir, "a.log")); err != nil || fi2.Size() != fi.Size() {
+        t.Errorf("a.log changed after Close")
+    }
+    stats := a.Stats()
+    if n := countLines(t, filepath.Join(dir, "a.log")); int64(n) != stats.Written || stats.Written+stats.Dropped != 1+writers*lines {
+        t.Errorf("a.log has %d lines, Written %d Dropped %d, want %d in total", n, stats.Written, stats.Dropped, 1+writers*lines)
+    }
+}
+
+// 先关闭后端再关闭日志：后端在最后一个日志关闭时才停止，之前写的日志都写入
+func TestBackendCloseBeforeLoggers(t *testing.T) {
+    dir := t.TempDir()
+    backend := NewBackend(16, 4)
+    a := newBackendLogger(t, backend, dir, "a.log")
+    b := newBackendLogger(t, backend, dir, "b.log")
+    if refs := backend.Refs(); refs != 3 {
+        t.Fatalf("refs = %d, want 3", refs)
+    }
+
+    for i := 0; i < 100; i++ {
+        a.Info("a", i)
+        b.Info("b", i)
+    }
+    backend.Close()
+    if refs := backend.Refs(); refs != 2 {
+        t.Fatalf("refs after Backend.Close = %d, want 2", refs)
+    }
+    backend.Close() // 重复关闭不再释放
+    if refs := backend.Refs(); refs != 2 {
+        t.Fatalf("refs after second Backend.Close = %d, want 2", refs)
+    }
+
+    a.Info("a", 100) // 后端仍在运行
+    a.Close()
+    b.Close()
+    if refs := backend.Refs(); refs != 0 {
+        t.Fatalf("refs after closing loggers = %d, want 0", refs)
+    }
+    <-backend.exit
+    if n := countLines(t, filepath.Join(dir, "a.log")); n != 101 {
+        t.Errorf("a.log has %d lines, want 101", n)
+    }
+    if n := countLines(t, filepath.Join(dir, "b.log")); n != 100 {
+        t.Errorf("b.log has %d lines, want 100", n)
+    }
+}
+
+// 先关闭日志再关闭后端：Backend.Close 等写协程退出后返回，之后 Init 失败
+func TestBackendCloseAfterLoggers(t *testing.T) {
+    dir := t.TempDir()
+    backend := NewBackend(16, 4)
+    a := newBackendLogger(t, backend, dir, "shared.log")
+    b := newBackendLogger(t, backend, dir, "shared.log") // 共用一个文件句柄
+
+    for i := 0; i < 50; i++ {
+        a.Info("a", i)
+        b.Info("b", i)
+    }
+    a.Close()
+    b.Info("b", 50) // 文件仍被 b 使用
+    b.Close()
+    if refs := backend.Refs(); refs != 1 {
+        t.Fatalf("refs after closing loggers = %d, want 1", refs)
+    }
+    backend.Close()
+    select {
+    case <-backend.exit:
+    default:
+        t.Fatal("Backend.Close returned before the writer exited")
+    }
+    if n := countLines(t, filepath.Join(dir, "shared.log")); n != 101 {
+        t.Errorf("shared.log has %d lines, want 101", n)
+    }
+
+    var c SimLogger
+    if c.Init(WithBackend(backend), WithLogdir(dir)) {
+        t.Error("Init on a stopped backend succeeded")
+    }
+}
+
+// 日志和后端在不同协程中以任意顺序关闭，引用计数最终为0且写协程退出
+func TestBackendConcurrentClose(t *testing.T) {
+    for round := 0; round < 20; round++ {
+        dir := t.TempDir()
+        backend := NewBackend(8, 2)
+        loggers := make([]*SimLogger, 8)
+        for i := range loggers {
+            loggers[i] = newBackendLogger(t, backend, dir, "c.log")
+        }
+
+        var wg sync.WaitGroup
+        for _, logger := range loggers {
+            wg.Add(1)
+            go func(logger *SimLogger) {
+                defer wg.Done()
+                for i := 0; i < 20; i++ {
+                    logger.Info("x", i)
+                }
+                logger.Close()
+            }(logger)
+        }
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            backend.Close()
+        }()
+        wg.Wait()
+
+        <-backend.exit
+        if refs := backend.Refs(); refs != 0 {
+            t.Fatalf("round %d: refs = %d, want 0", round, refs)
+        }
+        if n := countLines(t, filepath.Join(dir, "c.log")); n != 160 {
+            t.Fatalf("round %d: c.log has %d lines, want 160", round, n)
+        }
+    }
+}
@@ -0,0 +1,78 @@
+package simlog
+
+import (
+    "os"
+    "strings"
+)
+
+// WithBackupCodec 滚动时以名为 name 的编解码器（见 RegisterCodec，如“zstd”或“gzip”）压缩滚动出的备份文件，
+// 压缩后的文件名为备份文件名加“.name”（如 test.log.1.zstd），和未压缩的备份一样按序号后移，ExtractRange 按文件名解压。
+// 压缩在滚动时（持有滚动的文件锁）进行，失败时保留未压缩的备份，并以 ErrRotateFailed 通知 WithErrorHandler。
+// 压缩的备份不建立索引（见 WithRotationIndex），清单（见 WithManifest）中的大小和校验和为压缩前的。
+// name 为空表示不压缩（默认），编解码器未注册时 Init 失败。
+func WithBackupCodec(name string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.backupCodecName = name
+        o.backupCodec = nil
+        if name == "" {
+            return
+        }
+        codec, err := lookupCodec(name)
+        if err != nil {
+            o.optionErr = err
+            return
+        }
+        o.backupCodec = codec
+    })
+}
+
+// 压缩的备份文件的扩展名，不压缩时为空
+func (this *SimLogger) backupExt() string {
+    if this.opts.backupCodec == nil {
+        return ""
+    }
+    return "." + this.opts.backupCodecName
+}
+
+// 以 codec 压缩备份文件 path 为 path+ext（保留修改时间），成功后删除 path 及其索引
+func compressBackup(path string, codec Codec, ext string) error {
+    fi, err := os.Stat(path)
+    if err != nil {
+        return err
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return err
+    }
+    encoded, err := codec.Encode(data)
+    if err != nil {
+        return err
+    }
+    tmpPath := path + ext + ".tmp"
+    if err = os.WriteFile(tmpPath, encoded, 0644); err == nil {
+        os.Chtimes(tmpPath, fi.ModTime(), fi.ModTime())
+        err = os.Rename(tmpPath, path+ext)
+    }
+    if err != nil {
+        os.Remove(tmpPath)
+        return err
+    }
+    os.Remove(path + indexSuffix)
+    return os.Remove(path)
+}
+
+// 按文件名（“备份文件名.编解码器名”）取得压缩的备份文件的编解码器，不是压缩的备份时第2个返回值为 false
+func backupCodecOf(path string) (Codec, bool) {
+    codecsMutex.RLock()
+    defer codecsMutex.RUnlock()
+    for name, codec := range codecs {
+        backup, ok := strings.CutSuffix(path, "."+name)
+        if !ok {
+            continue
+        }
+        if pos := strings.LastIndexByte(backup, '.'); pos >= 0 && isBackupNumber(backup[pos+1:]) {
+            return codec, true
+        }
+    }
+    return nil, false
+}
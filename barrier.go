@@ -0,0 +1,91 @@
+package simlog
+
+import (
+    "errors"
+    "sync/atomic"
+)
+
+// Barrier 日志屏障，见 SimLogger.Barrier
+type Barrier struct {
+    logger *SimLogger
+    target int64 // 屏障之前放入日志队列的日志条数
+}
+
+// putLog 先将 numQueued 加一再放入日志队列，所以 Barrier 取得的 numQueued 包括了屏障之前已放入的所有日志，
+// 也可能包括其它协程正在放入的（它们在日志队列中可能排在后面），对这些日志多等一会儿，但不会漏等。
+
+// Barrier 在日志流中设置屏障，返回的 Barrier 的 Wait 在屏障之前写的日志都写入日志文件（包括写出写缓冲）后返回，
+// 以便请求处理者在应答之前确保其审计日志已落盘，而不必 Flush 所有日志（Flush 即 Barrier().Wait()）。
+// 可以先设置屏障，做完其它事情后再 Wait，Wait 期间写协程每批日志都写出缓冲。
+// 同步写时日志在返回前已写入，有写缓冲时 Wait 写出缓冲。
+func (this *SimLogger) Barrier() *Barrier {
+    barrier := &Barrier{logger: this}
+    if this.opts.asyncWrite {
+        barrier.target = atomic.LoadInt64(&this.numQueued)
+    }
+    return barrier
+}
+
+// Done 屏障之前写的日志是否都已写入日志文件，不等待
+func (this *Barrier) Done() bool {
+    logger := this.logger
+    if !logger.opts.asyncWrite {
+        return logger.opts.writeBufferSize <= 0
+    }
+    return atomic.LoadInt32(&logger.closed) == 1 || logger.numDurable() >= this.target
+}
+
+// Wait 等待屏障之前写的日志都写入日志文件，
+// 异步写时如果写协程未正常运行则返回 ErrWriterDown（包装了写协程的错误），而不是一直等待。
+func (this *Barrier) Wait() error {
+    logger := this.logger
+    if !logger.opts.asyncWrite {
+        if logger.opts.writeBufferSize > 0 {
+            logger.flushBuffered(false)
+        }
+        return nil
+    }
+    if atomic.LoadInt32(&logger.closed) == 1 {
+        return nil // 关闭时已写完
+    }
+
+    atomic.AddInt32(&logger.flushWaiters, 1)
+    defer atomic.AddInt32(&logger.flushWaiters, -1)
+    logger.progressMutex.Lock()
+    defer logger.progressMutex.Unlock()
+    for logger.numDurable() < this.target {
+        if state := atomic.LoadInt32(&logger.writerState); state == writerFailing || state == writerRestarting {
+            return errors.Join(ErrWriterDown, logger.writerError)
+        }
+        if atomic.LoadInt32(&logger.writerState) == writerStopped {
+            break
+        }
+        logger.progressCond.Wait()
+    }
+    return nil
+}
+
+// 已写入日志文件（包括写出写缓冲）的日志条数
+func (this *SimLogger) numDurable() int64 {
+    if this.opts.writeBufferSize <= 0 {
+        return atomic.LoadInt64(&this.numProcessed) // 没有写缓冲，处理了即已写入
+    }
+    return atomic.LoadInt64(&this.numFlushed)
+}
+
+// 写出缓冲，batchLines 为正在写的这批中尚未计入 numProcessed 的日志条数
+func (this *SimLogger) flushLogFile(file *logFile, batchLines int) {
+    file.Flush()
+    this.markFlushed(batchLines)
+}
+
+// 记下缓冲已写出，并唤醒等待的 Barrier
+func (this *SimLogger) markFlushed(batchLines int) {
+    if this.opts.writeBufferSize <= 0 {
+        return
+    }
+    this.progressMutex.Lock()
+    atomic.StoreInt64(&this.numFlushed, atomic.LoadInt64(&this.numProcessed)+int64(batchLines))
+    this.progressMutex.Unlock()
+    this.progressCond.Broadcast()
+}
@@ -0,0 +1,124 @@
+package simlog
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+func newBarrierLogger(t *testing.T, dir string, opts ...LogOption) *SimLogger {
+    t.Helper()
+    opts = append([]LogOption{WithLogdir(dir), WithFilename("barrier.log"), EnableLineFeed(true), EnableRegistry(false)}, opts...)
+    logger := new(SimLogger)
+    if err := logger.InitE(opts...); err != nil {
+        t.Fatalf("InitE: %s", err.Error())
+    }
+    t.Cleanup(func() { logger.Close() })
+    return logger
+}
+
+// 读日志文件，检查是否包含 marker
+func logFileContains(t *testing.T, path, marker string) bool {
+    t.Helper()
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatalf("read %s: %s", path, err.Error())
+    }
+    return strings.Contains(string(data), marker)
+}
+
+// 多个协程各写一条日志后等待屏障，返回时各自的日志都已写入日志文件（写缓冲也已写出）
+func TestBarrierWaitAsync(t *testing.T) {
+    for _, bufferSize := range []int32{0, 64 * 1024} {
+        t.Run(fmt.Sprintf("buffer=%d", bufferSize), func(t *testing.T) {
+            dir := t.TempDir()
+            logger := newBarrierLogger(t, dir, WithWriteBufferSize(bufferSize), WithLogQueueSize(64))
+            path := filepath.Join(dir, "barrier.log")
+
+            stop := make(chan struct{})
+            var noise sync.WaitGroup
+            noise.Add(1)
+            go func() { // 使日志队列不空，写协程不会因队列空而写出缓冲
+                defer noise.Done()
+                for {
+                    select {
+                    case <-stop:
+                        return
+                    default:
+                        logger.Info("noise")
+                    }
+                }
+            }()
+
+            var wg sync.WaitGroup
+            for g := 0; g < 8; g++ {
+                wg.Add(1)
+                go func(g int) {
+                    defer wg.Done()
+                    for i := 0; i < 20; i++ {
+                        marker := fmt.Sprintf("marker-%d-%d;", g, i)
+                        logger.Info(marker)
+                        if err := logger.Barrier().Wait(); err != nil {
+                            t.Errorf("Wait: %s", err.Error())
+                            return
+                        }
+                        if !logFileContains(t, path, marker) {
+                            t.Errorf("%s not in the log file after Wait", marker)
+                            return
+                        }
+                    }
+                }(g)
+            }
+            wg.Wait()
+            close(stop)
+            noise.Wait()
+        })
+    }
+}
+
+// 同步写且有写缓冲时 Wait 写出缓冲，Done 在写出之前为 false
+func TestBarrierWaitSyncBuffered(t *testing.T) {
+    dir := t.TempDir()
+    logger := newBarrierLogger(t, dir, EnableAsyncWrite(false), WithWriteBufferSize(64*1024), WithFlushInterval(time.Hour))
+    path := filepath.Join(dir, "barrier.log")
+
+    logger.Info("buffered;")
+    barrier := logger.Barrier()
+    if barrier.Done() {
+        t.Error("Done with a write buffer returned true")
+    }
+    if err := barrier.Wait(); err != nil {
+        t.Fatalf("Wait: %s", err.Error())
+    }
+    if !logFileContains(t, path, "buffered;") {
+        t.Error("buffered line not in the log file after Wait")
+    }
+}
+
+// 关闭后 Wait 立即返回，Done 为 true
+func TestBarrierAfterClose(t *testing.T) {
+    logger := newBarrierLogger(t, t.TempDir())
+    logger.Info("x")
+    barrier := logger.Barrier()
+    logger.Close()
+
+    done := make(chan error)
+    go func() {
+        done <- barrier.Wait()
+    }()
+    select {
+    case err := <-done:
+        if err != nil {
+            t.Errorf("Wait after Close: %s", err.Error())
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("Wait after Close did not return")
+    }
+    if !barrier.Done() {
+        t.Error("Done after Close returned false")
+    }
+}
@@ -0,0 +1,88 @@
+package simlog
+
+import (
+    "path/filepath"
+    "runtime"
+    "runtime/debug"
+    "strings"
+)
+
+// WithCallerTrimPrefix 日志头中的调用者文件名去掉前缀 prefix（一般为模块根目录）后输出，
+// 如“[pkg/sub/file.go:12]”，而不是只输出文件名（不同包中的同名文件无法区分），也不是输出冗长的完整路径，
+// 不以 prefix 开头的文件仍只输出文件名。prefix 为空表示不去前缀（默认），
+// 为 CallerTrimModule 表示按编译信息中的主模块路径自动确定，见 CallerTrimModule 的说明。
+// JSON 等结构化格式中的 Caller.File 不受影响，总是为完整路径。
+func WithCallerTrimPrefix(prefix string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        if prefix == CallerTrimModule {
+            prefix = detectModulePrefix()
+        } else if prefix != "" && !strings.HasSuffix(prefix, "/") {
+            prefix += "/"
+        }
+        o.callerTrimPrefix = prefix
+    })
+}
+
+// CallerTrimModule 作为 WithCallerTrimPrefix 的参数时，按编译信息中的主模块路径（如“github.com/foo/bar”）去前缀，
+// 适用于以 -trimpath 编译（源代码文件路径以模块路径开头）的程序，
+// 取不到编译信息时不去前缀。
+const CallerTrimModule = "<module>"
+
+// 取得主模块路径作为调用者文件名的前缀
+func detectModulePrefix() string {
+    info, ok := debug.ReadBuildInfo()
+    if !ok || info.Main.Path == "" {
+        return ""
+    }
+    return info.Main.Path + "/"
+}
+
+// 日志头中输出的调用者文件名
+func (this *SimLogger) callerFilename(file string) string {
+    if prefix := this.opts.callerTrimPrefix; prefix != "" {
+        if rest, ok := strings.CutPrefix(file, prefix); ok && rest != "" {
+            return rest
+        }
+    }
+    return filepath.Base(file)
+}
+
+// Caller 调用者（写日志的源代码位置），
+// 在 JSON 格式中作为对象输出，以便日志界面链接到源代码。
+type Caller struct {
+    File string `json:"file"`           // 源代码文件（完整路径）
+    Line int    `json:"line"`           // 源代码行号
+    Func string `json:"func,omitempty"` // 函数名（不包含包路径），如“(*SimLogger).Infof”
+    Pkg  string `json:"pkg,omitempty"`  // 包路径，如“github.com/eyjian/simlog”
+
+    pc uintptr // 程序计数器，用于按需解析函数名和包路径
+}
+
+// 是否为空（未记录调用者）
+func (this Caller) empty() bool {
+    return this.File == "" || this.Line <= 0
+}
+
+// 解析出函数名和包路径，
+// 取函数名有额外开销，所以只在需要时（如输出 JSON 格式时）才解析。
+func (this Caller) resolve() Caller {
+    if this.Func == "" && this.pc != 0 {
+        if fn := runtime.FuncForPC(this.pc); fn != nil {
+            this.Pkg, this.Func = splitFuncName(fn.Name())
+        }
+    }
+    return this
+}
+
+// 将函数全名（如“github.com/eyjian/simlog.(*SimLogger).Infof”）拆分为包路径和函数名
+func splitFuncName(name string) (string, string) {
+    lastSlash := strings.LastIndexByte(name, '/')
+    if lastSlash < 0 {
+        lastSlash = 0
+    }
+    dot := strings.IndexByte(name[lastSlash:], '.')
+    if dot < 0 {
+        return "", name
+    }
+    return name[:lastSlash+dot], name[lastSlash+dot+1:]
+}
@@ -0,0 +1,151 @@
+package simlog
+
+import (
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// 定时输出的统计中列出的调用者个数
+const callerStatsDumpTop = 10
+
+// CallerStat 一个调用者（源代码位置）输出的日志统计，见 TopCallers
+type CallerStat struct {
+    File  string // 源代码文件名，同日志头中的（见 WithCallerTrimPrefix）
+    Line  int    // 源代码行号
+    Lines int64  // 自 Init 起输出的日志条数
+    Bytes int64  // 自 Init 起输出的日志字节数（含日志头）
+}
+
+// 调用者统计的键
+type callerKey struct {
+    file string
+    line int
+}
+
+// WithCallerStats 按调用者（源代码文件名和行号）统计输出的日志条数和字节数，用 TopCallers 取得，
+// 以找出刷屏的代码。只统计记录了调用者的日志，因此需同时开启 EnableLogCaller。
+// dumpInterval 大于0时，每隔 dumpInterval 输出一条 NOTICE 级别的日志，列出条数最多的前10个调用者，如：
+// simlog: top callers since start | 120345 lines 9.6MB main.go:42 | 3012 lines 180.2KB db.go:108
+func WithCallerStats(dumpInterval time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.callerStats = true
+        o.callerStatsInterval = dumpInterval
+    })
+}
+
+// 计入调用者统计
+func (this *SimLogger) addCallerStat(caller Caller, numBytes int) {
+    key := callerKey{file: caller.File, line: caller.Line}
+
+    this.callerStatsMutex.Lock()
+    stat := this.callerStats[key]
+    if stat == nil {
+        stat = &CallerStat{File: this.callerFilename(caller.File), Line: caller.Line}
+        this.callerStats[key] = stat
+    }
+    stat.Lines++
+    stat.Bytes += int64(numBytes)
+    this.callerStatsMutex.Unlock()
+}
+
+// TopCallers 返回自 Init 起输出日志条数最多的前 n 个调用者（n 小于等于0表示所有），条数相同时按字节数，
+// 未开启 WithCallerStats 时返回 nil。
+func (this *SimLogger) TopCallers(n int) []CallerStat {
+    if !this.opts.callerStats {
+        return nil
+    }
+
+    this.callerStatsMutex.Lock()
+    stats := make([]CallerStat, 0, len(this.callerStats))
+    for _, stat := range this.callerStats {
+        stats = append(stats, *stat)
+    }
+    this.callerStatsMutex.Unlock()
+
+    sort.Slice(stats, func(i, j int) bool {
+        if stats[i].Lines != stats[j].Lines {
+            return stats[i].Lines > stats[j].Lines
+        }
+        if stats[i].Bytes != stats[j].Bytes {
+            return stats[i].Bytes > stats[j].Bytes
+        }
+        if stats[i].File != stats[j].File {
+            return stats[i].File < stats[j].File
+        }
+        return stats[i].Line < stats[j].Line
+    })
+    if n > 0 && len(stats) > n {
+        stats = stats[:n]
+    }
+    return stats
+}
+
+// 启动调用者统计，dumpInterval 大于0时启动定时输出统计的协程
+func (this *SimLogger) startCallerStats() {
+    this.callerStats = make(map[callerKey]*CallerStat)
+    if this.opts.callerStatsInterval <= 0 {
+        return
+    }
+    this.callerStatsExit = make(chan struct{})
+    this.callerStatsDone = make(chan struct{})
+    go func() {
+        ticker := time.NewTicker(this.opts.callerStatsInterval)
+        defer ticker.Stop()
+        defer close(this.callerStatsDone)
+
+        for {
+            select {
+            case <-ticker.C:
+                this.putCallerStats()
+            case <-this.callerStatsExit:
+                return
+            }
+        }
+    }()
+}
+
+// 停止定时输出统计的协程
+func (this *SimLogger) stopCallerStats() {
+    if this.callerStatsExit != nil {
+        close(this.callerStatsExit)
+        <-this.callerStatsDone
+    }
+}
+
+// 输出条数最多的调用者，还没有统计时不输出
+func (this *SimLogger) putCallerStats() {
+    stats := this.TopCallers(callerStatsDumpTop)
+    if len(stats) == 0 {
+        return
+    }
+
+    var sb strings.Builder
+    sb.WriteString("simlog: top callers since start")
+    for _, stat := range stats {
+        sb.WriteString(" | ")
+        sb.WriteString(strconv.FormatInt(stat.Lines, 10))
+        sb.WriteString(" lines ")
+        sb.WriteString(formatBytes(stat.Bytes))
+        sb.WriteString(" ")
+        sb.WriteString(stat.File)
+        sb.WriteString(":")
+        sb.WriteString(strconv.Itoa(stat.Line))
+    }
+    this.putInternalLog(time.Now(), LL_NOTICE, sb.String())
+}
+
+// 以 B、KB、MB 或 GB 为单位格式化字节数
+func formatBytes(n int64) string {
+    switch {
+    case n >= 1<<30:
+        return strconv.FormatFloat(float64(n)/(1<<30), 'f', 1, 64) + "GB"
+    case n >= 1<<20:
+        return strconv.FormatFloat(float64(n)/(1<<20), 'f', 1, 64) + "MB"
+    case n >= 1<<10:
+        return strconv.FormatFloat(float64(n)/(1<<10), 'f', 1, 64) + "KB"
+    default:
+        return strconv.FormatInt(n, 10) + "B"
+    }
+}
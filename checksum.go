@@ -0,0 +1,108 @@
+package simlog
+
+import (
+    "bufio"
+    "fmt"
+    "hash/crc32"
+    "io"
+    "strings"
+    "sync/atomic"
+)
+
+// 校验和在日志行尾的标记，完整格式为：“ #crc32:xxxxxxxx”（8位十六进制小写）
+const checksumMark = " #crc32:"
+
+// EnableChecksum 是否在每行日志（裸日志除外）的行尾追加 CRC32 校验和，
+// 校验范围为校验和标记之前的整行内容（包括日志头和日志体，不包括行尾换行符），
+// 以便下游检测传输过程中（比如 NFS）造成的损坏，可用 VerifyLine 或 ChecksumReader 校验。
+// 开启时带校验和的日志总是以换行符结尾（不论 EnableLineFeed），否则下一条日志接在校验和之后，使其无法校验。
+func EnableChecksum(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        if enabled {
+            atomic.StoreInt32(&o.enableChecksum, 1)
+        } else {
+            atomic.StoreInt32(&o.enableChecksum, 0)
+        }
+    })
+}
+
+// 是否开启了校验和
+func (this *SimLogger) EnabledChecksum() bool {
+    return atomic.LoadInt32(&this.opts.enableChecksum) == 1
+}
+
+// enabled为true表示在行尾追加校验和
+func (this *SimLogger) EnableChecksum(enabled bool) {
+    this.checkInit()
+    if enabled {
+        atomic.StoreInt32(&this.opts.enableChecksum, 1)
+    } else {
+        atomic.StoreInt32(&this.opts.enableChecksum, 0)
+    }
+}
+
+// 在行尾换行符之前插入校验和，没有换行符时补上，以使校验和总是在行尾
+func appendChecksum(logLine string) string {
+    n := len(logLine)
+    for n > 0 && logLine[n-1] == '\n' {
+        n--
+    }
+    content, lineFeed := logLine[:n], logLine[n:]
+    if lineFeed == "" {
+        lineFeed = "\n"
+    }
+    return fmt.Sprintf("%s%s%08x%s", content, checksumMark, crc32.ChecksumIEEE([]byte(content)), lineFeed)
+}
+
+// VerifyLine 校验一行日志的校验和（行尾的换行符可有可无），
+// hasChecksum 为 false 表示该行不带校验和，此时 valid 也为 false。
+// 注意日志体中如果含有换行符，则一条日志会被拆成多行，只有最后一行带有校验和。
+func VerifyLine(line string) (valid bool, hasChecksum bool) {
+    line = strings.TrimRight(line, "\r\n")
+    pos := strings.LastIndex(line, checksumMark)
+    if pos < 0 || len(line)-pos-len(checksumMark) != 8 {
+        return false, false
+    }
+
+    var checksum uint32
+    if _, err := fmt.Sscanf(line[pos+len(checksumMark):], "%08x", &checksum); err != nil {
+        return false, false
+    }
+    return crc32.ChecksumIEEE([]byte(line[:pos])) == checksum, true
+}
+
+// ChecksumReader 带校验的日志读取器，逐行读取日志并校验
+type ChecksumReader struct {
+    scanner   *bufio.Scanner
+    Corrupted int64 // 校验失败的行数
+    Unchecked int64 // 不带校验和的行数
+}
+
+// NewChecksumReader 创建带校验的日志读取器
+func NewChecksumReader(r io.Reader) *ChecksumReader {
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+    return &ChecksumReader{
+        scanner: scanner,
+    }
+}
+
+// ReadLine 读取一行日志（不包含行尾换行符），
+// 返回的 valid 为 false 表示该行校验失败或不带校验和，读到文件尾时返回 io.EOF。
+func (this *ChecksumReader) ReadLine() (line string, valid bool, err error) {
+    if !this.scanner.Scan() {
+        if err = this.scanner.Err(); err == nil {
+            err = io.EOF
+        }
+        return "", false, err
+    }
+
+    line = this.scanner.Text()
+    valid, hasChecksum := VerifyLine(line)
+    if !hasChecksum {
+        this.Unchecked++
+    } else if !valid {
+        this.Corrupted++
+    }
+    return line, valid, nil
+}
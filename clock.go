@@ -0,0 +1,78 @@
+package simlog
+
+import (
+    "fmt"
+    "strconv"
+    "sync/atomic"
+    "time"
+)
+
+// WithClockSkewThreshold 检测相邻两条日志之间墙上时钟的回退（比如 NTP 校时），
+// 回退超过 threshold 时先插入一条 NOTICE 级别的标记日志，注明回退的时长，
+// 以免事故时间线被悄无声息的时钟跳变打乱。小于等于0表示不检测（默认）。
+func WithClockSkewThreshold(threshold time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        atomic.StoreInt64(&o.clockSkewThreshold, int64(threshold))
+    })
+}
+
+// EnableElapsedTime 是否在日志头的时间之后输出自 Init 起经过的单调时长，格式如“[+12.345678s]”，
+// 不受墙上时钟跳变的影响。
+func EnableElapsedTime(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        if enabled {
+            atomic.StoreInt32(&o.enableElapsedTime, 1)
+        } else {
+            atomic.StoreInt32(&o.enableElapsedTime, 0)
+        }
+    })
+}
+
+// 是否开启了输出单调时长
+func (this *SimLogger) EnabledElapsedTime() bool {
+    return atomic.LoadInt32(&this.opts.enableElapsedTime) == 1
+}
+
+// enabled为true表示在日志头中输出自 Init 起经过的单调时长
+func (this *SimLogger) EnableElapsedTime(enabled bool) {
+    this.checkInit()
+    if enabled {
+        atomic.StoreInt32(&this.opts.enableElapsedTime, 1)
+    } else {
+        atomic.StoreInt32(&this.opts.enableElapsedTime, 0)
+    }
+}
+
+// 返回墙上时钟相对上一条日志回退的时长，未回退或回退不超过阈值时返回0
+func (this *SimLogger) detectClockSkew(now time.Time) time.Duration {
+    threshold := time.Duration(atomic.LoadInt64(&this.opts.clockSkewThreshold))
+    if threshold <= 0 {
+        return 0
+    }
+
+    this.clockMutex.Lock()
+    last := this.lastLogTime
+    this.lastLogTime = now
+    this.clockMutex.Unlock()
+    if last.IsZero() {
+        return 0
+    }
+
+    // Round(0) 去掉单调时钟读数，得到墙上时钟的差值
+    wall := now.Round(0).Sub(last.Round(0))
+    monotonic := now.Sub(last)
+    if skew := monotonic - wall; skew >= threshold {
+        return skew
+    }
+    return 0
+}
+
+// 输出时钟回退的标记日志
+func (this *SimLogger) putClockSkewMarker(now time.Time, skew time.Duration) {
+    this.putInternalLog(now, LL_NOTICE, fmt.Sprintf("simlog: wall clock stepped backward by %s", skew.String()))
+}
+
+// 格式化单调时长，格式如“[+12.345678s]”
+func formatElapsed(elapsed time.Duration) string {
+    return "[+" + strconv.FormatFloat(elapsed.Seconds(), 'f', 6, 64) + "s]"
+}
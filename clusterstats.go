@@ -0,0 +1,186 @@
+package simlog
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "sync/atomic"
+    "time"
+)
+import (
+    "github.com/gofrs/flock"
+)
+
+// ClusterStatsFilename 日志目录中多进程共享的统计文件名，见 WithClusterStats
+const ClusterStatsFilename = "simlog-stats.json"
+
+// 超过此时长未更新的进程从统计文件中移除，以免已退出的进程一直累积
+const clusterStatsExpiry = 24 * time.Hour
+
+// ProcessStats 统计文件中一个进程（的一个日志）的统计
+type ProcessStats struct {
+    Host       string        `json:"host"`
+    PID        int           `json:"pid"`
+    Log        string        `json:"log"`      // 日志文件的路径（不带日期子目录）
+    Started    time.Time     `json:"started"`  // Init 的时间
+    Updated    time.Time     `json:"updated"`  // 最后更新的时间
+    Interval   time.Duration `json:"interval"` // 更新间隔
+    Closed     bool          `json:"closed"`   // 日志是否已关闭
+    Written    int64         `json:"written"`
+    Dropped    int64         `json:"dropped"`
+    Rotations  int64         `json:"rotations"` // 本进程滚动日志文件的次数
+    SinkErrors int64         `json:"sink_errors"`
+    Fallback   int64         `json:"fallback"`
+}
+
+// ClusterStats 共用一个日志目录的各进程的统计及其合计，见 ReadClusterStats
+type ClusterStats struct {
+    Processes  []ProcessStats // 各进程的统计，按主机、进程号和日志文件排列
+    Alive      int            // 仍在更新（未关闭且在3个更新间隔内更新过）的进程数
+    Written    int64
+    Dropped    int64
+    Rotations  int64
+    SinkErrors int64
+    Fallback   int64
+}
+
+// 统计文件的内容，键为“主机:进程号:日志文件”
+type clusterStatsFile struct {
+    Processes map[string]ProcessStats `json:"processes"`
+}
+
+// WithClusterStats 每隔 interval 将本进程的日志统计（写入、丢弃、滚动次数等）写到日志目录中的共享统计文件（见 ClusterStatsFilename），
+// 各进程以主机、进程号和日志文件区分，在文件锁下更新（和滚动一样由 WithLockDir 和 EnableRotationLock 决定），关闭日志时写最后一次。
+// 运维可用 ReadClusterStats 得到共用一个日志的所有进程的合计（如总丢弃数和总滚动次数）。小于等于0表示不写（默认）。
+func WithClusterStats(interval time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.clusterStatsInterval = interval
+    })
+}
+
+// ReadClusterStats 读取日志目录 dir 中的共享统计文件，返回各进程的统计及其合计
+func ReadClusterStats(dir string) (*ClusterStats, error) {
+    file, err := readClusterStatsFile(filepath.Join(dir, ClusterStatsFilename))
+    if err != nil {
+        return nil, err
+    }
+
+    stats := new(ClusterStats)
+    now := time.Now()
+    for _, process := range file.Processes {
+        stats.Processes = append(stats.Processes, process)
+        if !process.Closed && now.Sub(process.Updated) <= 3*process.Interval {
+            stats.Alive++
+        }
+        stats.Written += process.Written
+        stats.Dropped += process.Dropped
+        stats.Rotations += process.Rotations
+        stats.SinkErrors += process.SinkErrors
+        stats.Fallback += process.Fallback
+    }
+    sort.Slice(stats.Processes, func(i, j int) bool {
+        a, b := stats.Processes[i], stats.Processes[j]
+        if a.Host != b.Host {
+            return a.Host < b.Host
+        }
+        if a.PID != b.PID {
+            return a.PID < b.PID
+        }
+        return a.Log < b.Log
+    })
+    return stats, nil
+}
+
+func readClusterStatsFile(path string) (*clusterStatsFile, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    var file clusterStatsFile
+    if err = json.Unmarshal(data, &file); err != nil {
+        return nil, err
+    }
+    return &file, nil
+}
+
+// 启动定时写共享统计文件的协程
+func (this *SimLogger) startClusterStats() {
+    this.clusterStatsExit = make(chan struct{})
+    this.clusterStatsDone = make(chan struct{})
+    go func() {
+        ticker := time.NewTicker(this.opts.clusterStatsInterval)
+        defer ticker.Stop()
+        defer close(this.clusterStatsDone)
+
+        for {
+            select {
+            case <-ticker.C:
+                this.writeClusterStats(false)
+            case <-this.clusterStatsExit:
+                this.writeClusterStats(true)
+                return
+            }
+        }
+    }()
+}
+
+// 停止定时写共享统计文件的协程，并写最后一次
+func (this *SimLogger) stopClusterStats() {
+    close(this.clusterStatsExit)
+    <-this.clusterStatsDone
+}
+
+// 在文件锁下更新共享统计文件中本进程的统计，并移除过期的进程
+func (this *SimLogger) writeClusterStats(closed bool) {
+    dir := this.getLogDir()
+    path := filepath.Join(dir, ClusterStatsFilename)
+    if !this.opts.noRotationLock {
+        fileLock := flock.New(this.lockFilepath(path))
+        if err := fileLock.Lock(); err != nil {
+            return
+        }
+        defer fileLock.Unlock()
+    }
+
+    file, err := readClusterStatsFile(path)
+    if err != nil || file.Processes == nil {
+        file = &clusterStatsFile{Processes: make(map[string]ProcessStats)}
+    }
+    now := time.Now()
+    for key, process := range file.Processes {
+        if now.Sub(process.Updated) > clusterStatsExpiry {
+            delete(file.Processes, key)
+        }
+    }
+
+    host, _ := os.Hostname()
+    process := ProcessStats{
+        Host:       host,
+        PID:        os.Getpid(),
+        Log:        this.baseFilepath,
+        Started:    this.startTime,
+        Updated:    now,
+        Interval:   this.opts.clusterStatsInterval,
+        Closed:     closed,
+        Written:    atomic.LoadInt64(&this.numWritten),
+        Dropped:    atomic.LoadInt64(&this.numDropped),
+        Rotations:  atomic.LoadInt64(&this.numRotations),
+        SinkErrors: atomic.LoadInt64(&this.numSinkErrors),
+        Fallback:   atomic.LoadInt64(&this.numFallback),
+    }
+    file.Processes[fmt.Sprintf("%s:%d:%s", process.Host, process.PID, process.Log)] = process
+
+    data, err := json.MarshalIndent(file, "", "  ")
+    if err != nil {
+        return
+    }
+    tmpPath := fmt.Sprintf("%s.%d.tmp", path, process.PID)
+    if err = os.WriteFile(tmpPath, data, 0644); err != nil {
+        return
+    }
+    if os.Rename(tmpPath, path) != nil {
+        os.Remove(tmpPath)
+    }
+}
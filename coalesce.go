@@ -0,0 +1,53 @@
+package simlog
+
+import (
+    "sync"
+)
+
+// 进程内按日志文件合并写的后端，键为日志文件清理后的绝对路径，见 EnableWriteCoalescing
+var coalescers struct {
+    mutex    sync.Mutex
+    backends map[string]*Backend
+}
+
+// EnableWriteCoalescing 是否合并进程内写同一个日志文件的 SimLogger 的写（默认为 false）。
+// 开启后，写同一个日志文件（按清理后的绝对路径判断，如“./log/a.log”和“log/a.log”为同一个）的 SimLogger
+// 自动共用一个内部的写日志后端（同 WithBackend），只有一个写协程和一个文件句柄，
+// 进程内不再争抢文件锁，各 SimLogger 的日志也不会在写缓冲中交错。
+// 后端的日志队列大小和批量数取第一个 SimLogger 的 WithLogQueueSize 和 WithBatchNumber，
+// 在写这个日志文件的最后一个 SimLogger 关闭时停止。已设置 WithBackend 或不写日志文件时不生效。
+func EnableWriteCoalescing(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.coalesce = enabled
+    })
+}
+
+// 挂到写 logger 的日志文件的合并写后端上，没有时创建
+func attachCoalescer(logger *SimLogger) *Backend {
+    coalescers.mutex.Lock()
+    defer coalescers.mutex.Unlock()
+
+    path := logger.baseFilepath
+    if backend := coalescers.backends[path]; backend != nil && backend.attach(logger) {
+        return backend
+    }
+    backend := NewBackend(logger.opts.logQueueSize, logger.opts.batchNumber)
+    backend.attach(logger)
+    backend.Close() // 不由创建者持有，最后一个 SimLogger 关闭时停止
+    if coalescers.backends == nil {
+        coalescers.backends = make(map[string]*Backend)
+    }
+    coalescers.backends[path] = backend
+    return backend
+}
+
+// logger 关闭后调用，合并写后端已停止时移除
+func detachCoalescer(logger *SimLogger) {
+    coalescers.mutex.Lock()
+    defer coalescers.mutex.Unlock()
+
+    path := logger.baseFilepath
+    if backend := coalescers.backends[path]; backend == logger.opts.backend && backend.Refs() == 0 {
+        delete(coalescers.backends, path)
+    }
+}
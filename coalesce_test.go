@@ -0,0 +1,74 @@
+package simlog
+
+import (
+    "path/filepath"
+    "sync"
+    "testing"
+)
+
+// 多个 SimLogger 同时写同一个日志文件并在任意时刻关闭：每条日志恰好写入一次，
+// 最后一个关闭后合并写后端停止并被移除
+func TestWriteCoalescingConcurrentClose(t *testing.T) {
+    const loggers, lines = 8, 200
+    for round := 0; round < 10; round++ {
+        dir := t.TempDir()
+        var wg sync.WaitGroup
+        for i := 0; i < loggers; i++ {
+            wg.Add(1)
+            go func(i int) {
+                defer wg.Done()
+                logger := new(SimLogger)
+                // 用不同的写法指定同一个日志文件
+                logdir := dir
+                if i%2 == 1 {
+                    logdir = filepath.Join(dir, "sub", "..")
+                }
+                if err := logger.InitE(WithLogdir(logdir), WithFilename("c.log"), EnableLineFeed(true),
+                    EnableWriteCoalescing(true), WithLogQueueSize(16), EnableRegistry(false)); err != nil {
+                    t.Errorf("InitE: %s", err.Error())
+                    return
+                }
+                for j := 0; j < lines; j++ {
+                    logger.Infof("%d-%d", i, j)
+                }
+                logger.Close()
+                if stats := logger.Stats(); stats.Written != lines || stats.Dropped != 0 {
+                    t.Errorf("logger %d: Written %d Dropped %d, want %d and 0", i, stats.Written, stats.Dropped, lines)
+                }
+            }(i)
+        }
+        wg.Wait()
+
+        if n := countLines(t, filepath.Join(dir, "c.log")); n != loggers*lines {
+            t.Fatalf("round %d: c.log has %d lines, want %d", round, n, loggers*lines)
+        }
+        coalescers.mutex.Lock()
+        backend := coalescers.backends[filepath.Join(dir, "c.log")]
+        coalescers.mutex.Unlock()
+        if backend != nil {
+            t.Fatalf("round %d: coalescing backend not removed, refs %d", round, backend.Refs())
+        }
+    }
+}
+
+// 写同一个文件的 SimLogger 共用一个后端，都关闭后引用计数为0
+func TestWriteCoalescingSharesBackend(t *testing.T) {
+    dir := t.TempDir()
+    var a, b SimLogger
+    for _, logger := range []*SimLogger{&a, &b} {
+        if err := logger.InitE(WithLogdir(dir), WithFilename("s.log"), EnableWriteCoalescing(true), EnableRegistry(false)); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if a.opts.backend == nil || a.opts.backend != b.opts.backend {
+        t.Fatal("loggers of the same file do not share a backend")
+    }
+    if refs := a.opts.backend.Refs(); refs != 2 {
+        t.Errorf("refs = %d, want 2", refs)
+    }
+    a.Close()
+    b.Close()
+    if refs := a.opts.backend.Refs(); refs != 0 {
+        t.Errorf("refs after Close = %d, want 0", refs)
+    }
+}
@@ -0,0 +1,120 @@
+package simlog
+
+import (
+    "bytes"
+    "compress/gzip"
+    "fmt"
+    "io"
+    "regexp"
+    "sort"
+    "strconv"
+    "sync"
+)
+
+// Codec 压缩或序列化的编解码器，须可被并发调用
+type Codec interface {
+    Encode(data []byte) ([]byte, error)
+    Decode(data []byte) ([]byte, error)
+}
+
+// 编解码器名：小写字母、数字、点、下划线和连字符，最长32个字符，以便作为压缩的日志体的标记（如“@lz4+b64:”）
+var codecNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+const maxCodecNameLength = 32
+
+var (
+    codecsMutex sync.RWMutex
+    codecs      = map[string]Codec{
+        "zstd": zstdCodec{},
+        "gzip": gzipCodec{},
+    }
+)
+
+// RegisterCodec 注册名为 name 的编解码器，供 WithBodyCodec、WithNetworkCodec 和 WithBackupCodec 按名字使用，
+// 以便第三方加入 lz4、snappy 或私有的编码，而不必修改本包。
+// 内置“zstd”和“gzip”，可被替换。name 不合法（小写字母、数字、点、下划线和连字符，最长32个字符，不能全为数字）
+// 或 codec 为 nil 时返回错误。
+func RegisterCodec(name string, codec Codec) error {
+    if len(name) > maxCodecNameLength || !codecNamePattern.MatchString(name) || isBackupNumber(name) {
+        return fmt.Errorf("simlog: invalid codec name %q", name)
+    }
+    if codec == nil {
+        return fmt.Errorf("simlog: codec %q is nil", name)
+    }
+    codecsMutex.Lock()
+    defer codecsMutex.Unlock()
+    codecs[name] = codec
+    return nil
+}
+
+// Codecs 返回已注册的编解码器名，按名字排列
+func Codecs() []string {
+    codecsMutex.RLock()
+    defer codecsMutex.RUnlock()
+    names := make([]string, 0, len(codecs))
+    for name := range codecs {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// GetCodec 返回名为 name 的编解码器，未注册时第2个返回值为 false
+func GetCodec(name string) (Codec, bool) {
+    codecsMutex.RLock()
+    defer codecsMutex.RUnlock()
+    codec, ok := codecs[name]
+    return codec, ok
+}
+
+// 是否为备份文件的序号，编解码器名也是备份文件的扩展名（见 WithBackupCodec），不能与序号混淆
+func isBackupNumber(name string) bool {
+    _, err := strconv.Atoi(name)
+    return err == nil
+}
+
+// 取得编解码器，未注册时返回错误，用于选项
+func lookupCodec(name string) (Codec, error) {
+    codec, ok := GetCodec(name)
+    if !ok {
+        return nil, fmt.Errorf("simlog: codec %q is not registered", name)
+    }
+    return codec, nil
+}
+
+// zstd 编解码器
+type zstdCodec struct{}
+
+func (zstdCodec) Encode(data []byte) ([]byte, error) {
+    zstdOnce.Do(initZstd)
+    return zstdEncoder.EncodeAll(data, nil), nil
+}
+
+func (zstdCodec) Decode(data []byte) ([]byte, error) {
+    zstdOnce.Do(initZstd)
+    return zstdDecoder.DecodeAll(data, nil)
+}
+
+// gzip 编解码器
+type gzipCodec struct{}
+
+func (gzipCodec) Encode(data []byte) ([]byte, error) {
+    var buf bytes.Buffer
+    zw := gzip.NewWriter(&buf)
+    if _, err := zw.Write(data); err != nil {
+        return nil, err
+    }
+    if err := zw.Close(); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decode(data []byte) ([]byte, error) {
+    zr, err := gzip.NewReader(bytes.NewReader(data))
+    if err != nil {
+        return nil, err
+    }
+    defer zr.Close()
+    return io.ReadAll(zr)
+}
@@ -0,0 +1,90 @@
+package simlog
+
+import (
+    "bytes"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+// 不合法的编解码器名或 nil 编解码器返回错误，不 panic
+func TestRegisterCodecInvalid(t *testing.T) {
+    for _, name := range []string{"", "LZ4", "a/b", "1", strings.Repeat("x", maxCodecNameLength+1)} {
+        if err := RegisterCodec(name, gzipCodec{}); err == nil {
+            t.Errorf("RegisterCodec(%q) = nil, want an error", name)
+        }
+    }
+    if err := RegisterCodec("simlog-test-nil", nil); err == nil {
+        t.Error("RegisterCodec with a nil codec = nil, want an error")
+    }
+    if err := RegisterCodec("simlog-test-gzip", gzipCodec{}); err != nil {
+        t.Fatalf("RegisterCodec: %s", err.Error())
+    }
+    if _, ok := GetCodec("simlog-test-gzip"); !ok {
+        t.Error("registered codec not found")
+    }
+}
+
+// WithBackupCodec 时滚动出的备份被压缩、按序号后移，ExtractRange 可解压读出
+func TestBackupCodecRotation(t *testing.T) {
+    dir := t.TempDir()
+    logger := new(SimLogger)
+    err := logger.InitE(WithLogdir(dir), WithFilename("codec.log"), WithFilesize(256), WithBackupNumber(4),
+        WithBackupCodec("zstd"), EnableAsyncWrite(false), EnableLineFeed(true), EnableRegistry(false))
+    if err != nil {
+        t.Fatalf("InitE: %s", err.Error())
+    }
+    for i := 0; i < 12; i++ {
+        logger.Infof("backup-codec-%02d %s", i, strings.Repeat("x", 64))
+    }
+    logger.Close()
+
+    path := filepath.Join(dir, "codec.log")
+    var files []string
+    if _, err := os.Stat(path); err == nil {
+        files = append(files, path)
+    }
+    for i := 1; i <= 3; i++ {
+        if _, err := os.Stat(fmt.Sprintf("%s.%d", path, i)); err == nil {
+            t.Errorf("uncompressed backup %s.%d left", path, i)
+        }
+        if _, err := os.Stat(fmt.Sprintf("%s.%d.zstd", path, i)); err != nil {
+            t.Fatalf("compressed backup: %s", err.Error())
+        }
+        files = append(files, fmt.Sprintf("%s.%d.zstd", path, i))
+    }
+
+    var buf bytes.Buffer
+    if err := ExtractRange(files, time.Time{}, time.Time{}, &buf); err != nil {
+        t.Fatalf("ExtractRange: %s", err.Error())
+    }
+    var numbers []int
+    for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+        var n int
+        if pos := strings.Index(line, "backup-codec-"); pos >= 0 {
+            fmt.Sscanf(line[pos:], "backup-codec-%d", &n)
+            numbers = append(numbers, n)
+        }
+    }
+    if len(numbers) == 0 || numbers[len(numbers)-1] != 11 {
+        t.Fatalf("extracted %v, want lines up to 11", numbers)
+    }
+    for i := 1; i < len(numbers); i++ {
+        if numbers[i] != numbers[i-1]+1 {
+            t.Errorf("extracted %v, want consecutive lines", numbers)
+            break
+        }
+    }
+}
+
+// 编解码器未注册时 Init 失败
+func TestBackupCodecUnregistered(t *testing.T) {
+    logger := new(SimLogger)
+    if err := logger.InitE(WithLogdir(t.TempDir()), WithBackupCodec("simlog-test-missing"), EnableRegistry(false)); err == nil {
+        logger.Close()
+        t.Error("InitE with an unregistered backup codec = nil, want an error")
+    }
+}
@@ -0,0 +1,56 @@
+package simlog
+
+import (
+    "strings"
+    "sync/atomic"
+)
+
+// 各日志级别打屏时的 ANSI 颜色，为空表示不着色
+var screenColors = [...]string{
+    LL_FATAL:   "\x1b[1;31m", // 粗体红
+    LL_ERROR:   "\x1b[31m",   // 红
+    LL_WARNING: "\x1b[33m",   // 黄
+    LL_NOTICE:  "\x1b[36m",   // 青
+    LL_INFO:    "",
+    LL_DEBUG:   "\x1b[90m", // 灰
+    LL_DETAIL:  "\x1b[90m",
+    LL_TRACE:   "\x1b[90m",
+    LL_RAW:     "",
+}
+
+const colorReset = "\x1b[0m"
+
+// EnableScreenColor 是否按日志级别给打屏的日志着色（ANSI 转义序列），只影响打屏，不影响日志文件
+func EnableScreenColor(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        if enabled {
+            atomic.StoreInt32(&o.screenColor, 1)
+        } else {
+            atomic.StoreInt32(&o.screenColor, 0)
+        }
+    })
+}
+
+// 是否开启了打屏着色
+func (this *SimLogger) EnabledScreenColor() bool {
+    return atomic.LoadInt32(&this.opts.screenColor) == 1
+}
+
+// enabled为true表示按日志级别给打屏的日志着色
+func (this *SimLogger) EnableScreenColor(enabled bool) {
+    this.checkInit()
+    if enabled {
+        atomic.StoreInt32(&this.opts.screenColor, 1)
+    } else {
+        atomic.StoreInt32(&this.opts.screenColor, 0)
+    }
+}
+
+// 给打屏的日志行着色，行尾的换行符留在颜色之外，以免颜色延续到下一行
+func (this *SimLogger) colorize(logLevel LogLevel, logLine string) string {
+    if !this.EnabledScreenColor() || logLevel < LL_FATAL || logLevel > LL_RAW || screenColors[logLevel] == "" {
+        return logLine
+    }
+    body := strings.TrimSuffix(logLine, "\n")
+    return screenColors[logLevel] + body + colorReset + logLine[len(body):]
+}
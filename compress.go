@@ -0,0 +1,112 @@
+package simlog
+
+import (
+    "encoding/base64"
+    "fmt"
+    "strings"
+    "sync"
+)
+import (
+    "github.com/klauspost/compress/zstd"
+)
+
+// 压缩日志体的标记，完整格式为：“@编解码器名+b64:”后跟压缩再 base64 编码的日志体，如“@zstd+b64:”
+const (
+    compressedMarkPrefix = "@"
+    compressedMarkSuffix = "+b64:"
+)
+
+// 默认压缩日志体的编解码器
+const defaultBodyCodec = "zstd"
+
+var (
+    zstdOnce    sync.Once
+    zstdEncoder *zstd.Encoder // EncodeAll 可并发调用
+    zstdDecoder *zstd.Decoder // DecodeAll 可并发调用
+)
+
+// WithBodyCompression 日志体超过 threshold 字节时，以 zstd（或 WithBodyCodec 设置的编解码器）压缩并 base64 编码后输出，
+// 并以“@zstd+b64:”标记，ParseLine 和 DecompressBody 会自动解压，
+// 供偶尔需要记录数 MB 数据块的服务使用。小于等于0表示不压缩（默认），裸日志总是不压缩。
+// 压缩只作用于写入日志文件的日志行，观察者收到的仍为原始日志体。
+func WithBodyCompression(threshold int) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.compressThreshold = threshold
+    })
+}
+
+// WithBodyCodec 设置 WithBodyCompression 压缩日志体的编解码器（见 RegisterCodec，默认为“zstd”），
+// 标记相应地为“@名字+b64:”，解压时按标记中的名字找编解码器，因此读日志的程序也须注册同名的编解码器。
+// 编解码器未注册时 Init 失败。
+func WithBodyCodec(name string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        codec, err := lookupCodec(name)
+        if err != nil {
+            o.optionErr = err
+            return
+        }
+        o.bodyCodecName = name
+        o.bodyCodec = codec
+    })
+}
+
+func initZstd() {
+    zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+    zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+}
+
+// 压缩日志体，日志体末尾的换行符保留在压缩之外，压缩失败时原样返回
+func (this *SimLogger) compressBody(body string) string {
+    name, codec := this.opts.bodyCodecName, this.opts.bodyCodec
+    if codec == nil {
+        name, codec = defaultBodyCodec, zstdCodec{}
+    }
+
+    trimmed := strings.TrimSuffix(body, "\n")
+    compressed, err := codec.Encode([]byte(trimmed))
+    if err != nil {
+        return body
+    }
+    return compressedMarkPrefix + name + compressedMarkSuffix + base64.StdEncoding.EncodeToString(compressed) + body[len(trimmed):]
+}
+
+// DecompressBody 解压 WithBodyCompression 压缩的日志体，
+// 第2个返回值表示 body 是否为压缩的日志体，未压缩（包括标记中的编解码器未注册）的原样返回。
+func DecompressBody(body string) (string, bool, error) {
+    name, encoded, ok := cutCompressedMark(body)
+    if !ok {
+        return body, false, nil
+    }
+    codec, ok := GetCodec(name)
+    if !ok {
+        return body, false, nil
+    }
+    trimmed := strings.TrimSuffix(encoded, "\n")
+    compressed, err := base64.StdEncoding.DecodeString(trimmed)
+    if err != nil {
+        return body, true, err
+    }
+
+    decompressed, err := codec.Decode(compressed)
+    if err != nil {
+        return body, true, fmt.Errorf("simlog: decode body with codec %s: %w", name, err)
+    }
+    return string(decompressed) + encoded[len(trimmed):], true, nil
+}
+
+// 去掉压缩的日志体的标记，返回编解码器名和标记之后的部分
+func cutCompressedMark(body string) (string, string, bool) {
+    rest, ok := strings.CutPrefix(body, compressedMarkPrefix)
+    if !ok {
+        return "", "", false
+    }
+    head := rest
+    if len(head) > maxCodecNameLength+len(compressedMarkSuffix) {
+        head = head[:maxCodecNameLength+len(compressedMarkSuffix)]
+    }
+    end := strings.Index(head, compressedMarkSuffix)
+    if end <= 0 || end > maxCodecNameLength || !codecNamePattern.MatchString(rest[:end]) {
+        return "", "", false
+    }
+    return rest[:end], rest[end+len(compressedMarkSuffix):], true
+}
@@ -0,0 +1,6 @@
+//go:build !js && !wasip1
+
+package simlog
+
+// 非 WASM 平台正常写日志文件，参见 console_wasm.go
+const consoleOnly = false
@@ -0,0 +1,8 @@
+//go:build js || wasip1
+
+package simlog
+
+// WASM（GOOS=js 或 wasip1）下一般没有可用的文件系统和文件锁，
+// 日志总是不写日志文件而打屏（js 下即浏览器的控制台），
+// 可用 WithScreenWriter 换为自定义的输出，以便通过 simlog 写日志的共享代码在 WASM 前端中照常编译运行。
+const consoleOnly = true
@@ -0,0 +1,218 @@
+package simlog
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "os/exec"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// ControlFDEnv 向子进程传递日志控制通道的环境变量，值为子进程中可读的文件描述符，见 ControlChannel
+const ControlFDEnv = "SIMLOG_CONTROL_FD"
+
+// 向一个子进程发送控制命令的超时
+const controlSendTimeout = time.Second
+
+// 从父进程继承的控制通道，进程内只读一次，命令分发给所有开启了 EnableInheritedControl 的 SimLogger
+var inheritedControl struct {
+    once    sync.Once
+    mutex   sync.Mutex
+    loggers []*SimLogger
+}
+
+// EnableInheritedControl 是否接受父进程（如进程管理器）通过 ControlChannel 传来的日志控制（默认为 false）。
+// 开启后 Init 时如有环境变量 SIMLOG_CONTROL_FD，则从该文件描述符逐行读取控制命令并应用到本日志，
+// 以便进程管理器统一调整其拉起的一批子进程的日志级别，同 simloghttp.Control 但不需要开放端口。
+// 命令为空格分隔的“key=value”，可调的项同 simloghttp.Control：level（级别名）、trace 和 caller（1/0 或 true/false），
+// 如“level=debug trace=1”。进程内有多个开启了的 SimLogger 时均应用；不支持的平台（如 Windows）上不生效。
+func EnableInheritedControl(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.inheritedControl = enabled
+    })
+}
+
+// 挂到继承的控制通道上，第一次时开始读控制通道
+func attachInheritedControl(logger *SimLogger) {
+    inheritedControl.mutex.Lock()
+    inheritedControl.loggers = append(inheritedControl.loggers, logger)
+    inheritedControl.mutex.Unlock()
+
+    inheritedControl.once.Do(func() {
+        value := os.Getenv(ControlFDEnv)
+        if value == "" {
+            return
+        }
+        os.Unsetenv(ControlFDEnv) // 不再传给孙进程
+        fd, err := strconv.Atoi(value)
+        if err != nil || fd < 0 {
+            fmt.Fprintf(os.Stderr, "simlog: invalid %s %q\n", ControlFDEnv, value)
+            return
+        }
+        file, err := openInheritedControl(fd)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "simlog: open inherited control fd %d failed: %s\n", fd, err.Error())
+            return
+        }
+        go readInheritedControl(file)
+    })
+}
+
+// 从继承的控制通道上摘下（由 Close 调用），logger 可以是子日志，按共用的 loggerCore 查找
+func detachInheritedControl(logger *SimLogger) {
+    inheritedControl.mutex.Lock()
+    defer inheritedControl.mutex.Unlock()
+    for i, l := range inheritedControl.loggers {
+        if l.loggerCore == logger.loggerCore {
+            inheritedControl.loggers = append(inheritedControl.loggers[:i], inheritedControl.loggers[i+1:]...)
+            return
+        }
+    }
+}
+
+// 逐行读控制命令并应用，父进程关闭控制通道（或退出）时结束
+func readInheritedControl(file *os.File) {
+    defer file.Close()
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        apply, err := parseControlCommand(scanner.Text())
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "simlog: inherited control: %s\n", err.Error())
+            continue
+        }
+        inheritedControl.mutex.Lock()
+        loggers := append([]*SimLogger(nil), inheritedControl.loggers...)
+        inheritedControl.mutex.Unlock()
+        for _, logger := range loggers {
+            apply(logger)
+        }
+    }
+}
+
+// 解析一行控制命令，全部合法时才返回调整函数，以免只调整了一部分
+func parseControlCommand(command string) (func(*SimLogger), error) {
+    var applies []func(*SimLogger)
+
+    for _, item := range strings.Fields(command) {
+        key, value, ok := strings.Cut(item, "=")
+        if !ok {
+            return nil, fmt.Errorf("invalid item %q", item)
+        }
+        switch key {
+        case "level":
+            logLevel, err := ParseLogLevel(value)
+            if err != nil {
+                return nil, err
+            }
+            applies = append(applies, func(logger *SimLogger) { logger.SetLogLevel(logLevel) })
+        case "trace", "caller":
+            enabled, err := strconv.ParseBool(value)
+            if err != nil {
+                return nil, fmt.Errorf("invalid %s %q", key, value)
+            }
+            if key == "trace" {
+                applies = append(applies, func(logger *SimLogger) { logger.EnableTraceLog(enabled) })
+            } else {
+                applies = append(applies, func(logger *SimLogger) { logger.EnableLogCaller(enabled) })
+            }
+        default:
+            return nil, fmt.Errorf("unknown item %q", key)
+        }
+    }
+    return func(logger *SimLogger) {
+        for _, apply := range applies {
+            apply(logger)
+        }
+    }, nil
+}
+
+// ControlChannel 父进程（如进程管理器）向其拉起的子进程传递日志控制的通道，
+// 子进程的 SimLogger 需开启 EnableInheritedControl。用法：
+//
+//	control := simlog.NewControlChannel()
+//	defer control.Close()
+//	cmd := exec.Command("./worker")
+//	control.Attach(cmd) // 在 cmd.Start 之前
+//	cmd.Start()
+//	...
+//	control.Send("level=debug trace=1") // 发给所有子进程
+type ControlChannel struct {
+    mutex   sync.Mutex
+    writers []*os.File // 各子进程的控制通道的写端
+    readers []*os.File // 各子进程的控制通道的读端（父进程中的副本，Close 时关闭）
+}
+
+// NewControlChannel 创建控制通道
+func NewControlChannel() *ControlChannel {
+    return &ControlChannel{}
+}
+
+// Attach 为 cmd 建立控制通道：经 cmd.ExtraFiles 传给子进程，并在 cmd.Env 中设置 SIMLOG_CONTROL_FD，
+// 应在 cmd.Start 之前调用，cmd.Env 为 nil 时以当前进程的环境变量为基础。
+func (this *ControlChannel) Attach(cmd *exec.Cmd) error {
+    r, w, err := os.Pipe()
+    if err != nil {
+        return err
+    }
+    fd, err := attachControlFile(cmd, r)
+    if err != nil {
+        r.Close()
+        w.Close()
+        return err
+    }
+    if cmd.Env == nil {
+        cmd.Env = os.Environ()
+    }
+    cmd.Env = append(cmd.Env, ControlFDEnv+"="+strconv.Itoa(fd))
+
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    this.writers = append(this.writers, w)
+    this.readers = append(this.readers, r)
+    return nil
+}
+
+// Send 向所有子进程发送一行控制命令，如“level=debug”，已退出的子进程自动移除，
+// 返回命令不合法的错误；写失败的子进程不计为错误，未读控制通道（通道已满）的子进程等待至多1秒后跳过。
+func (this *ControlChannel) Send(command string) error {
+    if _, err := parseControlCommand(command); err != nil {
+        return err
+    }
+
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    writers := this.writers[:0]
+    for _, w := range this.writers {
+        w.SetWriteDeadline(time.Now().Add(controlSendTimeout))
+        if _, err := w.WriteString(command + "\n"); err != nil && !os.IsTimeout(err) {
+            w.Close() // 子进程已退出
+            continue
+        }
+        writers = append(writers, w)
+    }
+    this.writers = writers
+    return nil
+}
+
+// SetLogLevel 设置所有子进程的日志级别
+func (this *ControlChannel) SetLogLevel(logLevel LogLevel) error {
+    return this.Send("level=" + GetLogLevelName(logLevel))
+}
+
+// Close 关闭所有子进程的控制通道，子进程保持当前的设置
+func (this *ControlChannel) Close() error {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    for _, w := range this.writers {
+        w.Close()
+    }
+    for _, r := range this.readers {
+        r.Close()
+    }
+    this.writers = nil
+    this.readers = nil
+    return nil
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package simlog
+
+import (
+    "errors"
+    "os"
+    "os/exec"
+)
+
+// 非 unix 平台不支持经文件描述符继承控制通道
+var errInheritedControl = errors.New("simlog: inherited control is not supported on this platform")
+
+func openInheritedControl(fd int) (*os.File, error) {
+    return nil, errInheritedControl
+}
+
+func attachControlFile(cmd *exec.Cmd, r *os.File) (int, error) {
+    return 0, errInheritedControl
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package simlog
+
+import (
+    "os"
+    "os/exec"
+    "syscall"
+)
+
+// 打开继承的控制通道，并设置 close-on-exec，以免传给孙进程
+func openInheritedControl(fd int) (*os.File, error) {
+    var stat syscall.Stat_t
+    if err := syscall.Fstat(fd, &stat); err != nil {
+        return nil, err
+    }
+    syscall.CloseOnExec(fd)
+    return os.NewFile(uintptr(fd), ControlFDEnv), nil
+}
+
+// 将控制通道的读端加到 cmd.ExtraFiles，返回其在子进程中的文件描述符
+func attachControlFile(cmd *exec.Cmd, r *os.File) (int, error) {
+    cmd.ExtraFiles = append(cmd.ExtraFiles, r)
+    return 2 + len(cmd.ExtraFiles), nil // ExtraFiles 从3开始
+}
@@ -0,0 +1,23 @@
+//go:build go1.23
+
+package simlog
+
+import (
+    "os"
+    "runtime/debug"
+)
+
+// 将运行时的崩溃信息同时追加到日志文件，返回恢复函数
+func setCrashOutput(logFilepath string) func() {
+    f, err := os.OpenFile(logFilepath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+    if err != nil {
+        return func() {}
+    }
+    defer f.Close() // SetCrashOutput 会复制文件描述符
+    if debug.SetCrashOutput(f, debug.CrashOptions{}) != nil {
+        return func() {}
+    }
+    return func() {
+        debug.SetCrashOutput(nil, debug.CrashOptions{})
+    }
+}
@@ -0,0 +1,8 @@
+//go:build !go1.23
+
+package simlog
+
+// Go 1.23 之前不支持 debug.SetCrashOutput
+func setCrashOutput(logFilepath string) func() {
+    return func() {}
+}
@@ -0,0 +1,43 @@
+package simlog
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// WithDateSubdirs 是否按日期分子目录存放日志文件，如“logDir/2024/03/19/xxx.log”，
+// 滚动出的备份文件和当前日志文件在同一个子目录中，
+// 以免长期运行的服务在一个目录下积累成千上万个滚动文件。
+// 子目录按写日志时的本地日期（而非日志头中的时间）确定，跨天后自动写到新的子目录，不存在时自动创建。
+func WithDateSubdirs(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.dateSubdirs = enabled
+    })
+}
+
+// 日志文件所在的日期子目录，如“2024/03/19”
+func dateSubdir(now time.Time) string {
+    return now.Format("2006/01/02")
+}
+
+// 不带日期子目录的日志文件路径，即 logDir/logFilename 清理后的绝对路径，用作共享后端中日志文件的标识，
+// 以便不同写法的同一个路径被识别为同一个日志文件
+func (this *SimLogger) getBaseFilepath() string {
+    path := fmt.Sprintf("%s/%s", this.getLogDir(), this.opts.logFilename)
+    if absPath, err := filepath.Abs(path); err == nil {
+        return absPath
+    }
+    return filepath.Clean(path)
+}
+
+// 创建日志文件所在的日期子目录
+func (this *SimLogger) makeDateSubdir(path string) error {
+    return os.MkdirAll(filepath.Dir(path), 0755)
+}
+
+// 保持打开的日志文件是否已跨天或日志目录已变（见 WithLogDirReresolve），即不再是当前应写的日志文件
+func (this *SimLogger) pathChanged(file *logFile) bool {
+    return (this.opts.dateSubdirs || this.opts.logDirReresolve > 0) && file.path != this.getFilepath()
+}
@@ -0,0 +1,118 @@
+package simlog
+
+import (
+    "errors"
+    "io"
+    "sync"
+    "time"
+)
+
+// 暂存待重试数据的默认上限（字节数）
+const defaultMaxPendingBytes = 4 * 1024 * 1024
+
+var errWriteTimeout = errors.New("simlog: write timeout")
+
+// WithWriteTimeout 设置影子输出等远端输出每批日志的写超时时长，小于等于0表示不设超时（默认），
+// 超时或写失败的日志暂存在内存中（超过上限时丢弃最早的），在下一批日志写入时重试，
+// 从而避免远端阻塞卡住整个写日志协程。
+func WithWriteTimeout(writeTimeout time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.writeTimeout = writeTimeout
+    })
+}
+
+// deadlineWriter 带写超时的输出
+type deadlineWriter struct {
+    w            io.Writer
+    timeout      time.Duration
+    maxPending   int
+    mutex        sync.Mutex
+    pending      []byte // 待重试的数据
+    busy         bool   // 上一次写尚未返回
+    droppedBytes int64  // 因超过暂存上限而丢弃的字节数
+}
+
+// 支持设置写超时的输出，比如 net.Conn
+type writeDeadliner interface {
+    SetWriteDeadline(t time.Time) error
+}
+
+func newDeadlineWriter(w io.Writer, timeout time.Duration) *deadlineWriter {
+    return &deadlineWriter{
+        w:          w,
+        timeout:    timeout,
+        maxPending: defaultMaxPendingBytes,
+    }
+}
+
+// 写入 p，连同之前暂存的数据一起写，
+// 超时或出错时返回错误，未写完的数据暂存待下次重试。
+func (this *deadlineWriter) Write(p []byte) (int, error) {
+    this.mutex.Lock()
+    this.pending = append(this.pending, p...)
+    if this.busy {
+        // 上一次写仍阻塞着，不再叠加新的写
+        this.trimPending()
+        this.mutex.Unlock()
+        return len(p), errWriteTimeout
+    }
+    data := this.pending
+    this.pending = nil
+    this.busy = true
+    this.mutex.Unlock()
+
+    done := make(chan error, 1)
+    go func() {
+        done <- this.writeData(data)
+    }()
+
+    timer := time.NewTimer(this.timeout)
+    defer timer.Stop()
+    select {
+    case err := <-done:
+        return len(p), err
+    case <-timer.C:
+        return len(p), errWriteTimeout
+    }
+}
+
+// 实际写，未写完的部分放回暂存区的最前面
+func (this *deadlineWriter) writeData(data []byte) error {
+    if deadliner, ok := this.w.(writeDeadliner); ok {
+        deadliner.SetWriteDeadline(time.Now().Add(this.timeout))
+        defer deadliner.SetWriteDeadline(time.Time{})
+    }
+    n, err := this.w.Write(data)
+    if err == nil && n < len(data) {
+        err = io.ErrShortWrite
+    }
+
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    if err != nil {
+        this.pending = append(data[n:len(data):len(data)], this.pending...)
+        this.trimPending()
+    }
+    this.busy = false
+    return err
+}
+
+// 超过暂存上限时丢弃最早的数据
+func (this *deadlineWriter) trimPending() {
+    if excess := len(this.pending) - this.maxPending; excess > 0 {
+        this.droppedBytes += int64(excess)
+        this.pending = this.pending[excess:]
+    }
+}
+
+// 尝试写出暂存的数据（关闭时调用）
+func (this *deadlineWriter) flushPending() error {
+    this.mutex.Lock()
+    empty := len(this.pending) == 0
+    this.mutex.Unlock()
+    if empty {
+        return nil
+    }
+    _, err := this.Write(nil)
+    return err
+}
@@ -0,0 +1,121 @@
+package simlog
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// 在 gate 关闭前每次写都阻塞的输出
+type gatedWriter struct {
+    gate  chan struct{}
+    mutex sync.Mutex
+    buf   bytes.Buffer
+}
+
+func (this *gatedWriter) Write(p []byte) (int, error) {
+    <-this.gate
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    return this.buf.Write(p)
+}
+
+func (this *gatedWriter) String() string {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    return this.buf.String()
+}
+
+// 等待阻塞的写返回后写出暂存的数据
+func drainDeadlineWriter(t *testing.T, w *deadlineWriter) {
+    t.Helper()
+    for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+        w.mutex.Lock()
+        idle := !w.busy && len(w.pending) == 0
+        w.mutex.Unlock()
+        if idle {
+            return
+        }
+        w.flushPending()
+    }
+    t.Fatal("pending data not written")
+}
+
+func TestDeadlineWriterConcurrentWrites(t *testing.T) {
+    const writers, lines = 8, 200
+    out := &gatedWriter{gate: make(chan struct{})}
+    w := newDeadlineWriter(out, time.Millisecond)
+
+    var wg sync.WaitGroup
+    for i := 0; i < writers; i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            for j := 0; j < lines; j++ {
+                w.Write([]byte(fmt.Sprintf("%d-%d\n", i, j)))
+                if i == 0 && j == lines/2 {
+                    close(out.gate) // 写到一半时远端恢复
+                }
+            }
+        }(i)
+    }
+    wg.Add(1)
+    go func() {
+        defer wg.Done()
+        for i := 0; i < 100; i++ {
+            w.flushPending()
+        }
+    }()
+    wg.Wait()
+    drainDeadlineWriter(t, w)
+
+    got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+    if len(got) != writers*lines {
+        t.Fatalf("got %d lines, want %d", len(got), writers*lines)
+    }
+    next := make(map[string]int)
+    for _, line := range got {
+        var i, j int
+        if _, err := fmt.Sscanf(line, "%d-%d", &i, &j); err != nil {
+            t.Fatalf("corrupted line %q", line)
+        }
+        key := fmt.Sprint(i)
+        if j != next[key] {
+            t.Fatalf("writer %d: got line %d, want %d", i, j, next[key])
+        }
+        next[key]++
+    }
+}
+
+func TestDeadlineWriterTimeoutDuringClose(t *testing.T) {
+    out := &gatedWriter{gate: make(chan struct{})}
+    var logger SimLogger
+    logger.Init(
+        WithLogdir(t.TempDir()),
+        WithShadowSink(out),
+        WithWriteTimeout(10*time.Millisecond),
+        EnableLineFeed(true),
+    )
+    shadow := logger.opts.shadowSink.(*deadlineWriter)
+
+    var wg sync.WaitGroup
+    wg.Add(1)
+    go func() {
+        defer wg.Done()
+        for i := 0; i < 100; i++ {
+            logger.Infof("line %d", i)
+        }
+        logger.Close() // 阻塞的写在关闭期间超时
+    }()
+    time.Sleep(20 * time.Millisecond)
+    close(out.gate)
+    wg.Wait()
+    drainDeadlineWriter(t, shadow)
+
+    if n := strings.Count(out.String(), "\n"); n != 100 {
+        t.Fatalf("shadow got %d lines, want 100", n)
+    }
+}
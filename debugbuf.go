@@ -0,0 +1,103 @@
+package simlog
+
+import (
+    "bufio"
+    "io"
+    "os"
+    "strings"
+    "sync"
+)
+
+// 内存中的调试日志，按字节数限制大小，超出时丢弃最早的
+type debugBuffer struct {
+    mutex    sync.Mutex
+    lines    []string
+    first    int // 最早一行在 lines 中的下标，之前的已丢弃
+    size     int // 保留的字节数
+    capacity int // 最大字节数
+}
+
+// WithDebugBuffer 在内存中总是保留最近 size 字节的 DEBUG 及以上级别的日志，即使日志级别为 INFO，
+// 需要时（如生成支持包）用 DumpDebugBuffer 写出，从而无需一直写调试日志也可得到详细的近期记录。
+// 只保留 Debug 系列写的调试日志，不包括 DETAIL 和跟踪日志；小于等于0表示不保留（默认）。
+func WithDebugBuffer(size int) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.debugBufferSize = size
+    })
+}
+
+func newDebugBuffer(capacity int) *debugBuffer {
+    return &debugBuffer{capacity: capacity}
+}
+
+// 加入一行，超出容量时丢弃最早的行
+func (this *debugBuffer) add(logLine string) {
+    if !strings.HasSuffix(logLine, "\n") {
+        logLine += "\n"
+    }
+    if len(logLine) > this.capacity {
+        return
+    }
+
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    this.lines = append(this.lines, logLine)
+    this.size += len(logLine)
+    for this.size > this.capacity {
+        this.size -= len(this.lines[this.first])
+        this.lines[this.first] = ""
+        this.first++
+    }
+    // 已丢弃的超过一半时整理，避免 lines 无限增长
+    if this.first > len(this.lines)/2 {
+        this.lines = append(this.lines[:0], this.lines[this.first:]...)
+        this.first = 0
+    }
+}
+
+// 返回保留的所有行（从早到晚）
+func (this *debugBuffer) snapshot() []string {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    return append([]string(nil), this.lines[this.first:]...)
+}
+
+// 构建调试日志行并只放入内存（日志级别未开启调试日志时）
+func (this *SimLogger) captureDebug(caller Caller, logBody string, lineFeed bool) {
+    entry := this.newEntry(LL_DEBUG, caller, logBody)
+    logLine, _ := this.buildLogLine(&entry, lineFeed)
+    this.debugBuffer.add(logLine)
+}
+
+// WriteDebugBuffer 将内存中保留的日志（从早到晚）写入 w，未开启 WithDebugBuffer 时什么也不写
+func (this *SimLogger) WriteDebugBuffer(w io.Writer) (int64, error) {
+    var n int64
+    if this.debugBuffer == nil {
+        return 0, nil
+    }
+    for _, logLine := range this.debugBuffer.snapshot() {
+        m, err := io.WriteString(w, logLine)
+        n += int64(m)
+        if err != nil {
+            return n, err
+        }
+    }
+    return n, nil
+}
+
+// DumpDebugBuffer 将内存中保留的日志写入文件 path（已存在时覆盖），用于生成支持包，
+// 内存中的日志不会被清除。
+func (this *SimLogger) DumpDebugBuffer(path string) error {
+    f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+    if err != nil {
+        return err
+    }
+    w := bufio.NewWriter(f)
+    if _, err = this.WriteDebugBuffer(w); err == nil {
+        err = w.Flush()
+    }
+    if closeErr := f.Close(); err == nil {
+        err = closeErr
+    }
+    return err
+}
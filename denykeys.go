@@ -0,0 +1,88 @@
+package simlog
+
+import (
+    "strings"
+)
+
+// 敏感值的替代
+const deniedValue = "***"
+
+// WithDenyKeys 设置敏感键（不区分大小写），如 WithDenyKeys("password", "token", "secret")，
+// 敏感键的值在输出前替换为“***”，日志文件、打屏和观察者看到的均为替换后的值。
+// 作用于日志体中“key=value”形式的键值对：
+// 值为双引号括起的字符串时替换整个字符串，否则替换到空白、“,”、“;”或“&”为止。裸日志不做处理。
+// 结构化字段同样处理：EnableKVExtraction 的字段从替换后的日志体中提取，
+// WithFields 的字段中敏感键的值在创建子日志时即替换为“***”。
+func WithDenyKeys(keys ...string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        if o.denyKeys == nil {
+            o.denyKeys = make(map[string]struct{}, len(keys))
+        }
+        for _, key := range keys {
+            o.denyKeys[strings.ToLower(key)] = struct{}{}
+        }
+    })
+}
+
+// 是否为敏感键
+func (this *SimLogger) deniedKey(key string) bool {
+    _, ok := this.opts.denyKeys[strings.ToLower(key)]
+    return ok
+}
+
+// 是否可为键的字符
+func isKeyByte(c byte) bool {
+    return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+// 值的结束位置（不包括）
+func valueEnd(s string, start int) int {
+    if start < len(s) && s[start] == '"' {
+        for i := start + 1; i < len(s); i++ {
+            if s[i] == '\\' {
+                i++
+            } else if s[i] == '"' {
+                return i + 1
+            }
+        }
+        return len(s)
+    }
+    for i := start; i < len(s); i++ {
+        if isValueDelimiter(s[i]) {
+            return i
+        }
+    }
+    return len(s)
+}
+
+// 替换日志体中敏感键的值
+func (this *SimLogger) redactBody(logBody string) string {
+    if len(this.opts.denyKeys) == 0 || strings.IndexByte(logBody, '=') < 0 {
+        return logBody
+    }
+
+    var sb strings.Builder
+    last := 0 // 尚未写入 sb 的起始位置
+    for i := 0; i < len(logBody); i++ {
+        if logBody[i] != '=' {
+            continue
+        }
+        start := i
+        for start > 0 && isKeyByte(logBody[start-1]) {
+            start--
+        }
+        if start == i || !this.deniedKey(logBody[start:i]) {
+            continue
+        }
+        end := valueEnd(logBody, i+1)
+        sb.WriteString(logBody[last : i+1])
+        sb.WriteString(deniedValue)
+        last = end
+        i = end - 1
+    }
+    if last == 0 {
+        return logBody
+    }
+    sb.WriteString(logBody[last:])
+    return sb.String()
+}
@@ -0,0 +1,75 @@
+package simlog
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+)
+
+func TestRedactBody(t *testing.T) {
+    var logger SimLogger
+    logger.Init(WithLogdir(t.TempDir()), EnableFileOutput(false), WithDenyKeys("password", "Token"))
+    defer logger.Close()
+
+    cases := []struct {
+        body string
+        want string
+    }{
+        {"login user=alice password=123456", "login user=alice password=***"},
+        {"PASSWORD=abc,token=xyz;next=1", "PASSWORD=***,token=***;next=1"},
+        {`password="a b\" c" ok`, `password=*** ok`},
+        {"url=/login?user=alice&token=xyz&x=1", "url=/login?user=alice&token=***&x=1"},
+        {"mypassword=1 password_hint=2", "mypassword=1 password_hint=2"},
+        {"no pairs here", "no pairs here"},
+        {"password=", "password=***"},
+    }
+    for _, c := range cases {
+        if got := logger.redactBody(c.body); got != c.want {
+            t.Errorf("redactBody(%q) = %q, want %q", c.body, got, c.want)
+        }
+    }
+}
+
+// 日志文件等看到的是替换后的值，裸日志不做处理
+func TestDenyKeysOutput(t *testing.T) {
+    var out bytes.Buffer
+    var logger SimLogger
+    logger.Init(WithLogdir(t.TempDir()), EnableFileOutput(false), WithShadowSink(&out),
+        EnableLineFeed(true), EnableRawLog(true), WithDenyKeys("secret"))
+    logger.Infof("secret=%s", "s3")
+    logger.Raw("secret=raw\n")
+    logger.Close()
+
+    if got := out.String(); !strings.Contains(got, "[INFO]secret=***\n") || !strings.Contains(got, "secret=raw\n") {
+        t.Errorf("unexpected output %q", got)
+    }
+}
+
+// 从日志体中提取的结构化字段也是替换后的值
+func TestDenyKeysExtractedFields(t *testing.T) {
+    var logger SimLogger
+    logger.Init(WithLogdir(t.TempDir()), EnableFileOutput(false), EnableKVExtraction(true), WithDenyKeys("token"))
+    defer logger.Close()
+
+    entry := logger.newEntry(LL_INFO, Caller{}, "user=alice token=xyz")
+    if entry.Fields["token"] != deniedValue || entry.Fields["user"] != "alice" {
+        t.Errorf("Fields = %v, want token=%s user=alice", entry.Fields, deniedValue)
+    }
+}
+
+// WithFields 绑定的字段中敏感键的值也被替换，文本格式和 Entry.Fields 中均是
+func TestDenyKeysBoundFields(t *testing.T) {
+    var out bytes.Buffer
+    var logger SimLogger
+    logger.Init(WithLogdir(t.TempDir()), EnableFileOutput(false), WithShadowSink(&out), EnableLineFeed(true), WithDenyKeys("Token"))
+    child := logger.WithFields(map[string]interface{}{"token": "xyz", "user": "alice"})
+    if fields := child.GetFields(); fields["token"] != deniedValue || fields["user"] != "alice" {
+        t.Errorf("GetFields() = %v, want token=%s user=alice", fields, deniedValue)
+    }
+    child.Info("login")
+    logger.Close()
+
+    if got := out.String(); !strings.Contains(got, "[INFO]token=*** user=alice login\n") || strings.Contains(got, "xyz") {
+        t.Errorf("unexpected output %q", got)
+    }
+}
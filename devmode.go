@@ -0,0 +1,41 @@
+package simlog
+
+import (
+    "sync/atomic"
+)
+
+// DevMode 开发调试用的预设选项（“println 调试”）：同步写、记录调用者、打屏并按级别着色、
+// 毫秒精度的时间、检查格式串和参数是否匹配、单个日志文件 1MB 且只保留2个备份，一个选项即把服务切换为便于开发者查看的配置：
+//
+//	mylog.Init(simlog.DevMode())
+//
+// 其后的选项仍然生效，可覆盖预设中的个别项，如 simlog.DevMode(), simlog.WithBackupNumber(10)。
+// ProductionMode 为其反向，恢复这些项的默认值。
+func DevMode() LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.asyncWrite = false
+        atomic.StoreInt32(&o.logCaller, 1)
+        atomic.StoreInt32(&o.printScreen, 1)
+        atomic.StoreInt32(&o.screenColor, 1)
+        atomic.StoreInt32(&o.timePrecision, int32(TimeMilli))
+        o.formatChecks = true
+        o.logFileSize = 1024 * 1024 // 1 MB
+        o.logNumBackups = 3         // 包括当前的在内
+    })
+}
+
+// ProductionMode 生产环境的预设选项，为 DevMode 的反向：
+// 异步写、不记录调用者、不打屏、微秒精度的时间、不检查格式串、单个日志文件 200MB 且共保留10个（同默认值）。
+func ProductionMode() LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        defaults := defaultLogOptions()
+        o.asyncWrite = defaults.asyncWrite
+        atomic.StoreInt32(&o.logCaller, defaults.logCaller)
+        atomic.StoreInt32(&o.printScreen, defaults.printScreen)
+        atomic.StoreInt32(&o.screenColor, defaults.screenColor)
+        atomic.StoreInt32(&o.timePrecision, defaults.timePrecision)
+        o.formatChecks = defaults.formatChecks
+        o.logFileSize = defaults.logFileSize
+        o.logNumBackups = defaults.logNumBackups
+    })
+}
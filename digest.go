@@ -0,0 +1,146 @@
+package simlog
+
+import (
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+)
+
+// 摘要中最多列出的消息模板数，其余的只计入总数
+const maxDigestTemplates = 20
+
+// 消息模板的最大长度（字节）
+const maxTemplateLength = 128
+
+// 摘要的统计键
+type digestKey struct {
+    level    LogLevel
+    template string
+}
+
+// WithDigest 按 interval（如 time.Minute）汇总 WARNING 和 ERROR 日志，
+// 每个周期输出一条 NOTICE 级别的摘要日志，包含各级别条数和按消息模板的条数（按条数从多到少），如：
+// simlog: digest 1m0s WARNING=12 ERROR=3 | 10 WARNING "slow query #ms" | 3 ERROR "connect to #.#.#.#:# failed"
+// 便于仅靠 grep 日志构建的看板。消息模板由 MessageKey 得到，小于等于0表示不汇总（默认）。
+func WithDigest(interval time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.digestInterval = interval
+    })
+}
+
+// 计入摘要
+func (this *SimLogger) addDigest(logLevel LogLevel, logBody string) {
+    if logLevel != LL_WARNING && logLevel != LL_ERROR {
+        return
+    }
+    key := digestKey{level: logLevel, template: this.MessageKey(logLevel, logBody)}
+
+    this.digestMutex.Lock()
+    this.digestCounts[key]++
+    this.digestMutex.Unlock()
+}
+
+// 启动定时输出摘要的协程
+func (this *SimLogger) startDigest() {
+    this.digestCounts = make(map[digestKey]int64)
+    this.digestExit = make(chan struct{})
+    this.digestDone = make(chan struct{})
+    go func() {
+        ticker := time.NewTicker(this.opts.digestInterval)
+        defer ticker.Stop()
+        defer close(this.digestDone)
+
+        for {
+            select {
+            case <-ticker.C:
+                this.putDigest()
+            case <-this.digestExit:
+                this.putDigest()
+                return
+            }
+        }
+    }()
+}
+
+// 停止定时输出摘要的协程，并输出最后一个周期的摘要
+func (this *SimLogger) stopDigest() {
+    close(this.digestExit)
+    <-this.digestDone
+}
+
+// 输出当前周期的摘要，周期内没有 WARNING 和 ERROR 日志时不输出
+func (this *SimLogger) putDigest() {
+    this.digestMutex.Lock()
+    counts := this.digestCounts
+    this.digestCounts = make(map[digestKey]int64, len(counts))
+    this.digestMutex.Unlock()
+    if len(counts) == 0 {
+        return
+    }
+
+    var numWarnings, numErrors int64
+    keys := make([]digestKey, 0, len(counts))
+    for key, count := range counts {
+        if key.level == LL_WARNING {
+            numWarnings += count
+        } else {
+            numErrors += count
+        }
+        keys = append(keys, key)
+    }
+    sort.Slice(keys, func(i, j int) bool {
+        if counts[keys[i]] != counts[keys[j]] {
+            return counts[keys[i]] > counts[keys[j]]
+        }
+        if keys[i].level != keys[j].level {
+            return keys[i].level < keys[j].level
+        }
+        return keys[i].template < keys[j].template
+    })
+
+    var sb strings.Builder
+    sb.WriteString("simlog: digest ")
+    sb.WriteString(this.opts.digestInterval.String())
+    sb.WriteString(" WARNING=")
+    sb.WriteString(strconv.FormatInt(numWarnings, 10))
+    sb.WriteString(" ERROR=")
+    sb.WriteString(strconv.FormatInt(numErrors, 10))
+    for i, key := range keys {
+        if i == maxDigestTemplates {
+            sb.WriteString(" | ")
+            sb.WriteString(strconv.Itoa(len(keys) - i))
+            sb.WriteString(" more templates")
+            break
+        }
+        sb.WriteString(" | ")
+        sb.WriteString(strconv.FormatInt(counts[key], 10))
+        sb.WriteString(" ")
+        sb.WriteString(GetLogLevelName(key.level))
+        sb.WriteString(" ")
+        sb.WriteString(strconv.Quote(key.template))
+    }
+    this.putInternalLog(time.Now(), LL_NOTICE, sb.String())
+}
+
+// 由日志体得到消息模板：去掉首尾空白，连续的数字替换为一个“#”，并截断到 maxTemplateLength
+func messageTemplate(logBody string) string {
+    var sb strings.Builder
+    inDigits := false
+    for _, r := range strings.TrimSpace(logBody) {
+        if unicode.IsDigit(r) {
+            if !inDigits {
+                sb.WriteByte('#')
+                inDigits = true
+            }
+            continue
+        }
+        inDigits = false
+        sb.WriteRune(r)
+        if sb.Len() >= maxTemplateLength {
+            break
+        }
+    }
+    return sb.String()
+}
@@ -0,0 +1,224 @@
+package simlog
+
+import (
+    "errors"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// LineGrammar 日志行（文本格式）的规范语法（ABNF），日志头格式演进时同步更新，
+// 包装 simlog 的库和下游解析器可据此及 ParseLine 和 simlogtest 中的黄金文件校验兼容性。
+const LineGrammar = `LINE     = [PRI] HEADER BODY [CHECKSUM] [LF]
+PRI      = "<" 1*3DIGIT ">"                        ; 见 WithSyslogPriority
+HEADER   = TIME [WRITE] [ELAPSED] [SERVICE] *TAG LEVEL [CODE] [CALLER]
+TIME     = "[" DATE " " CLOCK " " FRACTION [" " ZONE] "]" ; 如 [2020-03-19 08:00:00 123456]
+DATE     = 4DIGIT "-" 2DIGIT "-" 2DIGIT
+CLOCK    = 2DIGIT ":" 2DIGIT ":" 2DIGIT
+FRACTION = 6DIGIT / 3DIGIT / 9DIGIT                ; 微秒（默认）、毫秒或纳秒，见 WithTimePrecision
+ZONE     = ("+" / "-") 4DIGIT / 1*ALPHA            ; 时区偏移或缩写，见 WithTimeZoneFormat
+WRITE    = "[write:" DATE " " CLOCK " " FRACTION [" " ZONE] "]" ; 写入时间，见 EnableWriteTime
+ELAPSED  = "[+" 1*DIGIT "." 6DIGIT "s]"           ; 见 EnableElapsedTime
+SERVICE  = "[svc:" NAME ["@" VERSION] "]"
+TAG      = "[" TEXT "]"
+LEVEL    = "[" ("FATAL" / "ERROR" / "WARNING" / "NOTICE" / "INFO" / "DEBUG" / "DETAIL" / "TRACE") [":" DIGIT] "]" ; 数值见 EnableLevelNumber
+CODE     = "[code:" TEXT "]"                       ; 见 WithCode
+CALLER   = "[" FILENAME ":" 1*DIGIT "]"           ; FILENAME 可带相对路径，见 WithCallerTrimPrefix
+BODY     = TEXT / "@" CODEC "+b64:" BASE64 [LF]    ; 压缩的日志体，见 WithBodyCompression
+CODEC    = 1*32(%x61-7A / DIGIT / "." / "_" / "-") ; 编解码器名，如 zstd，见 WithBodyCodec
+CHECKSUM = " #crc32:" 8HEXDIG                     ; 见 EnableChecksum
+RAWLINE  = [TIME] BODY [LF]                        ; 裸日志，见 EnableRawLog`
+
+// ErrInvalidLine 不是合法的 simlog 日志行
+var ErrInvalidLine = errors.New("simlog: invalid log line")
+
+// Entry 一条日志
+type Entry struct {
+    Time      time.Time              `json:"time"`              // 日志时间
+    Elapsed   time.Duration          `json:"elapsed,omitempty"` // 自 Init 起经过的单调时长，见 EnableElapsedTime
+    WriteTime time.Time              `json:"write_time"`        // 写入时间，未记录时为零值，见 EnableWriteTime
+    Level     LogLevel               `json:"level"`             // 日志级别
+    Service   string                 `json:"service,omitempty"` // 服务名
+    Version   string                 `json:"version,omitempty"` // 服务版本
+    Tag       string                 `json:"tag,omitempty"`     // 第一个标签，同 Tags[0]，为兼容只有一个标签时的用法
+    Tags      []string               `json:"tags,omitempty"`    // 所有标签，WithTag 设置的在前，WithTags 设置的在后
+    Code      string                 `json:"code,omitempty"`    // 错误码（事件ID），见 WithCode
+    Caller    Caller                 `json:"caller"`            // 调用者，未记录时为零值
+    Body      string                 `json:"body"`              // 日志体
+    Fields    map[string]interface{} `json:"fields,omitempty"`  // 结构化字段，见 EnableKVExtraction 和 WithFields
+    Labels    map[string]string      `json:"-"`                 // 标签，不输出，见 WithLabels
+
+    line string // 按写这条日志的 SimLogger 的选项构建的日志行，供其自己的输出使用，见 localSink
+}
+
+// 构建一条日志
+func (this *SimLogger) newEntry(logLevel LogLevel, caller Caller, logBody string) Entry {
+    entry := Entry{
+        Time:   time.Now(),
+        Level:  logLevel,
+        Caller: caller,
+        Body:   logBody,
+    }
+    if logLevel != LL_RAW {
+        entry.Service = this.opts.serviceName
+        entry.Version = this.opts.serviceVersion
+        entry.Tags = this.opts.tags
+        if len(entry.Tags) > 0 {
+            entry.Tag = entry.Tags[0]
+        }
+        entry.Code = this.code
+        entry.Labels = this.labels
+        entry.Body = this.sanitizeBody(this.redactBody(logBody))
+        if this.EnabledKVExtraction() {
+            entry.Fields = ExtractKV(entry.Body)
+        }
+        if len(this.fields) > 0 {
+            this.mergeFields(&entry)
+            if this.opts.format == FormatText {
+                entry.Body = this.fieldsText + entry.Body
+            }
+        }
+        if this.EnabledElapsedTime() {
+            entry.Elapsed = time.Since(this.startTime)
+        }
+    }
+    return entry
+}
+
+// ParseLine 按 LineGrammar 解析一行日志（行尾的换行符和校验和会被去掉，但不校验，校验用 VerifyLine），
+// 带时间头的裸日志解析为 LL_RAW 级别，不带时间头的行返回 ErrInvalidLine。
+// 调用者只有源代码文件名（WithCallerTrimPrefix 时为相对路径）和行号，
+// WithBodyCompression 压缩的日志体会被解压，解压失败时返回 ErrInvalidLine。
+// WithFormat(FormatJSON) 输出的 JSON 格式的日志行亦可解析。
+// 注意：日志体以“[”开头且未记录调用者时，形如“[x:1]”的日志体开头会被误解析为调用者。
+func ParseLine(line string) (Entry, error) {
+    var entry Entry
+
+    line = strings.TrimRight(line, "\r\n")
+    if isJSONLine(line) {
+        return parseJSONLine(line)
+    }
+    line = trimSyslogPriority(line)
+    if pos := strings.LastIndex(line, checksumMark); pos >= 0 && len(line)-pos-len(checksumMark) == 8 {
+        if _, err := strconv.ParseUint(line[pos+len(checksumMark):], 16, 32); err == nil {
+            line = line[:pos]
+        }
+    }
+
+    token, rest, ok := nextHeaderToken(line)
+    if !ok {
+        return entry, ErrInvalidLine
+    }
+    logTime, err := parseLogTime(token)
+    if err != nil {
+        return entry, ErrInvalidLine
+    }
+    entry.Time = logTime
+    body := rest // 裸日志的日志体
+
+    if token, next, ok := nextHeaderToken(rest); ok && strings.HasPrefix(token, "write:") {
+        if writeTime, err := parseLogTime(token[len("write:"):]); err == nil {
+            entry.WriteTime = writeTime
+            rest = next
+        }
+    }
+    if token, next, ok := nextHeaderToken(rest); ok && strings.HasPrefix(token, "+") && strings.HasSuffix(token, "s") {
+        if elapsed, err := time.ParseDuration(token[1:]); err == nil {
+            entry.Elapsed = elapsed
+            rest = next
+        }
+    }
+    if token, next, ok := nextHeaderToken(rest); ok && strings.HasPrefix(token, "svc:") {
+        entry.Service = token[len("svc:"):]
+        if pos := strings.LastIndexByte(entry.Service, '@'); pos >= 0 {
+            entry.Service, entry.Version = entry.Service[:pos], entry.Service[pos+1:]
+        }
+        rest = next
+    }
+    for {
+        token, next, ok := nextHeaderToken(rest)
+        if !ok {
+            // 无级别，为带时间头的裸日志
+            return Entry{Time: logTime, Level: LL_RAW, Body: body}, nil
+        }
+        rest = next
+        if logLevel, ok := parseHeaderLevel(token); ok {
+            entry.Level = logLevel
+            break
+        }
+        entry.Tags = append(entry.Tags, token)
+    }
+    if len(entry.Tags) > 0 {
+        entry.Tag = entry.Tags[0]
+    }
+    if token, next, ok := nextHeaderToken(rest); ok && strings.HasPrefix(token, "code:") {
+        entry.Code = token[len("code:"):]
+        rest = next
+    }
+    if token, next, ok := nextHeaderToken(rest); ok {
+        if pos := strings.LastIndexByte(token, ':'); pos > 0 {
+            if line, err := strconv.Atoi(token[pos+1:]); err == nil && line > 0 {
+                entry.Caller.File = token[:pos]
+                entry.Caller.Line = line
+                rest = next
+            }
+        }
+    }
+    if entry.Body, _, err = DecompressBody(rest); err != nil {
+        return entry, ErrInvalidLine
+    }
+    return entry, nil
+}
+
+// 取出行首方括号内的内容，rest 为右方括号之后的部分
+func nextHeaderToken(s string) (token string, rest string, ok bool) {
+    if len(s) == 0 || s[0] != '[' {
+        return "", s, false
+    }
+    end := strings.IndexByte(s, ']')
+    if end < 0 {
+        return "", s, false
+    }
+    return s[1:end], s[end+1:], true
+}
+
+// 解析 getLogTime 格式的日志时间（不含方括号），
+// 带时区偏移时按偏移解析；带时区缩写时，UTC 和 GMT 按 UTC 解析，其它按本地时区解析。
+func parseLogTime(s string) (time.Time, error) {
+    const layout = "2006-01-02 15:04:05"
+    if len(s) <= len(layout)+1 || s[len(layout)] != ' ' {
+        return time.Time{}, ErrInvalidLine
+    }
+    fraction := s[len(layout)+1:]
+    location := time.Local
+    if pos := strings.IndexByte(fraction, ' '); pos >= 0 {
+        zone := fraction[pos+1:]
+        fraction = fraction[:pos]
+        if zone == "UTC" || zone == "GMT" {
+            location = time.UTC
+        } else if zt, err := time.Parse("-0700", zone); err == nil {
+            _, offset := zt.Zone()
+            location = time.FixedZone(zone, offset)
+        } else if zone == "" {
+            return time.Time{}, ErrInvalidLine
+        }
+    }
+    t, err := time.ParseInLocation(layout, s[:len(layout)], location)
+    if err != nil {
+        return t, err
+    }
+    n, err := strconv.Atoi(fraction)
+    if err != nil {
+        return t, ErrInvalidLine
+    }
+    switch len(fraction) {
+    case 3:
+        return t.Add(time.Duration(n) * time.Millisecond), nil
+    case 6:
+        return t.Add(time.Duration(n) * time.Microsecond), nil
+    case 9:
+        return t.Add(time.Duration(n)), nil
+    default:
+        return t, ErrInvalidLine
+    }
+}
@@ -0,0 +1,44 @@
+package simlog
+
+import (
+    "errors"
+    "fmt"
+    "os"
+)
+
+// 日志内部的失败，可用 errors.Is 判断（ErrClosed 见 WithStrictErrors，ErrWriterDown 见 Stats），
+// 由写日志的函数、输出（Sink）的 Write 等返回，或经 WithErrorHandler 设置的错误处理函数通知。
+var (
+    ErrQueueFull       = errors.New("simlog: queue is full")       // 队列已满，日志被丢弃（如网络输出和异步的结构化观察者）
+    ErrRotateFailed    = errors.New("simlog: rotate failed")       // 滚动日志文件失败（如加文件锁或改名失败）
+    ErrSinkUnavailable = errors.New("simlog: sink is unavailable") // 输出不可用（如连不上 syslog 或收集程序）
+)
+
+// ErrorHandler 日志内部失败的处理函数，err 可用 errors.Is 判断是哪种失败，
+// 在写日志、写协程或输出的发送协程中调用，不应阻塞，也不应再写本日志
+type ErrorHandler func(err error)
+
+// WithErrorHandler 设置日志内部失败（滚动失败、输出写失败或不可用、结构化观察者的队列满等）的处理函数，
+// 以便按失败的种类告警或统计，而不必匹配标准错误上的消息。
+// 未设置时滚动失败和输出不可用输出到标准错误，输出写失败和队列满只计数（见 LogStats 和 ObserverStats）。
+func WithErrorHandler(handler ErrorHandler) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.errorHandler = handler
+    })
+}
+
+// 通知内部失败，未设置错误处理函数时输出到标准错误
+func (this *SimLogger) reportError(err error) {
+    if this.opts.errorHandler != nil {
+        this.opts.errorHandler(err)
+    } else {
+        fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+    }
+}
+
+// 通知只计数的内部失败，未设置错误处理函数时忽略
+func (this *SimLogger) reportCountedError(err error) {
+    if this.opts.errorHandler != nil {
+        this.opts.errorHandler(err)
+    }
+}
@@ -0,0 +1,76 @@
+package simlog
+
+import (
+    "fmt"
+    "strings"
+)
+
+// EventField 保存事件名的字段名，见 Event
+const EventField = "event"
+
+// Event 多行的结构化事件，由 SimLogger.Event 创建，累积字段和子消息后由 Emit 作为一条日志写出
+type Event struct {
+    logger *SimLogger
+    name   string
+    level  LogLevel
+    fields map[string]interface{}
+    lines  []string
+}
+
+// Event 创建名为 name 的事件（默认为 INFO 级别），累积的字段和子消息在 Emit 时作为一条日志（一个队列项、一次写）写出，
+// 以保证相关的多行（如请求和响应的内容）在多个进程共写的日志文件中不被其它日志隔开，如：
+//
+//	logger.Event("http").Field("status", 200).Add(reqDump).Add(respDump).Emit()
+//
+// 日志体的第一行为事件名（字段名为 event）和字段（同 WithFields），其后每个子消息一行（多行的子消息保持多行），
+// 以制表符缩进。Event 不是并发安全的，Emit 之后不应再使用。
+func (this *SimLogger) Event(name string) *Event {
+    return &Event{
+        logger: this,
+        name:   name,
+        level:  LL_INFO,
+        fields: map[string]interface{}{EventField: name},
+    }
+}
+
+// Level 设置事件的日志级别
+func (this *Event) Level(logLevel LogLevel) *Event {
+    this.level = logLevel
+    return this
+}
+
+// Field 增加一个字段，同名的以后设置的为准
+func (this *Event) Field(key string, value interface{}) *Event {
+    this.fields[key] = value
+    return this
+}
+
+// Add 增加一个子消息
+func (this *Event) Add(a ...interface{}) *Event {
+    this.lines = append(this.lines, fmt.Sprint(a...))
+    return this
+}
+
+// Addf 按格式增加一个子消息
+func (this *Event) Addf(format string, a ...interface{}) *Event {
+    this.lines = append(this.lines, fmt.Sprintf(format, a...))
+    return this
+}
+
+// Emit 将事件作为一条日志写出，事件的级别未开启时不写
+func (this *Event) Emit() (int, error) {
+    if !this.logger.Enabled(this.level) {
+        return 0, nil
+    }
+    return this.emit(this.logger.opts.skip)
+}
+
+func (this *Event) emit(skip int32) (int, error) {
+    caller := this.logger.getCaller(this.level, skip)
+    var sb strings.Builder
+    for _, line := range this.lines {
+        sb.WriteString("\n\t")
+        sb.WriteString(strings.ReplaceAll(strings.TrimRight(line, "\r\n"), "\n", "\n\t"))
+    }
+    return this.logger.WithFields(this.fields).output(this.level, caller, sb.String(), false)
+}
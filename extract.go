@@ -0,0 +1,220 @@
+package simlog
+
+import (
+    "bufio"
+    "bytes"
+    "compress/gzip"
+    "io"
+    "os"
+    "sort"
+    "strings"
+    "time"
+)
+
+// 二分查找缩小到多少字节时改为顺序扫描
+const extractScanSize = 64 * 1024
+
+// ExtractRange 从 simlog 的日志文件（包括滚动出的备份、WithBackupCodec 压缩的备份和 gzip 压缩的备份）中提取时间在 [from, to] 之间的日志写到 w，
+// 用于为事故收集一个时间窗口内的日志。from 为零值表示不限开始，to 为零值表示不限结束。
+// 文件按各自第一行日志的时间排序后依次提取，因此 files 的顺序无关紧要。
+// 日志文件内的日志按时间先后排列，未压缩的文件有索引（见 WithRotationIndex）时按索引定位开始位置，否则用二分查找，不必从头读；
+// 压缩的文件只能从头顺序读。不带时间的行（如裸日志、多行日志体的后续行）跟随其前面带时间的行。
+func ExtractRange(files []string, from, to time.Time, w io.Writer) error {
+    type fileStart struct {
+        path  string
+        start time.Time
+    }
+
+    starts := make([]fileStart, 0, len(files))
+    for _, path := range files {
+        start, err := firstLogTime(path)
+        if err != nil {
+            return err
+        }
+        starts = append(starts, fileStart{path: path, start: start})
+    }
+    sort.SliceStable(starts, func(i, j int) bool {
+        return starts[i].start.Before(starts[j].start)
+    })
+
+    for _, file := range starts {
+        if !to.IsZero() && file.start.After(to) {
+            break
+        }
+        if err := extractFile(file.path, from, to, w); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// 取得日志行的时间，不带时间的行返回 false
+func lineTime(line string) (time.Time, bool) {
+    if isJSONLine(line) {
+        entry, err := parseJSONLine(strings.TrimRight(line, "\r\n"))
+        return entry.Time, err == nil
+    }
+    token, _, ok := nextHeaderToken(trimSyslogPriority(line))
+    if !ok {
+        return time.Time{}, false
+    }
+    logTime, err := parseLogTime(token)
+    return logTime, err == nil
+}
+
+// 打开日志文件，压缩的（WithBackupCodec 的按文件名、gzip 的按文件头识别）返回解压的 reader，第2个返回值为 nil 表示可随机读
+func openLogForRead(path string) (io.ReadCloser, *os.File, error) {
+    if codec, ok := backupCodecOf(path); ok {
+        data, err := os.ReadFile(path)
+        if err != nil {
+            return nil, nil, err
+        }
+        if data, err = codec.Decode(data); err != nil {
+            return nil, nil, err
+        }
+        return io.NopCloser(bytes.NewReader(data)), nil, nil
+    }
+
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    var magic [2]byte
+    if n, _ := io.ReadFull(f, magic[:]); n == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+        if _, err = f.Seek(0, io.SeekStart); err == nil {
+            var zr *gzip.Reader
+            if zr, err = gzip.NewReader(f); err == nil {
+                return struct {
+                    io.Reader
+                    io.Closer
+                }{zr, f}, nil, nil
+            }
+        }
+        f.Close()
+        return nil, nil, err
+    }
+    if _, err = f.Seek(0, io.SeekStart); err != nil {
+        f.Close()
+        return nil, nil, err
+    }
+    return f, f, nil
+}
+
+// 取得文件中第一行带时间的日志的时间，没有时返回零值
+func firstLogTime(path string) (time.Time, error) {
+    r, _, err := openLogForRead(path)
+    if err != nil {
+        return time.Time{}, err
+    }
+    defer r.Close()
+
+    reader := bufio.NewReader(r)
+    for {
+        line, err := reader.ReadString('\n')
+        if logTime, ok := lineTime(line); ok {
+            return logTime, nil
+        }
+        if err == io.EOF {
+            return time.Time{}, nil
+        }
+        if err != nil {
+            return time.Time{}, err
+        }
+    }
+}
+
+// 从 offset 之后的第一个完整行开始，取得第一行带时间的日志的时间
+func logTimeAfter(f *os.File, offset int64) (time.Time, bool, error) {
+    if _, err := f.Seek(offset, io.SeekStart); err != nil {
+        return time.Time{}, false, err
+    }
+    reader := bufio.NewReader(f)
+    if offset > 0 {
+        if _, err := reader.ReadString('\n'); err != nil {
+            return time.Time{}, false, nil // 不完整的最后一行
+        }
+    }
+    for {
+        line, err := reader.ReadString('\n')
+        if logTime, ok := lineTime(line); ok {
+            return logTime, true, nil
+        }
+        if err != nil {
+            return time.Time{}, false, nil
+        }
+    }
+}
+
+// 提取一个日志文件中时间在 [from, to] 之间的日志
+func extractFile(path string, from, to time.Time, w io.Writer) error {
+    r, f, err := openLogForRead(path)
+    if err != nil {
+        return err
+    }
+    defer r.Close()
+
+    var offset int64
+    aligned := false // offset 是否为行的开始
+    if f == nil || from.IsZero() {
+        // 从头读
+    } else if index, e := ReadLogIndex(path); e == nil {
+        offset = index.Offset(from)
+        aligned = true
+        if _, err = f.Seek(offset, io.SeekStart); err != nil {
+            return err
+        }
+    } else {
+        // 二分查找：找到一个位置，其后第一行日志的时间早于 from，且再往后不远处即晚于 from
+        fi, err := f.Stat()
+        if err != nil {
+            return err
+        }
+        lo, hi := int64(0), fi.Size()
+        for hi-lo > extractScanSize {
+            mid := lo + (hi-lo)/2
+            logTime, ok, err := logTimeAfter(f, mid)
+            if err != nil {
+                return err
+            }
+            if ok && logTime.Before(from) {
+                lo = mid
+            } else {
+                hi = mid
+            }
+        }
+        offset = lo
+        if _, err = f.Seek(offset, io.SeekStart); err != nil {
+            return err
+        }
+    }
+
+    reader := bufio.NewReader(r)
+    if offset > 0 && !aligned {
+        reader.ReadString('\n') // 跳过不完整的行
+    }
+    inRange := from.IsZero()
+    for {
+        line, err := reader.ReadString('\n')
+        if logTime, ok := lineTime(line); ok {
+            if !to.IsZero() && logTime.After(to) {
+                return nil
+            }
+            inRange = from.IsZero() || !logTime.Before(from)
+        }
+        if inRange && line != "" {
+            if !strings.HasSuffix(line, "\n") {
+                line += "\n"
+            }
+            if _, e := io.WriteString(w, line); e != nil {
+                return e
+            }
+        }
+        if err == io.EOF {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+    }
+}
@@ -0,0 +1,64 @@
+package simlog
+
+import (
+    "os"
+    "sync/atomic"
+)
+
+// FatalPolicy 记录致命错误日志（Fatal）后的处理策略
+type FatalPolicy int32
+
+const (
+    FatalExit  FatalPolicy = 0 // 关闭日志（写完队列中的日志）后以 fatalExitCode 退出进程（默认）
+    FatalPanic FatalPolicy = 1 // 以 *FatalError 为值 panic，由嵌入 simlog 的框架 recover 后自行处理
+    FatalNone  FatalPolicy = 2 // 只记录日志，不做其它处理
+)
+
+// FatalError 策略为 FatalPanic 时 panic 的值
+type FatalError struct {
+    Body string // 致命错误日志的日志体
+}
+
+func (this *FatalError) Error() string {
+    return this.Body
+}
+
+// WithFatalExitCode 设置策略为 FatalExit 时进程的退出码（默认为1）
+func WithFatalExitCode(code int) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        atomic.StoreInt32(&o.fatalExitCode, int32(code))
+    })
+}
+
+// WithFatalPolicy 设置记录致命错误日志后的处理策略（默认为 FatalExit）
+func WithFatalPolicy(policy FatalPolicy) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        atomic.StoreInt32(&o.fatalPolicy, int32(policy))
+    })
+}
+
+// 取得致命错误日志的处理策略
+func (this *SimLogger) GetFatalPolicy() FatalPolicy {
+    return FatalPolicy(atomic.LoadInt32(&this.opts.fatalPolicy))
+}
+
+// 设置致命错误日志的处理策略
+func (this *SimLogger) SetFatalPolicy(policy FatalPolicy) {
+    this.checkInit()
+    atomic.StoreInt32(&this.opts.fatalPolicy, int32(policy))
+}
+
+// 记录致命错误日志并按策略处理
+func (this *SimLogger) fatal(caller Caller, logBody string, lineFeed bool) (int, error) {
+    n, err := this.output(LL_FATAL, caller, logBody, lineFeed)
+
+    switch FatalPolicy(atomic.LoadInt32(&this.opts.fatalPolicy)) {
+    case FatalPanic:
+        panic(&FatalError{Body: logBody})
+    case FatalNone:
+    default:
+        this.Close()
+        os.Exit(int(atomic.LoadInt32(&this.opts.fatalExitCode))) // 致使错误
+    }
+    return n, err
+}
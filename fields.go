@@ -0,0 +1,69 @@
+package simlog
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// WithFields 返回附带结构化字段的子日志，如每个请求一个的 logger.WithFields(map[string]interface{}{"request_id": id, "user_id": uid})，
+// 不必重新 Init，也不必在每次写日志时重复传入。文本格式时字段按键排序，以“key=value”的形式加在日志体之前，如：
+//
+//	[2020-03-19 08:00:00 123456][INFO]request_id=abc user_id=42 order created
+//
+// JSON 格式时输出为 fields（见 WithFormat），同时也在 Entry.Fields 中，供观察者等使用。
+// 子日志的字段为本日志的字段加上 fields（同名的以 fields 为准），WithDenyKeys 中的键的值输出为“***”。
+// 子日志和本日志共用选项、队列和日志文件，可保存下来重复使用。裸日志不带字段。
+func (this *SimLogger) WithFields(fields map[string]interface{}) *SimLogger {
+    child := *this
+    child.fields = make(map[string]interface{}, len(this.fields)+len(fields))
+    for key, value := range this.fields {
+        child.fields[key] = value
+    }
+    for key, value := range fields {
+        if this.deniedKey(key) {
+            value = deniedValue
+        }
+        child.fields[key] = value
+    }
+
+    keys := make([]string, 0, len(child.fields))
+    for key := range child.fields {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    var sb strings.Builder
+    for _, key := range keys {
+        sb.WriteString(key)
+        sb.WriteByte('=')
+        sb.WriteString(formatFieldValue(child.fields[key]))
+        sb.WriteByte(' ')
+    }
+    child.fieldsText = sb.String()
+    return &child
+}
+
+// GetFields 返回结构化字段，未设置时为 nil，返回的 map 不应修改
+func (this *SimLogger) GetFields() map[string]interface{} {
+    return this.fields
+}
+
+// 格式化字段的值，含分隔符、引号或为空的字符串加引号，以便 ExtractKV 能还原
+func formatFieldValue(value interface{}) string {
+    s := fmt.Sprint(value)
+    if s == "" || strings.ContainsAny(s, " \t\r\n,;&\"") || !strconv.CanBackquote(s) {
+        return strconv.Quote(s)
+    }
+    return s
+}
+
+// 将子日志的字段加入日志的结构化字段，子日志的字段优先于从日志体中提取的
+func (this *SimLogger) mergeFields(entry *Entry) {
+    if entry.Fields == nil {
+        entry.Fields = make(map[string]interface{}, len(this.fields))
+    }
+    for key, value := range this.fields {
+        entry.Fields[key] = value
+    }
+}
@@ -0,0 +1,89 @@
+package simlog
+
+import (
+    "fmt"
+    "strings"
+    "unicode"
+)
+
+// 日志文件名的最大长度（字节数），为滚动的“.N”和锁文件的“.lock”等后缀留出余地（NAME_MAX 一般为255）
+const maxFilenameLen = 200
+
+// 检查日志文件名（或其组成部分）：不能含路径分隔符和控制字符（包括'\0'），
+// 不能为“.”或“..”，也不能过长，以免如 WithSubSuffix("../..\x00") 在日志目录之外创建文件。
+func checkFilename(name string) error {
+    if name == "." || name == ".." {
+        return fmt.Errorf("%q is not a file name", name)
+    }
+    if len(name) > maxFilenameLen {
+        return fmt.Errorf("longer than %d bytes", maxFilenameLen)
+    }
+    for _, r := range name {
+        if r == '/' || r == '\\' {
+            return fmt.Errorf("contains path separator %q", r)
+        }
+        if unicode.IsControl(r) || r == unicode.ReplacementChar {
+            return fmt.Errorf("contains control or invalid character %q", r)
+        }
+    }
+    return nil
+}
+
+// 检查日志标签：标签输出在日志头中，不能含控制字符（如换行，会伪造出一行新日志）和方括号
+func checkTag(tag string) error {
+    for _, r := range tag {
+        if r == '[' || r == ']' {
+            return fmt.Errorf("contains bracket %q", r)
+        }
+        if unicode.IsControl(r) || r == unicode.ReplacementChar {
+            return fmt.Errorf("contains control or invalid character %q", r)
+        }
+    }
+    return nil
+}
+
+// SanitizeFilename 将 name 清理为可用作日志文件名（或 WithSubPrefix、WithSubSuffix 的值）的字符串：
+// 路径分隔符、控制字符和无效的 UTF-8 字节替换为“_”，“.”和“..”替换为“_”，过长时截断，
+// 供前后缀来自外部输入（如租户名、主机名）时使用，以免 Init 因文件名不合法而失败。
+func SanitizeFilename(name string) string {
+    if name == "." || name == ".." {
+        return "_"
+    }
+
+    var builder strings.Builder
+    for _, r := range name {
+        if r == '/' || r == '\\' || unicode.IsControl(r) || r == unicode.ReplacementChar {
+            r = '_'
+        }
+        if builder.Len()+len(string(r)) > maxFilenameLen {
+            break
+        }
+        builder.WriteRune(r)
+    }
+    return builder.String()
+}
+
+// 检查日志文件名的前后缀、文件名和标签，Init 时调用
+func (this *logOptions) validate() error {
+    if this.optionErr != nil {
+        return this.optionErr
+    }
+    if err := checkFilename(this.subPrefix); err != nil {
+        return fmt.Errorf("simlog: invalid sub prefix %q: %s", this.subPrefix, err.Error())
+    }
+    if err := checkFilename(this.subSuffix); err != nil {
+        return fmt.Errorf("simlog: invalid sub suffix %q: %s", this.subSuffix, err.Error())
+    }
+    if this.logFilename == "" {
+        return fmt.Errorf("simlog: empty log file name")
+    }
+    if err := checkFilename(this.logFilename); err != nil {
+        return fmt.Errorf("simlog: invalid log file name %q: %s", this.logFilename, err.Error())
+    }
+    for _, tag := range this.tags {
+        if err := checkTag(tag); err != nil {
+            return fmt.Errorf("simlog: invalid tag %q: %s", tag, err.Error())
+        }
+    }
+    return nil
+}
@@ -0,0 +1,141 @@
+package simlog
+
+import (
+    "bytes"
+    "encoding/json"
+    "strings"
+    "time"
+)
+
+// Format 日志行的输出格式
+type Format int32
+
+const (
+    FormatText Format = 0 // 文本格式（默认），见 LineGrammar
+    FormatJSON Format = 1 // 每行一个 JSON 对象，见 WithFormat
+)
+
+// WithFormat 设置日志行的输出格式（默认为 FormatText）。
+// FormatJSON 时每条日志输出为一行 JSON 对象，可直接送入 ELK、Loki 等，不必解析文本格式的日志头，如：
+//
+//	{"time":"2020-03-19T08:00:00.123456+08:00","level":"INFO","tags":["10.0.0.1"],"caller":{"file":"/src/app/main.go","line":42,"func":"main"},"message":"hello"}
+//
+// 时间为 RFC 3339 格式，总是带时区偏移，秒以下的精度同 WithTimePrecision；
+// caller 中的文件为完整路径（不受 WithCallerTrimPrefix 影响），以便日志界面链接到源代码；
+// 日志体中的键值对（EnableKVExtraction）输出为 fields，级别数值（EnableLevelNumber）输出为 level_number。
+// 裸日志原样输出；EnableChecksum 和 WithSyslogPriority 只对文本格式有效。
+func WithFormat(format Format) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.format = format
+    })
+}
+
+// JSON 格式的一行日志，字段按此顺序输出
+type jsonLine struct {
+    Time        string                 `json:"time"`
+    WriteTime   string                 `json:"write_time,omitempty"` // 由写协程插入，见 EnableWriteTime
+    Elapsed     float64                `json:"elapsed,omitempty"`    // 秒
+    Level       string                 `json:"level"`
+    LevelNumber *int                   `json:"level_number,omitempty"`
+    Service     string                 `json:"service,omitempty"`
+    Version     string                 `json:"version,omitempty"`
+    Tags        []string               `json:"tags,omitempty"`
+    Code        string                 `json:"code,omitempty"`
+    Caller      *Caller                `json:"caller,omitempty"`
+    Message     string                 `json:"message"`
+    Fields      map[string]interface{} `json:"fields,omitempty"`
+}
+
+// 各时间精度的 RFC 3339 格式
+var jsonTimeLayouts = [...]string{
+    TimeMicro: "2006-01-02T15:04:05.000000Z07:00",
+    TimeMilli: "2006-01-02T15:04:05.000Z07:00",
+    TimeNano:  "2006-01-02T15:04:05.000000000Z07:00",
+}
+
+// 构建 JSON 格式的日志行（以换行符结尾），logBody 为可能已压缩的日志体
+func (this *SimLogger) formatJSONLine(entry *Entry, logBody string) string {
+    line := jsonLine{
+        Level:   GetLogLevelName(entry.Level),
+        Service: entry.Service,
+        Version: entry.Version,
+        Tags:    entry.Tags,
+        Code:    entry.Code,
+        Message: strings.TrimSuffix(logBody, "\n"),
+        Fields:  entry.Fields,
+    }
+    precision := this.GetTimePrecision()
+    if precision < TimeMicro || precision > TimeNano {
+        precision = TimeMicro
+    }
+    line.Time = entry.Time.Format(jsonTimeLayouts[precision])
+    if entry.Elapsed > 0 {
+        line.Elapsed = entry.Elapsed.Seconds()
+    }
+    if this.EnabledLevelNumber() {
+        number := int(entry.Level)
+        line.LevelNumber = &number
+    }
+    if !entry.Caller.empty() {
+        caller := entry.Caller.resolve()
+        line.Caller = &caller
+    }
+
+    var buf bytes.Buffer
+    encoder := json.NewEncoder(&buf)
+    encoder.SetEscapeHTML(false)
+    if err := encoder.Encode(&line); err != nil {
+        // 只有 fields 中的值可能无法编码，去掉后重试
+        line.Fields = nil
+        buf.Reset()
+        encoder.Encode(&line)
+    }
+    return buf.String()
+}
+
+// 解析 JSON 格式的日志行
+func parseJSONLine(line string) (Entry, error) {
+    var entry Entry
+    var parsed jsonLine
+
+    if err := json.Unmarshal([]byte(line), &parsed); err != nil {
+        return entry, ErrInvalidLine
+    }
+    logTime, err := time.Parse(time.RFC3339Nano, parsed.Time)
+    if err != nil {
+        return entry, ErrInvalidLine
+    }
+    logLevel, err := ParseLogLevel(parsed.Level)
+    if err != nil {
+        return entry, ErrInvalidLine
+    }
+
+    entry.Time = logTime
+    if parsed.WriteTime != "" {
+        if entry.WriteTime, err = time.Parse(time.RFC3339Nano, parsed.WriteTime); err != nil {
+            return entry, ErrInvalidLine
+        }
+    }
+    entry.Elapsed = time.Duration(parsed.Elapsed * float64(time.Second))
+    entry.Level = logLevel
+    entry.Service = parsed.Service
+    entry.Version = parsed.Version
+    entry.Tags = parsed.Tags
+    if len(entry.Tags) > 0 {
+        entry.Tag = entry.Tags[0]
+    }
+    entry.Code = parsed.Code
+    if parsed.Caller != nil {
+        entry.Caller = *parsed.Caller
+    }
+    entry.Fields = parsed.Fields
+    if entry.Body, _, err = DecompressBody(parsed.Message); err != nil {
+        return entry, ErrInvalidLine
+    }
+    return entry, nil
+}
+
+// 是否为 JSON 格式的日志行
+func isJSONLine(line string) bool {
+    return strings.HasPrefix(line, "{")
+}
@@ -0,0 +1,30 @@
+package simlog
+
+import (
+    "bytes"
+    "path/filepath"
+    "runtime"
+    "testing"
+)
+
+// JSON 格式中的 Caller.File 总是为完整路径，不受 WithCallerTrimPrefix 影响
+func TestJSONCallerFullPath(t *testing.T) {
+    _, file, _, _ := runtime.Caller(0)
+    var buf bytes.Buffer
+    logger := new(SimLogger)
+    err := logger.InitE(EnableFileOutput(false), EnablePrintScreen(true), WithScreenWriter(&buf),
+        WithFormat(FormatJSON), EnableLogCaller(true), WithCallerTrimPrefix(filepath.Dir(filepath.Dir(file))), EnableRegistry(false))
+    if err != nil {
+        t.Fatalf("InitE: %s", err.Error())
+    }
+    logger.Info("hello")
+    logger.Close()
+
+    entry, err := ParseLine(buf.String())
+    if err != nil {
+        t.Fatalf("ParseLine(%q): %s", buf.String(), err.Error())
+    }
+    if entry.Caller.File != file {
+        t.Errorf("caller file = %q, want %q", entry.Caller.File, file)
+    }
+}
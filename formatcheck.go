@@ -0,0 +1,47 @@
+package simlog
+
+import (
+    "fmt"
+    "runtime"
+    "strings"
+)
+
+// WithFormatChecks 是否检查 Xf 系列的格式串和参数是否匹配（默认为 false，DevMode 时开启），
+// 开启后格式化结果中出现 fmt 的错误标记（如“%!d(MISSING)”、“%!(EXTRA int=1)”、“%!d(string=x)”）时，
+// 另以 WARNING 级别输出一条带调用者（总是记录，不论是否 EnableLogCaller）的日志，指出格式串和错误，
+// 以便在开发和测试环境中尽早发现参数个数或类型不匹配。检查需要额外的字符串查找，不建议在生产环境中开启。
+func WithFormatChecks(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.formatChecks = enabled
+    })
+}
+
+// 格式化，开启 WithFormatChecks 时检查，skip 为从 checkFormat 到写日志的调用者之间的调用层数
+func (this *SimLogger) sprintf(skip int, format string, a []interface{}) string {
+    logBody := fmt.Sprintf(format, a...)
+    if this.opts.formatChecks {
+        this.checkFormat(skip, format, a, logBody)
+    }
+    return logBody
+}
+
+// 格式化结果中有 fmt 的错误标记（且不是来自参数本身）时输出 WARNING
+func (this *SimLogger) checkFormat(skip int, format string, a []interface{}, logBody string) {
+    pos := strings.Index(logBody, "%!")
+    if pos < 0 || strings.Contains(format, "%!") {
+        return
+    }
+    for _, arg := range a {
+        if s, ok := arg.(string); ok && strings.Contains(s, "%!") {
+            return // 可能来自参数本身
+        }
+    }
+
+    problem := logBody[pos:]
+    if end := strings.IndexByte(problem, ')'); end >= 0 {
+        problem = problem[:end+1]
+    }
+    var caller Caller
+    caller.pc, caller.File, caller.Line, _ = runtime.Caller(skip)
+    this.output(LL_WARNING, caller, fmt.Sprintf("simlog: format check: %s in format %q with %d args", problem, format, len(a)), true)
+}
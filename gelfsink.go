@@ -0,0 +1,151 @@
+package simlog
+
+import (
+    "bytes"
+    "compress/gzip"
+    "encoding/binary"
+    "encoding/json"
+    "math/rand"
+    "net"
+    "os"
+    "regexp"
+    "strings"
+    "syscall"
+)
+
+// GELF 的 UDP 分块：块大小（Graylog 建议的局域网值）、最多块数和块头的魔数
+const (
+    gelfChunkSize = 8154
+    gelfMaxChunks = 128
+)
+
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// GELF 附加字段名中不允许的字符
+var gelfFieldInvalid = regexp.MustCompile(`[^\w.\-]`)
+
+// NewGELFSink 创建发送到 Graylog 的 GELF 1.1 输出，network 为“udp”或“tcp”，
+// UDP 时超过 8154 字节的消息分块发送（最多128块，超过的被丢弃），compress 为 true 时先 gzip 压缩；
+// TCP 时以空字节分隔，不压缩（GELF TCP 不支持压缩）。
+// 日志级别按 SyslogSeverity 对应为 level，日志体的第一行为 short_message，多行时完整的日志体为 full_message，
+// 服务、标签、错误码、调用者和结构化字段为附加字段（如 _tag、_file、_line）。opts 同 NewNetworkSink（缓冲、重连、落盘和关闭亦同）。
+func NewGELFSink(network, addr string, compress bool, opts ...LogOption) (Sink, error) {
+    host, _ := os.Hostname()
+    encode := func(entry *Entry) string {
+        return encodeGELF(entry, host)
+    }
+    write := func(conn net.Conn, msg string) error {
+        return writeGELF(conn, msg, compress)
+    }
+    sink, err := newNetworkSink(network, addr, opts, encode, write)
+    if err != nil {
+        return nil, err
+    }
+    return sink, nil
+}
+
+// 编码为 GELF 1.1 的 JSON
+func encodeGELF(entry *Entry, host string) string {
+    body := strings.TrimRight(entry.Body, "\r\n")
+    short := body
+    if pos := strings.IndexByte(body, '\n'); pos >= 0 {
+        short = body[:pos]
+    }
+    if short == "" {
+        short = "-" // short_message 不能为空
+    }
+
+    msg := map[string]interface{}{
+        "version":       "1.1",
+        "host":          host,
+        "short_message": short,
+        "timestamp":     float64(entry.Time.UnixMicro()) / 1e6,
+        "level":         SyslogSeverity(entry.Level),
+    }
+    if short != body {
+        msg["full_message"] = body
+    }
+    for key, value := range entry.Fields {
+        msg[gelfFieldName(key)] = value
+    }
+    addGELFField(msg, "service", entry.Service)
+    addGELFField(msg, "version", entry.Version)
+    addGELFField(msg, "tag", entry.Tag)
+    if len(entry.Tags) > 1 {
+        msg["_tags"] = strings.Join(entry.Tags, ",")
+    }
+    addGELFField(msg, "code", entry.Code)
+    addGELFField(msg, "log_level", GetLogLevelName(entry.Level))
+    if !entry.Caller.empty() {
+        msg["_file"] = entry.Caller.File
+        msg["_line"] = entry.Caller.Line
+    }
+
+    data, err := json.Marshal(msg)
+    if err != nil {
+        // 字段的值无法编码时去掉结构化字段再编码
+        for key := range entry.Fields {
+            delete(msg, gelfFieldName(key))
+        }
+        data, _ = json.Marshal(msg)
+    }
+    return string(data)
+}
+
+func addGELFField(msg map[string]interface{}, name, value string) {
+    if value != "" {
+        msg["_"+name] = value
+    }
+}
+
+// 附加字段名：加下划线前缀，不允许的字符替换为下划线，保留的“_id”改为“_id_”
+func gelfFieldName(key string) string {
+    name := "_" + gelfFieldInvalid.ReplaceAllString(key, "_")
+    if name == "_id" {
+        name = "_id_"
+    }
+    return name
+}
+
+// 发送一条 GELF 消息：TCP 等流式连接以空字节结尾，UDP 等数据报按需压缩和分块
+func writeGELF(conn net.Conn, msg string, compress bool) error {
+    if _, ok := conn.(*net.UDPConn); !ok {
+        _, err := conn.Write([]byte(msg + "\x00"))
+        return err
+    }
+
+    data := []byte(msg)
+    if compress {
+        var buf bytes.Buffer
+        zw := gzip.NewWriter(&buf)
+        zw.Write(data)
+        zw.Close()
+        data = buf.Bytes()
+    }
+    if len(data) <= gelfChunkSize {
+        _, err := conn.Write(data)
+        return err
+    }
+
+    dataSize := gelfChunkSize - 12 // 块头：魔数2字节、消息ID 8字节、序号和块数各1字节
+    numChunks := (len(data) + dataSize - 1) / dataSize
+    if numChunks > gelfMaxChunks {
+        return syscall.EMSGSIZE
+    }
+    chunk := make([]byte, 0, gelfChunkSize)
+    id := rand.Uint64()
+    for i := 0; i < numChunks; i++ {
+        end := (i + 1) * dataSize
+        if end > len(data) {
+            end = len(data)
+        }
+        chunk = append(chunk[:0], gelfChunkMagic...)
+        chunk = binary.BigEndian.AppendUint64(chunk, id)
+        chunk = append(chunk, byte(i), byte(numChunks))
+        chunk = append(chunk, data[i*dataSize:end]...)
+        if _, err := conn.Write(chunk); err != nil {
+            return err
+        }
+    }
+    return nil
+}
@@ -0,0 +1,193 @@
+//go:build ignore
+
+// 生成日志级别的常量、级别名和各级别的写日志方法（level_methods.go），由 go generate 调用：
+//
+//	go generate github.com/eyjian/simlog
+//
+// 增加日志级别时，在 levels 中按级别值的顺序加一行，重新生成即得到 LL_X 常量、级别名（GetLogLevelName、
+// ParseLogLevel 和 String 的取值范围随之扩展）、X/Xln/Xf、SkipX/SkipXln/SkipXf、IsEnabledXLog，
+// 以及 Logger 接口和 Tee 的对应方法，不必再手工复制，也就不会出现各级别间的不一致。
+package main
+
+import (
+    "bytes"
+    "go/format"
+    "log"
+    "os"
+    "strings"
+    "text/template"
+)
+
+// 一个日志级别，级别值为在 levels 中的下标
+type level struct {
+    Name      string // 方法名中的级别名，如 Info，大写即为级别名，前加“LL_”即为 LogLevel 常量
+    Desc      string // 中文名，用于注释
+    ConstNote string // LogLevel 常量的注释，可为空
+    Note      string // 附加的注释，可为空
+    Enabled   string // IsEnabledXLog 的判断表达式，为空时按日志级别判断
+    Caller    bool   // 是否记录调用者，为 false 时不生成 Skip 方法和 IsEnabledXLog
+    Capture   bool   // 未开启时是否放入 WithDebugBuffer 的内存环
+    Fatal     bool   // 是否按 FatalPolicy 处理
+}
+
+// 级别名，如 INFO
+func (this level) Upper() string {
+    return strings.ToUpper(this.Name)
+}
+
+// LogLevel 常量，如 LL_INFO
+func (this level) Const() string {
+    return "LL_" + this.Upper()
+}
+
+var levels = []level{
+    {Name: "Fatal", Desc: "致命错误日志", Note: "注意在调用后进程默认会退出，可通过 WithFatalPolicy 改变。", Caller: true, Fatal: true},
+    {Name: "Error", Desc: "错误日志", Caller: true},
+    {Name: "Warning", Desc: "警示日志", Caller: true},
+    {Name: "Notice", Desc: "注意日志", Caller: true},
+    {Name: "Info", Desc: "信息日志", ConstNote: "默认日志级别", Caller: true},
+    {Name: "Debug", Desc: "调试日志", Caller: true, Capture: true},
+    {Name: "Detail", Desc: "详细日志", ConstNote: "比DEBUG更详细的级别", Caller: true},
+    {Name: "Trace", Desc: "跟踪日志", ConstNote: "跟踪日志，独立的日志级别", Enabled: "atomic.LoadInt32(&this.opts.enableTraceLog) == 1", Caller: true},
+    {Name: "Raw", Desc: "裸日志", ConstNote: "裸日志"},
+}
+
+const tmpl = `// Code generated by gen_levels.go; DO NOT EDIT.
+
+package simlog
+
+import (
+    "sync/atomic"
+)
+
+// 调用函数 GetLogLevelName，可取得对应级别的字符串值
+const (
+{{- range $i, $l := .}}
+    {{$l.Const}} LogLevel = {{$i}}{{if $l.ConstNote}} // {{$l.ConstNote}}{{end}}
+{{- end}}
+)
+
+// 各日志级别的级别名，下标为日志级别，也是 GetLogLevelName、ParseLogLevel 和 String 的取值范围
+var logLevelNames = [...]string{
+{{- range .}}
+    "{{.Upper}}",
+{{- end}}
+}
+
+// Logger 写日志的接口，*SimLogger 和 Tee 的返回值均实现了此接口
+type Logger interface {
+{{- range .}}
+    {{.Name}}(a ...interface{}) (int, error)
+    {{.Name}}ln(a ...interface{}) (int, error)
+    {{.Name}}f(format string, a ...interface{}) (int, error)
+{{- end}}
+}
+
+var _ Logger = (*SimLogger)(nil)
+{{range .}}
+// 写{{.Desc}}（{{.Name}}）{{if .Note}}，
+// {{.Note}}{{end}}
+{{if .Caller}}
+func (this *SimLogger) IsEnabled{{.Name}}Log() bool {
+    this.checkInit()
+    return this.loggerCore != nil && {{if .Enabled}}{{.Enabled}}{{else}}atomic.LoadInt32(&this.opts.logLevel) >= int32({{.Const}}){{end}}
+}
+
+func (this *SimLogger) {{.Name}}(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.Skip{{.Name}}(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) {{.Name}}ln(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.Skip{{.Name}}ln(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) {{.Name}}f(format string, a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.Skip{{.Name}}f(this.GetSkip(), format, a...)
+}
+
+// 写{{.Desc}}（Skip{{.Name}}）
+
+func (this *SimLogger) Skip{{.Name}}(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabled{{.Name}}Log() {
+{{- if .Capture}}
+        if this.debugBuffer != nil {
+            this.skipCapture(skip, false, a)
+        }
+{{- end}}
+        return 0, nil
+    }
+    return {{if .Fatal}}this.skipFatal(skip, false, a){{else}}this.skipLog({{.Const}}, skip, false, a){{end}}
+}
+
+func (this *SimLogger) Skip{{.Name}}ln(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabled{{.Name}}Log() {
+{{- if .Capture}}
+        if this.debugBuffer != nil {
+            this.skipCapture(skip, true, a)
+        }
+{{- end}}
+        return 0, nil
+    }
+    return {{if .Fatal}}this.skipFatal(skip, true, a){{else}}this.skipLog({{.Const}}, skip, true, a){{end}}
+}
+
+func (this *SimLogger) Skip{{.Name}}f(skip int32, format string, a ...interface{}) (int, error) {
+    if !this.IsEnabled{{.Name}}Log() {
+{{- if .Capture}}
+        if this.debugBuffer != nil {
+            this.skipCapturef(skip, format, a)
+        }
+{{- end}}
+        return 0, nil
+    }
+    return {{if .Fatal}}this.skipFatalf(skip, format, a){{else}}this.skipLogf({{.Const}}, skip, format, a){{end}}
+}
+{{else}}
+func (this *SimLogger) {{.Name}}(a ...interface{}) (int, error) {
+    return this.log({{.Const}}, Caller{}, a...)
+}
+
+func (this *SimLogger) {{.Name}}ln(a ...interface{}) (int, error) {
+    return this.logln({{.Const}}, Caller{}, a...)
+}
+
+func (this *SimLogger) {{.Name}}f(format string, a ...interface{}) (int, error) {
+    return this.logf({{.Const}}, Caller{}, format, a...)
+}
+{{end}}
+func (this *teeLogger) {{.Name}}(a ...interface{}) (int, error) {
+    return this.output({{.Const}}, false, nil, a)
+}
+
+func (this *teeLogger) {{.Name}}ln(a ...interface{}) (int, error) {
+    return this.output({{.Const}}, true, nil, a)
+}
+
+func (this *teeLogger) {{.Name}}f(format string, a ...interface{}) (int, error) {
+    return this.output({{.Const}}, false, &format, a)
+}
+{{end}}`
+
+func main() {
+    var buf bytes.Buffer
+    if err := template.Must(template.New("levels").Parse(tmpl)).Execute(&buf, levels); err != nil {
+        log.Fatal(err)
+    }
+    src, err := format.Source(buf.Bytes())
+    if err != nil {
+        log.Fatal(err)
+    }
+
+    // 同其它源代码文件，以4个空格缩进
+    lines := strings.Split(string(src), "\n")
+    for i, line := range lines {
+        trimmed := strings.TrimLeft(line, "\t")
+        lines[i] = strings.Repeat("    ", len(line)-len(trimmed)) + trimmed
+    }
+    if err = os.WriteFile("level_methods.go", []byte(strings.Join(lines, "\n")), 0644); err != nil {
+        log.Fatal(err)
+    }
+}
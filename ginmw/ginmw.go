@@ -0,0 +1,99 @@
+// Package ginmw 提供 gin 的访问日志中间件，每个请求一行日志写到 simlog，
+// 并在处理请求时 panic 的情况下恢复，以 ERROR 级别记录 panic 值和调用栈后返回500。
+//
+// 用法：
+// router := gin.New()
+// router.Use(ginmw.New(&mylog))
+//
+// 访问日志的日志体如：
+//
+//	GET /api/users?id=1 status=200 latency=1.234ms ip=10.0.0.1
+//
+// 级别按状态码：5xx 为 ERROR，4xx 为 WARNING，其它为 INFO（可用 WithLevel 改为固定级别）。
+package ginmw
+
+import (
+    "net/http"
+    "runtime/debug"
+    "strconv"
+    "time"
+)
+import (
+    "github.com/eyjian/simlog"
+    "github.com/gin-gonic/gin"
+)
+
+// Option 中间件的选项
+type Option func(*options)
+
+type options struct {
+    level     simlog.LogLevel     // 访问日志的固定级别，为 LL_RAW 时按状态码
+    skipPaths map[string]struct{} // 不记录访问日志的路径
+}
+
+// WithLevel 访问日志固定使用 level 级别，而不是按状态码
+func WithLevel(level simlog.LogLevel) Option {
+    return func(o *options) {
+        o.level = level
+    }
+}
+
+// WithSkipPaths 不记录这些路径（不含查询参数，如健康检查的“/healthz”）的访问日志，panic 仍会记录
+func WithSkipPaths(paths ...string) Option {
+    return func(o *options) {
+        for _, path := range paths {
+            o.skipPaths[path] = struct{}{}
+        }
+    }
+}
+
+// New 创建访问日志和 panic 恢复的中间件，日志写到 logger
+func New(logger *simlog.SimLogger, opts ...Option) gin.HandlerFunc {
+    o := options{level: simlog.LL_RAW, skipPaths: make(map[string]struct{})}
+    for _, opt := range opts {
+        opt(&o)
+    }
+
+    return func(c *gin.Context) {
+        start := time.Now()
+        path := c.Request.URL.Path
+        defer func() {
+            if r := recover(); r != nil {
+                logger.Recovered(r, debug.Stack())
+                c.AbortWithStatus(http.StatusInternalServerError)
+            }
+            if _, ok := o.skipPaths[path]; ok {
+                return
+            }
+
+            status := c.Writer.Status()
+            level := o.level
+            if level == simlog.LL_RAW {
+                level = levelOfStatus(status)
+            }
+            if !logger.Enabled(level) {
+                return
+            }
+            if c.Request.URL.RawQuery != "" {
+                path += "?" + c.Request.URL.RawQuery
+            }
+            logger.Output(level, simlog.Caller{}, c.Request.Method+" "+path+
+                " status="+strconv.Itoa(status)+
+                " latency="+time.Since(start).String()+
+                " ip="+c.ClientIP())
+        }()
+        c.Next()
+    }
+}
+
+// 按状态码确定访问日志的级别
+func levelOfStatus(status int) simlog.LogLevel {
+    switch {
+    case status >= http.StatusInternalServerError:
+        return simlog.LL_ERROR
+    case status >= http.StatusBadRequest:
+        return simlog.LL_WARNING
+    default:
+        return simlog.LL_INFO
+    }
+}
@@ -0,0 +1,224 @@
+// Package glog 提供和 github.com/golang/glog 兼容的接口，底层由 simlog.SimLogger 实现，
+// 以便正在使用 glog 的服务迁移到 simlog：只需将导入路径改为 github.com/eyjian/simlog/glog。
+//
+// 支持的命令行参数（和 glog 同名，注册在 flag.CommandLine 上，因此不能同时链接原版 glog）：
+//
+//	-v               V 日志的级别
+//	-logtostderr     日志只输出到标准错误，不写日志文件
+//	-alsologtostderr 日志同时输出到标准错误
+//	-log_dir         日志目录，为空时同 simlog 的默认日志目录
+//
+// 第一次写日志时才按参数初始化日志，所以应在 flag.Parse 之后再写日志，
+// 也可以调用 SetLogger 使用自己初始化好的 SimLogger。
+package glog
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "strings"
+    "sync"
+    "sync/atomic"
+)
+import (
+    "github.com/eyjian/simlog"
+)
+
+// 从 glog 的接口函数到调用者的跳数（参见 SimLogger 的 SetSkip）
+const skip = 3
+
+// Level V 日志的级别
+type Level int32
+
+var (
+    verbosity       Level
+    logToStderr     bool
+    alsoLogToStderr bool
+    logDir          string
+
+    initOnce sync.Once
+    logger   *simlog.SimLogger
+)
+
+func init() {
+    flag.Var(&verbosity, "v", "log level for V logs")
+    flag.BoolVar(&logToStderr, "logtostderr", false, "log to standard error instead of files")
+    flag.BoolVar(&alsoLogToStderr, "alsologtostderr", false, "log to standard error as well as files")
+    flag.StringVar(&logDir, "log_dir", "", "If non-empty, write log files in this directory")
+}
+
+// Get 实现 flag.Getter
+func (this *Level) Get() interface{} {
+    return Level(atomic.LoadInt32((*int32)(this)))
+}
+
+// Set 实现 flag.Value
+func (this *Level) Set(value string) error {
+    var v int32
+    if _, err := fmt.Sscanf(value, "%d", &v); err != nil {
+        return err
+    }
+    atomic.StoreInt32((*int32)(this), v)
+    return nil
+}
+
+// String 实现 flag.Value
+func (this *Level) String() string {
+    return fmt.Sprintf("%d", atomic.LoadInt32((*int32)(this)))
+}
+
+// SetLogger 使用自己初始化好的 SimLogger，应在第一次写日志之前调用
+func SetLogger(simLogger *simlog.SimLogger) {
+    initOnce.Do(func() {})
+    logger = simLogger
+}
+
+// 取得日志，第一次调用时按命令行参数初始化
+func getLogger() *simlog.SimLogger {
+    initOnce.Do(func() {
+        opts := []simlog.LogOption{
+            simlog.EnableLogCaller(true),
+            simlog.EnableLineFeed(true),
+            simlog.WithFatalExitCode(255),
+            simlog.WithScreenWriter(os.Stderr),
+        }
+        if logDir != "" {
+            opts = append(opts, simlog.WithLogdir(logDir))
+        }
+        if logToStderr {
+            opts = append(opts, simlog.EnableFileOutput(false), simlog.EnablePrintScreen(true))
+        } else if alsoLogToStderr {
+            opts = append(opts, simlog.EnablePrintScreen(true))
+        }
+
+        logger = new(simlog.SimLogger)
+        logger.Init(opts...)
+    })
+    return logger
+}
+
+// Flush 写出所有缓冲的日志，应在进程退出前调用
+func Flush() {
+    getLogger().Flush()
+}
+
+// 去掉末尾的换行符，换行由 SimLogger 自动加上
+func trimLineFeed(s string) string {
+    return strings.TrimSuffix(s, "\n")
+}
+
+// Verbose V 的返回值，为 true 时才输出日志
+type Verbose bool
+
+// V 返回 level 是否不大于 -v 指定的级别，用法：glog.V(2).Infof("...")
+func V(level Level) Verbose {
+    return Verbose(level <= verbosity.Get().(Level))
+}
+
+func (this Verbose) Info(args ...interface{}) {
+    if this {
+        infoDepth(1, fmt.Sprint(args...))
+    }
+}
+
+func (this Verbose) Infoln(args ...interface{}) {
+    if this {
+        infoDepth(1, fmt.Sprintln(args...))
+    }
+}
+
+func (this Verbose) Infof(format string, args ...interface{}) {
+    if this {
+        infoDepth(1, fmt.Sprintf(format, args...))
+    }
+}
+
+func infoDepth(depth int, body string) {
+    l := getLogger()
+    if l.IsEnabledInfoLog() {
+        l.SkipInfo(int32(skip+depth), trimLineFeed(body))
+    }
+}
+
+func Info(args ...interface{}) {
+    infoDepth(1, fmt.Sprint(args...))
+}
+
+func InfoDepth(depth int, args ...interface{}) {
+    infoDepth(1+depth, fmt.Sprint(args...))
+}
+
+func Infoln(args ...interface{}) {
+    infoDepth(1, fmt.Sprintln(args...))
+}
+
+func Infof(format string, args ...interface{}) {
+    infoDepth(1, fmt.Sprintf(format, args...))
+}
+
+func warningDepth(depth int, body string) {
+    l := getLogger()
+    if l.IsEnabledWarningLog() {
+        l.SkipWarning(int32(skip+depth), trimLineFeed(body))
+    }
+}
+
+func Warning(args ...interface{}) {
+    warningDepth(1, fmt.Sprint(args...))
+}
+
+func WarningDepth(depth int, args ...interface{}) {
+    warningDepth(1+depth, fmt.Sprint(args...))
+}
+
+func Warningln(args ...interface{}) {
+    warningDepth(1, fmt.Sprintln(args...))
+}
+
+func Warningf(format string, args ...interface{}) {
+    warningDepth(1, fmt.Sprintf(format, args...))
+}
+
+func errorDepth(depth int, body string) {
+    l := getLogger()
+    if l.IsEnabledErrorLog() {
+        l.SkipError(int32(skip+depth), trimLineFeed(body))
+    }
+}
+
+func Error(args ...interface{}) {
+    errorDepth(1, fmt.Sprint(args...))
+}
+
+func ErrorDepth(depth int, args ...interface{}) {
+    errorDepth(1+depth, fmt.Sprint(args...))
+}
+
+func Errorln(args ...interface{}) {
+    errorDepth(1, fmt.Sprintln(args...))
+}
+
+func Errorf(format string, args ...interface{}) {
+    errorDepth(1, fmt.Sprintf(format, args...))
+}
+
+// Fatal 等写致命错误日志后以退出码 255 退出进程（同 glog）
+func fatalDepth(depth int, body string) {
+    getLogger().SkipFatal(int32(skip+depth), trimLineFeed(body))
+}
+
+func Fatal(args ...interface{}) {
+    fatalDepth(1, fmt.Sprint(args...))
+}
+
+func FatalDepth(depth int, args ...interface{}) {
+    fatalDepth(1+depth, fmt.Sprint(args...))
+}
+
+func Fatalln(args ...interface{}) {
+    fatalDepth(1, fmt.Sprintln(args...))
+}
+
+func Fatalf(format string, args ...interface{}) {
+    fatalDepth(1, fmt.Sprintf(format, args...))
+}
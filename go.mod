@@ -1,7 +1,9 @@
 module github.com/eyjian/simlog
 
-go 1.21
+go 1.21.0
 
-require github.com/gofrs/flock v0.12.1
-
-require golang.org/x/sys v0.22.0 // indirect
\ No newline at end of file
+require (
+	github.com/gofrs/flock v0.12.1
+	github.com/klauspost/compress v1.17.11
+	golang.org/x/sys v0.22.0
+)
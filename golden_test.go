@@ -0,0 +1,63 @@
+package simlog
+
+import (
+    "encoding/json"
+    "os"
+    "strings"
+    "testing"
+)
+
+// 渲染黄金用例需设置的选项（日志行以换行符结尾时另加 EnableLineFeed），新增需特殊选项的用例时同步更新
+var goldenRenderOptions = map[string][]LogOption{
+    "checksum":        {EnableChecksum(true)},
+    "raw_with_time":   {EnableRawLog(true), EnableRawLogTime(true)},
+    "milliseconds":    {WithTimePrecision(TimeMilli)},
+    "nanoseconds":     {WithTimePrecision(TimeNano)},
+    "zone_offset":     {WithTimeZoneFormat(TimeZoneOffset)},
+    "zone_name":       {WithTimePrecision(TimeMilli), WithTimeZoneFormat(TimeZoneName)},
+    "syslog_priority": {WithSyslogPriority(FacilityLocal0)},
+    "compressed_body": {WithBodyCompression(16)},
+    "level_number":    {EnableLevelNumber(true)},
+    "json":            {WithFormat(FormatJSON)},
+}
+
+// 每个合法的黄金用例（simlogtest/testdata/format.golden.json，simlogtest 依赖本包，所以这里直接读文件）
+// 解析后再经日志行的构建渲染，须与用例的日志行一致
+func TestGoldenRender(t *testing.T) {
+    data, err := os.ReadFile("simlogtest/testdata/format.golden.json")
+    if err != nil {
+        t.Fatal(err)
+    }
+    var cases []struct {
+        Name    string `json:"name"`
+        Line    string `json:"line"`
+        Invalid bool   `json:"invalid"`
+    }
+    if err := json.Unmarshal(data, &cases); err != nil {
+        t.Fatal(err)
+    }
+
+    for _, c := range cases {
+        if c.Invalid {
+            continue
+        }
+        t.Run(c.Name, func(t *testing.T) {
+            entry, err := ParseLine(c.Line)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            opts := append([]LogOption{EnableLineFeed(strings.HasSuffix(c.Line, "\n"))}, goldenRenderOptions[c.Name]...)
+            var logger SimLogger
+            logger.Init(opts...)
+            defer logger.Close()
+            got, _ := logger.buildLogLine(&entry, false)
+            if !entry.WriteTime.IsZero() {
+                got = logger.stampWriteTime(entry.Level, got, entry.WriteTime) // 写入时间由写协程插入
+            }
+            if got != c.Line {
+                t.Errorf("got  %q\nwant %q", got, c.Line)
+            }
+        })
+    }
+}
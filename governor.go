@@ -0,0 +1,63 @@
+package simlog
+
+import (
+    "fmt"
+    "math/rand"
+    "sync/atomic"
+    "time"
+)
+
+// 自适应限流的最大档位，此时 NOTICE 及更详细级别的日志只输出 1/64
+const governorMaxStep = 6
+
+// WithCPUBudget 按每秒在 simlog 中花费的时间自适应限流（默认为0，不限流），以免意外的日志风暴拖垮延迟 SLO，
+// 如 WithCPUBudget(50*time.Millisecond) 表示每秒最多约 5% 个 CPU 用于写日志。
+// 花费的时间为各写日志调用（格式化、观察者、同步写等）的耗时之和，按秒统计，
+// 超过 budget 时限流升一档，每档 NOTICE 及更详细级别（含跟踪日志）的日志的采样率减半，WARNING 及更严重级别和裸日志不限流，
+// 低于 budget 的一半时降一档，档位变化时输出一条 NOTICE 日志说明，被限流的日志计入 LogStats.Governed。
+func WithCPUBudget(budget time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.cpuBudget = budget
+    })
+}
+
+// 按限流档位采样，返回false表示不输出
+func (this *SimLogger) governed(logLevel LogLevel) bool {
+    step := atomic.LoadInt32(&this.governorStep)
+    if step == 0 || logLevel <= LL_WARNING || logLevel == LL_RAW {
+        return true
+    }
+    if rand.Int63n(1<<uint(step)) == 0 {
+        return true
+    }
+    atomic.AddInt64(&this.numGoverned, 1)
+    return false
+}
+
+// 计入一次写日志调用的耗时，满一秒时调整限流档位
+func (this *SimLogger) chargeCPU(start time.Time) {
+    now := time.Now()
+    atomic.AddInt64(&this.governorSpent, int64(now.Sub(start)))
+    window := atomic.LoadInt64(&this.governorWindow)
+    elapsed := now.UnixNano() - window
+    if elapsed < int64(time.Second) || !atomic.CompareAndSwapInt64(&this.governorWindow, window, now.UnixNano()) {
+        return
+    }
+
+    spent := time.Duration(atomic.SwapInt64(&this.governorSpent, 0) * int64(time.Second) / elapsed) // 折算为每秒
+    step := atomic.LoadInt32(&this.governorStep)
+    switch {
+    case spent > this.opts.cpuBudget && step < governorMaxStep:
+        step++
+    case spent < this.opts.cpuBudget/2 && step > 0:
+        step--
+    default:
+        return
+    }
+    atomic.StoreInt32(&this.governorStep, step)
+    if step == 0 {
+        this.putInternalLog(now, LL_NOTICE, fmt.Sprintf("simlog: cpu governor: spent %s/s within budget %s/s, verbose logs restored", spent.String(), this.opts.cpuBudget.String()))
+    } else {
+        this.putInternalLog(now, LL_NOTICE, fmt.Sprintf("simlog: cpu governor: spent %s/s against budget %s/s, sampling NOTICE and below at 1/%d", spent.String(), this.opts.cpuBudget.String(), 1<<uint(step)))
+    }
+}
@@ -0,0 +1,57 @@
+package simlog
+
+import (
+    "errors"
+    "fmt"
+    "sync/atomic"
+)
+
+// WithHealthThresholds 设置 Healthy 的阈值：两次 Healthy 之间丢弃的日志行数超过 maxDropped，
+// 或影子输出写失败次数超过 maxShadowErrors 时视为不健康，小于0表示不限制（默认均为0，即有任何丢弃或失败都视为不健康）。
+func WithHealthThresholds(maxDropped, maxShadowErrors int64) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.healthMaxDropped = maxDropped
+        o.healthMaxShadowErrors = maxShadowErrors
+    })
+}
+
+// Healthy 报告日志是否健康，以便服务将日志的健康状况纳入就绪探针（readiness probe），检查：
+// 1）异步写时写协程是否正常运行；
+// 2）日志文件是否可写（以追加方式打开，日志文件不存在时会创建；未开启写日志文件时不检查）；
+// 3）自上次调用 Healthy 以来丢弃的日志行数和影子输出写失败次数是否在阈值内，见 WithHealthThresholds。
+// 不健康时返回的 error 说明了所有原因。
+func (this *SimLogger) Healthy() (bool, error) {
+    var errs []error
+
+    if atomic.LoadInt32(&this.closed) == 1 {
+        return false, errors.New("simlog: logger is closed")
+    }
+    if this.opts.asyncWrite {
+        if state := atomic.LoadInt32(&this.writerState); state == writerFailing || state == writerRestarting {
+            errs = append(errs, errors.Join(ErrWriterDown, this.getWriterError()))
+        }
+    }
+    if !this.opts.noFileOutput {
+        if file, err := this.openLogFile(); err != nil {
+            errs = append(errs, fmt.Errorf("simlog: log file not writable: %w", err))
+        } else {
+            file.Close()
+        }
+    }
+
+    numDropped := atomic.LoadInt64(&this.numDropped)
+    dropped := numDropped - atomic.SwapInt64(&this.healthDropped, numDropped)
+    if this.opts.healthMaxDropped >= 0 && dropped > this.opts.healthMaxDropped {
+        errs = append(errs, fmt.Errorf("simlog: %d log lines dropped since last check", dropped))
+    }
+    numShadowErrors := atomic.LoadInt64(&this.numShadowErrors)
+    shadowErrors := numShadowErrors - atomic.SwapInt64(&this.healthShadowErrors, numShadowErrors)
+    if this.opts.healthMaxShadowErrors >= 0 && shadowErrors > this.opts.healthMaxShadowErrors {
+        errs = append(errs, fmt.Errorf("simlog: %d shadow sink errors since last check", shadowErrors))
+    }
+
+    if len(errs) > 0 {
+        return false, errors.Join(errs...)
+    }
+    return true, nil
+}
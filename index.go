@@ -0,0 +1,148 @@
+package simlog
+
+import (
+    "bufio"
+    "encoding/json"
+    "errors"
+    "io"
+    "os"
+    "sort"
+    "time"
+)
+
+// 索引文件的后缀，索引文件和日志文件同名，如“xxx.log.1.idx”
+const indexSuffix = ".idx"
+
+// ErrStaleIndex 索引已过期（日志文件比建立索引时小，已不是同一个文件）
+var ErrStaleIndex = errors.New("simlog: stale log index")
+
+// WithRotationIndex 设置滚动时为滚动出的备份文件建立索引（默认为0，表示不建立），
+// 索引文件和备份文件同名加 .idx 后缀，随备份文件一起改名和覆盖，
+// 记录首末日志的时间、各级别的条数，以及每 every 条日志的时间和字节偏移，
+// 以便 ExtractRange 和 ReadLogIndex 的使用者按时间定位，不必从头扫描整个文件。
+// 建立索引需在滚动时（持有文件锁）读一遍被滚动的文件。
+func WithRotationIndex(every int) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.indexEvery = every
+    })
+}
+
+// LogIndex 一个日志文件的索引
+type LogIndex struct {
+    Size   int64            `json:"size"`   // 建立索引时日志文件的大小
+    First  time.Time        `json:"first"`  // 第一条日志的时间
+    Last   time.Time        `json:"last"`   // 最后一条日志的时间
+    Lines  int64            `json:"lines"`  // 带时间的日志条数
+    Levels map[string]int64 `json:"levels"` // 各级别的日志条数，键为级别名，如“INFO”
+    Every  int              `json:"every"`  // 每多少条日志记录一个索引点
+    Points []IndexPoint     `json:"points"` // 索引点，按偏移排列
+}
+
+// IndexPoint 索引点：一条日志的时间和其所在行的开始偏移
+type IndexPoint struct {
+    Time   time.Time `json:"time"`
+    Offset int64     `json:"offset"`
+}
+
+// BuildLogIndex 读一遍日志文件，建立每 every 条日志一个索引点的索引
+func BuildLogIndex(path string, every int) (*LogIndex, error) {
+    if every <= 0 {
+        every = 1
+    }
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    index := &LogIndex{Levels: make(map[string]int64), Every: every}
+    reader := bufio.NewReader(f)
+    var offset int64
+    for {
+        line, err := reader.ReadString('\n')
+        if entry, e := ParseLine(line); e == nil && entry.Level != LL_RAW {
+            if index.Lines%int64(every) == 0 {
+                index.Points = append(index.Points, IndexPoint{Time: entry.Time, Offset: offset})
+            }
+            if index.Lines == 0 {
+                index.First = entry.Time
+            }
+            index.Last = entry.Time
+            index.Lines++
+            index.Levels[GetLogLevelName(entry.Level)]++
+        }
+        offset += int64(len(line))
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+    }
+    index.Size = offset
+    return index, nil
+}
+
+// WriteLogIndex 为日志文件建立索引并写到同名的 .idx 文件
+func WriteLogIndex(path string, every int) error {
+    index, err := BuildLogIndex(path, every)
+    if err != nil {
+        return err
+    }
+    data, err := json.Marshal(index)
+    if err != nil {
+        return err
+    }
+    tmpPath := path + indexSuffix + ".tmp"
+    if err = os.WriteFile(tmpPath, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmpPath, path+indexSuffix)
+}
+
+// ReadLogIndex 读取日志文件的索引（同名的 .idx 文件），
+// 日志文件比建立索引时小（已不是同一个文件）时返回 ErrStaleIndex；比建立索引时大（滚动后其它进程又追加了少量日志）时索引仍可用于定位。
+func ReadLogIndex(path string) (*LogIndex, error) {
+    data, err := os.ReadFile(path + indexSuffix)
+    if err != nil {
+        return nil, err
+    }
+    var index LogIndex
+    if err = json.Unmarshal(data, &index); err != nil {
+        return nil, err
+    }
+    fi, err := os.Stat(path)
+    if err != nil {
+        return nil, err
+    }
+    if fi.Size() < index.Size {
+        return nil, ErrStaleIndex
+    }
+    return &index, nil
+}
+
+// Offset 返回开始读时间不早于 from 的日志的偏移：时间早于 from 的最后一个索引点的偏移，没有时为0，
+// 从此处读起，跳过时间早于 from 的日志即可
+func (this *LogIndex) Offset(from time.Time) int64 {
+    i := sort.Search(len(this.Points), func(i int) bool {
+        return !this.Points[i].Time.Before(from)
+    })
+    if i == 0 {
+        return 0
+    }
+    return this.Points[i-1].Offset
+}
+
+// 滚动前为当前日志文件建立索引，索引文件随后和日志文件一起改名
+func (this *SimLogger) indexRotatedFile(path string) {
+    if err := WriteLogIndex(path, this.opts.indexEvery); err != nil {
+        os.Remove(path + indexSuffix)
+    }
+}
+
+// 改名日志文件的索引文件，没有索引文件时删除目标处的（以免留下过期的索引）
+func renameIndex(oldFilepath, newFilepath string) {
+    if err := os.Rename(oldFilepath+indexSuffix, newFilepath+indexSuffix); err != nil {
+        os.Remove(newFilepath + indexSuffix)
+    }
+}
@@ -0,0 +1,150 @@
+package simlog
+
+import (
+    "math/rand"
+    "sync"
+    "time"
+)
+
+// 按标签采样的规则
+type labelSampling struct {
+    key   string
+    value string
+    rate  float64
+}
+
+// 按标签路由的规则
+type labelRoute struct {
+    key   string
+    value string
+    sinks []Sink
+}
+
+// 按标签限流的规则（令牌桶，桶容量为每秒的条数）
+type labelRateLimit struct {
+    key       string
+    value     string
+    perSecond float64
+    mutex     sync.Mutex
+    tokens    float64   // 剩余的令牌数
+    last      time.Time // 上次补充令牌的时间，为零值时桶是满的
+}
+
+// WithLabels 返回附带标签的子日志，标签和结构化字段不同，不输出到日志中，
+// 只用于路由、采样和限流决策（见 WithLabelRoute、WithLabelSampling 和 WithLabelRateLimit），
+// 如 logger.WithLabels(map[string]string{"noisy": "true"})。
+// 子日志的标签为本日志的标签加上 labels（同名的以 labels 为准），
+// 子日志和本日志共用选项、队列和日志文件，可保存下来重复使用。
+func (this *SimLogger) WithLabels(labels map[string]string) *SimLogger {
+    child := *this
+    child.labels = make(map[string]string, len(this.labels)+len(labels))
+    for key, value := range this.labels {
+        child.labels[key] = value
+    }
+    for key, value := range labels {
+        child.labels[key] = value
+    }
+    return &child
+}
+
+// GetLabels 返回标签，未设置时为 nil，返回的 map 不应修改
+func (this *SimLogger) GetLabels() map[string]string {
+    return this.labels
+}
+
+// WithLabelRoute 将带标签 key=value 的日志（见 WithLabels）只写到 sinks，
+// 不再写日志自己的输出（日志文件等）和 WithSink 的输出，如将审计日志写到单独的日志文件：
+//
+//	audit, _ := simlog.NewFileSink(simlog.WithFilename("audit.log"))
+//	logger.Init(simlog.WithLabelRoute("audit", "true", audit))
+//	logger.WithLabels(map[string]string{"audit": "true"}).Info("user=alice action=delete")
+//
+// 可设置多条规则，日志匹配多条时按最先设置的一条路由，路由后写日志的函数返回 0, nil，
+// 写失败计入 LogStats.SinkErrors。关闭日志时关闭 sinks，同一个输出只应设置一次。
+func WithLabelRoute(key, value string, sinks ...Sink) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.labelRoutes = append(o.labelRoutes, labelRoute{key: key, value: value, sinks: sinks})
+    })
+}
+
+// 按标签路由，返回日志应写到的输出，不路由时返回 nil
+func (this *SimLogger) routeLabels() []Sink {
+    if len(this.labels) == 0 {
+        return nil
+    }
+    for _, route := range this.opts.labelRoutes {
+        if value, ok := this.labels[route.key]; ok && value == route.value {
+            return route.sinks
+        }
+    }
+    return nil
+}
+
+// WithLabelSampling 对带标签 key=value 的日志（见 WithLabels）按比例 rate（0到1）采样，
+// 如 WithLabelSampling("noisy", "true", 0.01) 只输出1%，未被采中的日志直接丢弃（不计入丢弃数）。
+// 可设置多条规则，日志匹配多条时按最小的比例采样。裸日志和致命错误日志不采样。
+func WithLabelSampling(key, value string, rate float64) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.labelSamplings = append(o.labelSamplings, labelSampling{key: key, value: value, rate: rate})
+    })
+}
+
+// WithLabelRateLimit 限制带标签 key=value 的日志（见 WithLabels）每秒最多输出 perSecond 条，
+// 允许一秒内的突发，超出的直接丢弃（不计入丢弃数），如 WithLabelRateLimit("noisy", "true", 100)。
+// 同一规则由所有匹配的子日志共享，可设置多条规则，日志须通过所匹配的每一条。
+// 先采样后限流，裸日志和致命错误日志不限流。
+func WithLabelRateLimit(key, value string, perSecond int) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.labelRateLimits = append(o.labelRateLimits, &labelRateLimit{key: key, value: value, perSecond: float64(perSecond)})
+    })
+}
+
+// 按标签采样和限流，返回false表示不输出
+func (this *SimLogger) filterLabels(logLevel LogLevel) bool {
+    if len(this.labels) == 0 || logLevel == LL_RAW || logLevel == LL_FATAL {
+        return true
+    }
+    if !this.sampleLabels() {
+        return false
+    }
+
+    now := time.Now()
+    for _, limit := range this.opts.labelRateLimits {
+        if value, ok := this.labels[limit.key]; ok && value == limit.value && !limit.allow(now) {
+            return false
+        }
+    }
+    return true
+}
+
+// 按标签采样，返回false表示未被采中
+func (this *SimLogger) sampleLabels() bool {
+    rate := 1.0
+    for _, sampling := range this.opts.labelSamplings {
+        if value, ok := this.labels[sampling.key]; ok && value == sampling.value && sampling.rate < rate {
+            rate = sampling.rate
+        }
+    }
+    return rate >= 1 || rand.Float64() < rate
+}
+
+// 取一个令牌，没有时返回false
+func (this *labelRateLimit) allow(now time.Time) bool {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+
+    if this.last.IsZero() {
+        this.tokens = this.perSecond
+    } else if elapsed := now.Sub(this.last); elapsed > 0 {
+        this.tokens += elapsed.Seconds() * this.perSecond
+        if this.tokens > this.perSecond {
+            this.tokens = this.perSecond
+        }
+    }
+    this.last = now
+    if this.tokens < 1 {
+        return false
+    }
+    this.tokens--
+    return true
+}
@@ -0,0 +1,81 @@
+package simlog
+
+import (
+    "bytes"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestLabelRateLimitAllow(t *testing.T) {
+    limit := &labelRateLimit{perSecond: 10}
+    now := time.Now()
+    allowed := 0
+    for i := 0; i < 100; i++ {
+        if limit.allow(now) {
+            allowed++
+        }
+    }
+    if allowed != 10 {
+        t.Fatalf("burst allowed %d, want 10", allowed)
+    }
+    if !limit.allow(now.Add(100*time.Millisecond)) || limit.allow(now.Add(100*time.Millisecond)) {
+        t.Fatal("want exactly one token refilled after 100ms")
+    }
+    allowed = 0
+    for i := 0; i < 100; i++ {
+        if limit.allow(now.Add(time.Hour)) {
+            allowed++
+        }
+    }
+    if allowed != 10 {
+        t.Fatalf("allowed %d after a long pause, want 10 (bucket capacity)", allowed)
+    }
+}
+
+// 匹配的子日志共享一条规则，不匹配的和裸日志不受限，采样为0时全部丢弃
+func TestLabelFilter(t *testing.T) {
+    var out bytes.Buffer
+    var logger SimLogger
+    logger.Init(WithLogdir(t.TempDir()), EnableFileOutput(false), WithShadowSink(&out), EnableLineFeed(true),
+        EnableRawLog(true), WithLabelRateLimit("noisy", "true", 5), WithLabelSampling("muted", "true", 0))
+    noisy1 := logger.WithLabels(map[string]string{"noisy": "true"})
+    noisy2 := noisy1.WithLabels(map[string]string{"route": "b"})
+    quiet := logger.WithLabels(map[string]string{"noisy": "false"})
+    muted := logger.WithLabels(map[string]string{"muted": "true"})
+
+    start := time.Now()
+    var wg sync.WaitGroup
+    for _, child := range []*SimLogger{noisy1, noisy2} {
+        wg.Add(1)
+        go func(child *SimLogger) {
+            defer wg.Done()
+            for i := 0; i < 50; i++ {
+                child.Info("noisy")
+            }
+        }(child)
+    }
+    wg.Wait()
+    refilled := int(time.Since(start).Seconds() * 5)
+    for i := 0; i < 20; i++ {
+        quiet.Info("quiet")
+        muted.Info("muted")
+        noisy1.Raw("raw\n")
+    }
+    logger.Close()
+
+    got := out.String()
+    if n := strings.Count(got, "noisy\n"); n < 5 || n > 5+refilled {
+        t.Errorf("got %d noisy lines, want 5 plus at most %d refilled", n, refilled)
+    }
+    if n := strings.Count(got, "quiet\n"); n != 20 {
+        t.Errorf("got %d quiet lines, want 20", n)
+    }
+    if n := strings.Count(got, "raw\n"); n != 20 {
+        t.Errorf("got %d raw lines, want 20", n)
+    }
+    if strings.Contains(got, "muted") {
+        t.Error("lines sampled at rate 0 were written")
+    }
+}
@@ -0,0 +1,43 @@
+package simlog
+
+import (
+    "fmt"
+    "strings"
+)
+
+// String 返回日志级别名，同 GetLogLevelName
+func (this LogLevel) String() string {
+    if this < 0 || int(this) >= len(logLevelNames) {
+        return fmt.Sprintf("LogLevel(%d)", int(this))
+    }
+    return GetLogLevelName(this)
+}
+
+// MarshalText 以级别名序列化，使 JSON 等格式中的日志级别可读
+func (this LogLevel) MarshalText() ([]byte, error) {
+    return []byte(this.String()), nil
+}
+
+// UnmarshalText 从级别名反序列化
+func (this *LogLevel) UnmarshalText(text []byte) error {
+    logLevel, err := ParseLogLevel(string(text))
+    if err != nil {
+        return err
+    }
+    *this = logLevel
+    return nil
+}
+
+// ParseLogLevel 根据级别名（不区分大小写，WARN 同 WARNING）得到对应的日志级别
+func ParseLogLevel(logLevelName string) (LogLevel, error) {
+    name := strings.ToUpper(logLevelName)
+    if name == "WARN" {
+        return LL_WARNING, nil
+    }
+    for logLevel, logLevelName := range logLevelNames {
+        if logLevelName == name {
+            return LogLevel(logLevel), nil
+        }
+    }
+    return LL_INFO, fmt.Errorf("simlog: unknown log level %q", logLevelName)
+}
@@ -0,0 +1,558 @@
+// Code generated by gen_levels.go; DO NOT EDIT.
+
+package simlog
+
+import (
+    "sync/atomic"
+)
+
+// 调用函数 GetLogLevelName，可取得对应级别的字符串值
+const (
+    LL_FATAL   LogLevel = 0
+    LL_ERROR   LogLevel = 1
+    LL_WARNING LogLevel = 2
+    LL_NOTICE  LogLevel = 3
+    LL_INFO    LogLevel = 4 // 默认日志级别
+    LL_DEBUG   LogLevel = 5
+    LL_DETAIL  LogLevel = 6 // 比DEBUG更详细的级别
+    LL_TRACE   LogLevel = 7 // 跟踪日志，独立的日志级别
+    LL_RAW     LogLevel = 8 // 裸日志
+)
+
+// 各日志级别的级别名，下标为日志级别，也是 GetLogLevelName、ParseLogLevel 和 String 的取值范围
+var logLevelNames = [...]string{
+    "FATAL",
+    "ERROR",
+    "WARNING",
+    "NOTICE",
+    "INFO",
+    "DEBUG",
+    "DETAIL",
+    "TRACE",
+    "RAW",
+}
+
+// Logger 写日志的接口，*SimLogger 和 Tee 的返回值均实现了此接口
+type Logger interface {
+    Fatal(a ...interface{}) (int, error)
+    Fatalln(a ...interface{}) (int, error)
+    Fatalf(format string, a ...interface{}) (int, error)
+    Error(a ...interface{}) (int, error)
+    Errorln(a ...interface{}) (int, error)
+    Errorf(format string, a ...interface{}) (int, error)
+    Warning(a ...interface{}) (int, error)
+    Warningln(a ...interface{}) (int, error)
+    Warningf(format string, a ...interface{}) (int, error)
+    Notice(a ...interface{}) (int, error)
+    Noticeln(a ...interface{}) (int, error)
+    Noticef(format string, a ...interface{}) (int, error)
+    Info(a ...interface{}) (int, error)
+    Infoln(a ...interface{}) (int, error)
+    Infof(format string, a ...interface{}) (int, error)
+    Debug(a ...interface{}) (int, error)
+    Debugln(a ...interface{}) (int, error)
+    Debugf(format string, a ...interface{}) (int, error)
+    Detail(a ...interface{}) (int, error)
+    Detailln(a ...interface{}) (int, error)
+    Detailf(format string, a ...interface{}) (int, error)
+    Trace(a ...interface{}) (int, error)
+    Traceln(a ...interface{}) (int, error)
+    Tracef(format string, a ...interface{}) (int, error)
+    Raw(a ...interface{}) (int, error)
+    Rawln(a ...interface{}) (int, error)
+    Rawf(format string, a ...interface{}) (int, error)
+}
+
+var _ Logger = (*SimLogger)(nil)
+
+// 写致命错误日志（Fatal），
+// 注意在调用后进程默认会退出，可通过 WithFatalPolicy 改变。
+
+func (this *SimLogger) IsEnabledFatalLog() bool {
+    this.checkInit()
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_FATAL)
+}
+
+func (this *SimLogger) Fatal(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipFatal(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Fatalln(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipFatalln(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Fatalf(format string, a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipFatalf(this.GetSkip(), format, a...)
+}
+
+// 写致命错误日志（SkipFatal）
+
+func (this *SimLogger) SkipFatal(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledFatalLog() {
+        return 0, nil
+    }
+    return this.skipFatal(skip, false, a)
+}
+
+func (this *SimLogger) SkipFatalln(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledFatalLog() {
+        return 0, nil
+    }
+    return this.skipFatal(skip, true, a)
+}
+
+func (this *SimLogger) SkipFatalf(skip int32, format string, a ...interface{}) (int, error) {
+    if !this.IsEnabledFatalLog() {
+        return 0, nil
+    }
+    return this.skipFatalf(skip, format, a)
+}
+
+func (this *teeLogger) Fatal(a ...interface{}) (int, error) {
+    return this.output(LL_FATAL, false, nil, a)
+}
+
+func (this *teeLogger) Fatalln(a ...interface{}) (int, error) {
+    return this.output(LL_FATAL, true, nil, a)
+}
+
+func (this *teeLogger) Fatalf(format string, a ...interface{}) (int, error) {
+    return this.output(LL_FATAL, false, &format, a)
+}
+
+// 写错误日志（Error）
+
+func (this *SimLogger) IsEnabledErrorLog() bool {
+    this.checkInit()
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_ERROR)
+}
+
+func (this *SimLogger) Error(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipError(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Errorln(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipErrorln(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Errorf(format string, a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipErrorf(this.GetSkip(), format, a...)
+}
+
+// 写错误日志（SkipError）
+
+func (this *SimLogger) SkipError(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledErrorLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_ERROR, skip, false, a)
+}
+
+func (this *SimLogger) SkipErrorln(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledErrorLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_ERROR, skip, true, a)
+}
+
+func (this *SimLogger) SkipErrorf(skip int32, format string, a ...interface{}) (int, error) {
+    if !this.IsEnabledErrorLog() {
+        return 0, nil
+    }
+    return this.skipLogf(LL_ERROR, skip, format, a)
+}
+
+func (this *teeLogger) Error(a ...interface{}) (int, error) {
+    return this.output(LL_ERROR, false, nil, a)
+}
+
+func (this *teeLogger) Errorln(a ...interface{}) (int, error) {
+    return this.output(LL_ERROR, true, nil, a)
+}
+
+func (this *teeLogger) Errorf(format string, a ...interface{}) (int, error) {
+    return this.output(LL_ERROR, false, &format, a)
+}
+
+// 写警示日志（Warning）
+
+func (this *SimLogger) IsEnabledWarningLog() bool {
+    this.checkInit()
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_WARNING)
+}
+
+func (this *SimLogger) Warning(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipWarning(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Warningln(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipWarningln(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Warningf(format string, a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipWarningf(this.GetSkip(), format, a...)
+}
+
+// 写警示日志（SkipWarning）
+
+func (this *SimLogger) SkipWarning(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledWarningLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_WARNING, skip, false, a)
+}
+
+func (this *SimLogger) SkipWarningln(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledWarningLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_WARNING, skip, true, a)
+}
+
+func (this *SimLogger) SkipWarningf(skip int32, format string, a ...interface{}) (int, error) {
+    if !this.IsEnabledWarningLog() {
+        return 0, nil
+    }
+    return this.skipLogf(LL_WARNING, skip, format, a)
+}
+
+func (this *teeLogger) Warning(a ...interface{}) (int, error) {
+    return this.output(LL_WARNING, false, nil, a)
+}
+
+func (this *teeLogger) Warningln(a ...interface{}) (int, error) {
+    return this.output(LL_WARNING, true, nil, a)
+}
+
+func (this *teeLogger) Warningf(format string, a ...interface{}) (int, error) {
+    return this.output(LL_WARNING, false, &format, a)
+}
+
+// 写注意日志（Notice）
+
+func (this *SimLogger) IsEnabledNoticeLog() bool {
+    this.checkInit()
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_NOTICE)
+}
+
+func (this *SimLogger) Notice(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipNotice(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Noticeln(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipNoticeln(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Noticef(format string, a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipNoticef(this.GetSkip(), format, a...)
+}
+
+// 写注意日志（SkipNotice）
+
+func (this *SimLogger) SkipNotice(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledNoticeLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_NOTICE, skip, false, a)
+}
+
+func (this *SimLogger) SkipNoticeln(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledNoticeLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_NOTICE, skip, true, a)
+}
+
+func (this *SimLogger) SkipNoticef(skip int32, format string, a ...interface{}) (int, error) {
+    if !this.IsEnabledNoticeLog() {
+        return 0, nil
+    }
+    return this.skipLogf(LL_NOTICE, skip, format, a)
+}
+
+func (this *teeLogger) Notice(a ...interface{}) (int, error) {
+    return this.output(LL_NOTICE, false, nil, a)
+}
+
+func (this *teeLogger) Noticeln(a ...interface{}) (int, error) {
+    return this.output(LL_NOTICE, true, nil, a)
+}
+
+func (this *teeLogger) Noticef(format string, a ...interface{}) (int, error) {
+    return this.output(LL_NOTICE, false, &format, a)
+}
+
+// 写信息日志（Info）
+
+func (this *SimLogger) IsEnabledInfoLog() bool {
+    this.checkInit()
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_INFO)
+}
+
+func (this *SimLogger) Info(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipInfo(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Infoln(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipInfoln(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Infof(format string, a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipInfof(this.GetSkip(), format, a...)
+}
+
+// 写信息日志（SkipInfo）
+
+func (this *SimLogger) SkipInfo(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledInfoLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_INFO, skip, false, a)
+}
+
+func (this *SimLogger) SkipInfoln(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledInfoLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_INFO, skip, true, a)
+}
+
+func (this *SimLogger) SkipInfof(skip int32, format string, a ...interface{}) (int, error) {
+    if !this.IsEnabledInfoLog() {
+        return 0, nil
+    }
+    return this.skipLogf(LL_INFO, skip, format, a)
+}
+
+func (this *teeLogger) Info(a ...interface{}) (int, error) {
+    return this.output(LL_INFO, false, nil, a)
+}
+
+func (this *teeLogger) Infoln(a ...interface{}) (int, error) {
+    return this.output(LL_INFO, true, nil, a)
+}
+
+func (this *teeLogger) Infof(format string, a ...interface{}) (int, error) {
+    return this.output(LL_INFO, false, &format, a)
+}
+
+// 写调试日志（Debug）
+
+func (this *SimLogger) IsEnabledDebugLog() bool {
+    this.checkInit()
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_DEBUG)
+}
+
+func (this *SimLogger) Debug(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipDebug(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Debugln(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipDebugln(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Debugf(format string, a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipDebugf(this.GetSkip(), format, a...)
+}
+
+// 写调试日志（SkipDebug）
+
+func (this *SimLogger) SkipDebug(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledDebugLog() {
+        if this.debugBuffer != nil {
+            this.skipCapture(skip, false, a)
+        }
+        return 0, nil
+    }
+    return this.skipLog(LL_DEBUG, skip, false, a)
+}
+
+func (this *SimLogger) SkipDebugln(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledDebugLog() {
+        if this.debugBuffer != nil {
+            this.skipCapture(skip, true, a)
+        }
+        return 0, nil
+    }
+    return this.skipLog(LL_DEBUG, skip, true, a)
+}
+
+func (this *SimLogger) SkipDebugf(skip int32, format string, a ...interface{}) (int, error) {
+    if !this.IsEnabledDebugLog() {
+        if this.debugBuffer != nil {
+            this.skipCapturef(skip, format, a)
+        }
+        return 0, nil
+    }
+    return this.skipLogf(LL_DEBUG, skip, format, a)
+}
+
+func (this *teeLogger) Debug(a ...interface{}) (int, error) {
+    return this.output(LL_DEBUG, false, nil, a)
+}
+
+func (this *teeLogger) Debugln(a ...interface{}) (int, error) {
+    return this.output(LL_DEBUG, true, nil, a)
+}
+
+func (this *teeLogger) Debugf(format string, a ...interface{}) (int, error) {
+    return this.output(LL_DEBUG, false, &format, a)
+}
+
+// 写详细日志（Detail）
+
+func (this *SimLogger) IsEnabledDetailLog() bool {
+    this.checkInit()
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_DETAIL)
+}
+
+func (this *SimLogger) Detail(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipDetail(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Detailln(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipDetailln(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Detailf(format string, a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipDetailf(this.GetSkip(), format, a...)
+}
+
+// 写详细日志（SkipDetail）
+
+func (this *SimLogger) SkipDetail(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledDetailLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_DETAIL, skip, false, a)
+}
+
+func (this *SimLogger) SkipDetailln(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledDetailLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_DETAIL, skip, true, a)
+}
+
+func (this *SimLogger) SkipDetailf(skip int32, format string, a ...interface{}) (int, error) {
+    if !this.IsEnabledDetailLog() {
+        return 0, nil
+    }
+    return this.skipLogf(LL_DETAIL, skip, format, a)
+}
+
+func (this *teeLogger) Detail(a ...interface{}) (int, error) {
+    return this.output(LL_DETAIL, false, nil, a)
+}
+
+func (this *teeLogger) Detailln(a ...interface{}) (int, error) {
+    return this.output(LL_DETAIL, true, nil, a)
+}
+
+func (this *teeLogger) Detailf(format string, a ...interface{}) (int, error) {
+    return this.output(LL_DETAIL, false, &format, a)
+}
+
+// 写跟踪日志（Trace）
+
+func (this *SimLogger) IsEnabledTraceLog() bool {
+    this.checkInit()
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.enableTraceLog) == 1
+}
+
+func (this *SimLogger) Trace(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipTrace(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Traceln(a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipTraceln(this.GetSkip(), a...)
+}
+
+func (this *SimLogger) Tracef(format string, a ...interface{}) (int, error) {
+    this.checkInit()
+    return this.SkipTracef(this.GetSkip(), format, a...)
+}
+
+// 写跟踪日志（SkipTrace）
+
+func (this *SimLogger) SkipTrace(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledTraceLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_TRACE, skip, false, a)
+}
+
+func (this *SimLogger) SkipTraceln(skip int32, a ...interface{}) (int, error) {
+    if !this.IsEnabledTraceLog() {
+        return 0, nil
+    }
+    return this.skipLog(LL_TRACE, skip, true, a)
+}
+
+func (this *SimLogger) SkipTracef(skip int32, format string, a ...interface{}) (int, error) {
+    if !this.IsEnabledTraceLog() {
+        return 0, nil
+    }
+    return this.skipLogf(LL_TRACE, skip, format, a)
+}
+
+func (this *teeLogger) Trace(a ...interface{}) (int, error) {
+    return this.output(LL_TRACE, false, nil, a)
+}
+
+func (this *teeLogger) Traceln(a ...interface{}) (int, error) {
+    return this.output(LL_TRACE, true, nil, a)
+}
+
+func (this *teeLogger) Tracef(format string, a ...interface{}) (int, error) {
+    return this.output(LL_TRACE, false, &format, a)
+}
+
+// 写裸日志（Raw）
+
+func (this *SimLogger) Raw(a ...interface{}) (int, error) {
+    return this.log(LL_RAW, Caller{}, a...)
+}
+
+func (this *SimLogger) Rawln(a ...interface{}) (int, error) {
+    return this.logln(LL_RAW, Caller{}, a...)
+}
+
+func (this *SimLogger) Rawf(format string, a ...interface{}) (int, error) {
+    return this.logf(LL_RAW, Caller{}, format, a...)
+}
+
+func (this *teeLogger) Raw(a ...interface{}) (int, error) {
+    return this.output(LL_RAW, false, nil, a)
+}
+
+func (this *teeLogger) Rawln(a ...interface{}) (int, error) {
+    return this.output(LL_RAW, true, nil, a)
+}
+
+func (this *teeLogger) Rawf(format string, a ...interface{}) (int, error) {
+    return this.output(LL_RAW, false, &format, a)
+}
@@ -0,0 +1,26 @@
+package simlog
+
+import (
+    "fmt"
+    "testing"
+)
+
+// 级别名和日志级别互相转换，取值范围随 gen_levels.go 生成的级别名扩展
+func TestLogLevelNames(t *testing.T) {
+    for i := range logLevelNames {
+        logLevel := LogLevel(i)
+        parsed, err := ParseLogLevel(logLevel.String())
+        if err != nil || parsed != logLevel {
+            t.Errorf("ParseLogLevel(%q) = %v, %v, want %d", logLevel.String(), parsed, err, i)
+        }
+    }
+    if GetLogLevelName(LL_RAW) != "RAW" || LL_RAW != LogLevel(len(logLevelNames)-1) {
+        t.Errorf("LL_RAW is not the last generated level")
+    }
+    if s, want := LogLevel(len(logLevelNames)).String(), fmt.Sprintf("LogLevel(%d)", len(logLevelNames)); s != want {
+        t.Errorf("out of range String() = %q, want %q", s, want)
+    }
+    if _, err := ParseLogLevel("verbose"); err == nil {
+        t.Error("ParseLogLevel accepted an unknown name")
+    }
+}
@@ -0,0 +1,90 @@
+package simlog
+
+import (
+    "bytes"
+    "io"
+    "os/exec"
+    "path/filepath"
+    "sync"
+)
+
+// 单行的最大长度，超过时不再等待换行符，直接作为一行输出
+const maxLevelWriterLine = 64 * 1024
+
+// levelWriter 按行拆分并以指定级别写日志的 io.WriteCloser
+type levelWriter struct {
+    logger   *SimLogger
+    logLevel LogLevel
+    prefix   string
+    mutex    sync.Mutex
+    buf      []byte // 尚未遇到换行符的部分
+}
+
+// NewLevelWriter 返回按行拆分、以 logLevel 级别写日志的 io.WriteCloser，每行日志体以 prefix 开头，
+// 不完整的最后一行在 Close 时输出。
+func (this *SimLogger) NewLevelWriter(logLevel LogLevel, prefix string) io.WriteCloser {
+    return &levelWriter{
+        logger:   this,
+        logLevel: logLevel,
+        prefix:   prefix,
+    }
+}
+
+// CommandOutput 将子进程的标准输出和标准错误按行写入日志，
+// 标准输出以 logLevel 级别写，标准错误以 WARNING 级别写（logLevel 比 WARNING 更严重时同 logLevel），
+// 每行日志体以“[命令名:stdout] ”或“[命令名:stderr] ”开头。
+// 应在 cmd.Start 之前调用，返回的 done 应在 cmd.Wait 返回之后调用，以输出不以换行符结尾的最后一行。
+func (this *SimLogger) CommandOutput(cmd *exec.Cmd, logLevel LogLevel) (done func()) {
+    name := filepath.Base(cmd.Path)
+    stderrLevel := LL_WARNING
+    if logLevel < stderrLevel {
+        stderrLevel = logLevel
+    }
+
+    stdout := this.NewLevelWriter(logLevel, "["+name+":stdout] ")
+    stderr := this.NewLevelWriter(stderrLevel, "["+name+":stderr] ")
+    cmd.Stdout = stdout
+    cmd.Stderr = stderr
+    return func() {
+        stdout.Close()
+        stderr.Close()
+    }
+}
+
+func (this *levelWriter) Write(p []byte) (int, error) {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+
+    this.buf = append(this.buf, p...)
+    for {
+        pos := bytes.IndexByte(this.buf, '\n')
+        if pos < 0 {
+            if len(this.buf) >= maxLevelWriterLine {
+                this.writeLine(this.buf)
+                this.buf = this.buf[:0]
+            }
+            break
+        }
+        this.writeLine(this.buf[:pos])
+        this.buf = this.buf[pos+1:]
+    }
+    return len(p), nil
+}
+
+func (this *levelWriter) Close() error {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+
+    if len(this.buf) > 0 {
+        this.writeLine(this.buf)
+        this.buf = nil
+    }
+    return nil
+}
+
+func (this *levelWriter) writeLine(line []byte) {
+    if this.logger.Enabled(this.logLevel) {
+        line = bytes.TrimSuffix(line, []byte{'\r'})
+        this.logger.output(this.logLevel, Caller{}, this.prefix+string(line), true)
+    }
+}
@@ -0,0 +1,56 @@
+package simlog
+
+import (
+    "strconv"
+    "strings"
+    "sync/atomic"
+)
+
+// EnableLevelNumber 是否在日志级别名之后同时输出级别的数值，如“[INFO:4]”，
+// 便于下游查询引擎按数值做阈值过滤（数值越小越严重，如 level<=2 即 WARNING 及以上），
+// 不必维护级别名到严重程度的映射。
+func EnableLevelNumber(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        if enabled {
+            atomic.StoreInt32(&o.levelNumber, 1)
+        } else {
+            atomic.StoreInt32(&o.levelNumber, 0)
+        }
+    })
+}
+
+// 是否开启了输出级别数值
+func (this *SimLogger) EnabledLevelNumber() bool {
+    return atomic.LoadInt32(&this.opts.levelNumber) == 1
+}
+
+// enabled为true表示在日志头中同时输出级别的数值
+func (this *SimLogger) EnableLevelNumber(enabled bool) {
+    this.checkInit()
+    if enabled {
+        atomic.StoreInt32(&this.opts.levelNumber, 1)
+    } else {
+        atomic.StoreInt32(&this.opts.levelNumber, 0)
+    }
+}
+
+// 日志头中的级别
+func (this *SimLogger) formatLevel(logLevel LogLevel) string {
+    if this.EnabledLevelNumber() {
+        return "[" + GetLogLevelName(logLevel) + ":" + strconv.Itoa(int(logLevel)) + "]"
+    }
+    return "[" + GetLogLevelName(logLevel) + "]"
+}
+
+// 解析日志头中的级别（不含方括号），可带级别数值，数值须和级别名一致
+func parseHeaderLevel(token string) (LogLevel, bool) {
+    name, number, hasNumber := strings.Cut(token, ":")
+    logLevel, err := ParseLogLevel(name)
+    if err != nil || logLevel == LL_RAW {
+        return logLevel, false
+    }
+    if hasNumber && number != strconv.Itoa(int(logLevel)) {
+        return logLevel, false
+    }
+    return logLevel, true
+}
@@ -0,0 +1,78 @@
+package simlog
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "sync/atomic"
+    "time"
+)
+
+// 生命周期事件的名字
+const (
+    LifecycleStarted    = "started"     // 日志已 Init
+    LifecycleRotated    = "rotated"     // 本进程滚动了日志文件
+    LifecycleSinkFailed = "sink_failed" // 输出（Sink）写或关闭失败，每秒最多记录一次
+    LifecycleDropped    = "dropped"     // 关闭时统计到有日志被丢弃
+    LifecycleClosed     = "closed"      // 日志已关闭
+)
+
+// 同一种输出失败在此间隔内只记录一次，以免输出持续不可用时刷满生命周期文件
+const lifecycleSinkFailedInterval = time.Second
+
+// LifecycleEvent 生命周期文件中的一行（JSON），见 WithLifecycleFile
+type LifecycleEvent struct {
+    Time    time.Time `json:"time"`
+    Event   string    `json:"event"`
+    Host    string    `json:"host"`
+    PID     int       `json:"pid"`
+    Log     string    `json:"log"`               // 日志文件的路径
+    Size    int64     `json:"size,omitempty"`    // rotated：滚动时日志文件的大小
+    Written int64     `json:"written,omitempty"` // closed：已写入的日志行数
+    Dropped int64     `json:"dropped,omitempty"` // dropped 和 closed：丢弃的日志行数
+    Error   string    `json:"error,omitempty"`   // sink_failed：错误
+}
+
+// WithLifecycleFile 将日志子系统的生命周期事件（Init、滚动、输出失败、丢弃和关闭，见 LifecycleEvent）
+// 以 JSONL 追加到文件 filename（不是绝对路径时在日志目录中），每个事件一行，
+// 以便运维工具在大量主机上以统一的方式审计日志子系统的行为，而不必解析标准错误或日志文件。
+// 每个事件单独打开文件追加写，多个进程可共用同一个文件，文件不滚动，写失败时忽略。
+func WithLifecycleFile(filename string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.lifecycleFile = filename
+    })
+}
+
+// 追加一个生命周期事件，未设置 WithLifecycleFile 时忽略
+func (this *SimLogger) recordLifecycle(event LifecycleEvent) {
+    if this.opts.lifecycleFile == "" {
+        return
+    }
+    if event.Event == LifecycleSinkFailed {
+        now := time.Now().UnixNano()
+        last := atomic.LoadInt64(&this.lastSinkEvent)
+        if now-last < int64(lifecycleSinkFailedInterval) || !atomic.CompareAndSwapInt64(&this.lastSinkEvent, last, now) {
+            return
+        }
+    }
+
+    event.Time = time.Now()
+    event.Host, _ = os.Hostname()
+    event.PID = os.Getpid()
+    event.Log = this.getFilepath()
+    data, err := json.Marshal(event)
+    if err != nil {
+        return
+    }
+
+    path := this.opts.lifecycleFile
+    if !filepath.IsAbs(path) {
+        path = filepath.Join(this.getLogDir(), path)
+    }
+    f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+    if err != nil {
+        return
+    }
+    f.Write(append(data, '\n')) // 一次写完一行，多个进程追加时不会交错
+    f.Close()
+}
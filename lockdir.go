@@ -0,0 +1,51 @@
+package simlog
+
+import (
+    "fmt"
+    "hash/crc32"
+    "path/filepath"
+)
+
+// WithLockDir 设置滚动时的文件锁所在的目录（默认和日志文件在同一目录），
+// 用于日志目录在 NFS 等 flock 不可靠的文件系统上、而本地磁盘可存放文件锁的部署，不存在时 Init 自动创建。
+// 写同一个日志文件的所有进程需设置相同的目录，文件锁的文件名见 LockFilepath。
+func WithLockDir(lockDir string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.lockDir = lockDir
+    })
+}
+
+// EnableRotationLock 滚动时是否加文件锁（默认为 true），
+// 只有一个进程写日志文件时可设为 false，滚动时不再创建和争抢文件锁（也不再记录滚动轮次，见 RotationEpoch）；
+// 多个进程写同一个日志文件时不可关闭。
+func EnableRotationLock(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.noRotationLock = !enabled
+    })
+}
+
+// LockFilepath 返回日志文件 logFilepath 滚动时的文件锁的路径：lockDir 为空时为日志文件名加 .lock，
+// 否则为 lockDir 中的“日志文件名-绝对路径的CRC32.lock”，以区分不同目录中的同名日志文件
+func LockFilepath(logFilepath, lockDir string) string {
+    if lockDir == "" {
+        return logFilepath + ".lock"
+    }
+    absPath, err := filepath.Abs(logFilepath)
+    if err != nil {
+        absPath = filepath.Clean(logFilepath)
+    }
+    return filepath.Join(lockDir, fmt.Sprintf("%s-%08x.lock", filepath.Base(logFilepath), crc32.ChecksumIEEE([]byte(absPath))))
+}
+
+// 滚动 path 时的文件锁的路径
+func (this *SimLogger) lockFilepath(path string) string {
+    return LockFilepath(path, this.opts.lockDir)
+}
+
+// 更新 path 所在目录的清单时的文件锁的路径，不加锁时为空
+func (this *SimLogger) manifestLockFilepath(path string) string {
+    if this.opts.noRotationLock {
+        return ""
+    }
+    return this.lockFilepath(filepath.Join(filepath.Dir(path), ManifestFilename))
+}
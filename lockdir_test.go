@@ -0,0 +1,29 @@
+package simlog
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// SnapshotTo 和滚动用同一个文件锁，WithLockDir 时不在日志目录中创建文件锁
+func TestSnapshotUsesLockDir(t *testing.T) {
+    logDir, lockDir := t.TempDir(), t.TempDir()
+    logger := new(SimLogger)
+    if err := logger.InitE(WithLogdir(logDir), WithFilename("snap.log"), WithLockDir(lockDir), EnableAsyncWrite(false), EnableRegistry(false)); err != nil {
+        t.Fatalf("InitE: %s", err.Error())
+    }
+    defer logger.Close()
+    logger.Info("before snapshot")
+
+    if err := logger.SnapshotTo(filepath.Join(t.TempDir(), "snap.copy"), false); err != nil {
+        t.Fatalf("SnapshotTo: %s", err.Error())
+    }
+    path := filepath.Join(logDir, "snap.log")
+    if _, err := os.Stat(path + ".lock"); err == nil {
+        t.Errorf("%s.lock created in the log directory", path)
+    }
+    if _, err := os.Stat(LockFilepath(path, lockDir)); err != nil {
+        t.Errorf("lock file not in the lock directory: %s", err.Error())
+    }
+}
@@ -0,0 +1,70 @@
+package simlog
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sync/atomic"
+    "time"
+)
+
+// WithLogDirCandidates 设置按顺序查找的候选日志目录，Init 时使用第一个存在的目录，
+// 都不存在时使用 WithLogdir 指定的（未指定时为 GetLogDir 自动取得的）目录，
+// 以便同一个程序在不同的部署布局下（如“/var/log/app”、“../log”）自动找到日志目录。
+func WithLogDirCandidates(dirs ...string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.logDirCandidates = append([]string(nil), dirs...)
+    })
+}
+
+// WithLogDirReresolve 设置重新查找日志目录的间隔（默认为0，表示只在 Init 时查找一次），
+// 开启后写日志时每隔 interval 重新查找一次，更靠前的候选目录（见 WithLogDirCandidates）出现时自动改写到该目录，
+// 以免程序启动后才创建的日志目录要等重启才生效。未设置候选目录且未用 WithLogdir 指定日志目录时，
+// 候选目录为程序所在目录的“../log”（同 GetLogDir）。已在写的日志文件不移动，滚动出的备份留在原目录。
+func WithLogDirReresolve(interval time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.logDirReresolve = interval
+    })
+}
+
+// Init 时确定日志目录
+func (this *SimLogger) initLogDir() {
+    if this.opts.logDirReresolve > 0 && len(this.opts.logDirCandidates) == 0 && this.opts.logDir == GetLogDir() {
+        this.opts.logDirCandidates = []string{fmt.Sprintf("%s/../log", filepath.Dir(os.Args[0]))}
+    }
+    this.resolvedLogDir.Store(this.resolveLogDir())
+    atomic.StoreInt64(&this.nextLogDirResolve, time.Now().Add(this.opts.logDirReresolve).UnixNano())
+}
+
+// 第一个存在的候选目录，都不存在时为 WithLogdir 指定的目录
+func (this *SimLogger) resolveLogDir() string {
+    for _, dir := range this.opts.logDirCandidates {
+        if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+            return dir
+        }
+    }
+    return this.opts.logDir
+}
+
+// 取得当前的日志目录，到了重新查找的时间时重新查找
+func (this *SimLogger) getLogDir() string {
+    logDir, ok := this.resolvedLogDir.Load().(string)
+    if !ok {
+        return this.opts.logDir // 未 Init
+    }
+    if this.opts.logDirReresolve <= 0 {
+        return logDir
+    }
+
+    now := time.Now().UnixNano()
+    next := atomic.LoadInt64(&this.nextLogDirResolve)
+    if now < next || !atomic.CompareAndSwapInt64(&this.nextLogDirResolve, next, now+int64(this.opts.logDirReresolve)) {
+        return logDir
+    }
+    if newLogDir := this.resolveLogDir(); newLogDir != logDir {
+        this.resolvedLogDir.Store(newLogDir)
+        fmt.Fprintf(os.Stderr, "simlog: log dir of %s changed from %s to %s\n", this.opts.logFilename, logDir, newLogDir)
+        return newLogDir
+    }
+    return logDir
+}
@@ -0,0 +1,151 @@
+package simlog
+
+import (
+    "bufio"
+    "os"
+    "time"
+)
+
+// logFile 日志文件，可带写缓冲
+type logFile struct {
+    file   *os.File
+    path   string        // 打开时的文件路径
+    writer *bufio.Writer // 写缓冲，为nil表示不缓冲
+}
+
+// WithWriteBufferSize 设置写日志文件的缓冲大小（字节数），小于等于0表示不缓冲（默认，以保证持久性），
+// 异步写时在一波日志写完（日志队列为空）时写出缓冲，同步写时按 WithFlushInterval 设置的间隔定时写出缓冲，
+// 以减少小块写的系统调用次数，关闭日志时总是会写出缓冲。
+func WithWriteBufferSize(writeBufferSize int32) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.writeBufferSize = writeBufferSize
+    })
+}
+
+// WithFlushInterval 设置同步写且有写缓冲时定时写出缓冲的间隔（默认为1秒）
+func WithFlushInterval(flushInterval time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        if flushInterval > 0 {
+            o.flushInterval = flushInterval
+        }
+    })
+}
+
+func newLogFile(f *os.File, path string, writeBufferSize int) *logFile {
+    file := &logFile{file: f, path: path}
+    if writeBufferSize > 0 {
+        file.writer = bufio.NewWriterSize(f, writeBufferSize)
+    }
+    return file
+}
+
+func (this *logFile) WriteString(s string) (int, error) {
+    if this.writer != nil {
+        return this.writer.WriteString(s)
+    }
+    return this.file.WriteString(s)
+}
+
+// 写出缓冲
+func (this *logFile) Flush() error {
+    if this.writer != nil {
+        return this.writer.Flush()
+    }
+    return nil
+}
+
+// 取得文件大小（包括尚在缓冲中的）
+func (this *logFile) Size() (int64, error) {
+    fi, err := this.file.Stat()
+    if err != nil {
+        return 0, err
+    }
+    if this.writer != nil {
+        return fi.Size() + int64(this.writer.Buffered()), nil
+    }
+    return fi.Size(), nil
+}
+
+// 是否仍为 filepath 指向的文件（可能已被其它进程滚动改名）
+func (this *logFile) IsFile(filepath string) bool {
+    fi, err := this.file.Stat()
+    if err != nil {
+        return false
+    }
+    pfi, err := os.Stat(filepath)
+    return err == nil && os.SameFile(fi, pfi)
+}
+
+// 写出缓冲后关闭
+func (this *logFile) Close() error {
+    err := this.Flush()
+    if e := this.file.Close(); err == nil {
+        err = e
+    }
+    return err
+}
+
+// 同步写且有写缓冲时写日志，日志文件在多次写之间保持打开
+func (this *SimLogger) writeLogBuffered(logLine string) (int, error) {
+    this.syncMutex.Lock()
+    defer this.syncMutex.Unlock()
+
+    if this.syncFile != nil && this.pathChanged(this.syncFile) {
+        this.syncFile.Close() // 跨天了或日志目录变了，写到新的目录
+        this.syncFile = nil
+    }
+    if this.syncFile == nil {
+        file, err := this.openLogFile()
+        if err != nil {
+            return 0, err
+        }
+        this.syncFile = file
+    }
+    n, err, rotated := this.writeLog(this.syncFile, logLine)
+    if rotated || isReadOnlyError(err) {
+        this.syncFile.Close()
+        this.syncFile = nil // 下次写时重新打开
+    }
+    return n, err
+}
+
+// 启动定时写出缓冲的协程
+func (this *SimLogger) startBufferFlusher() {
+    this.flusherExit = make(chan struct{})
+    this.flusherDone = make(chan struct{})
+    go func() {
+        ticker := time.NewTicker(this.opts.flushInterval)
+        defer ticker.Stop()
+        defer close(this.flusherDone)
+
+        for {
+            select {
+            case <-ticker.C:
+                this.flushBuffered(false)
+            case <-this.flusherExit:
+                this.flushBuffered(true)
+                return
+            }
+        }
+    }()
+}
+
+// 停止定时写出缓冲的协程，并写出缓冲、关闭日志文件
+func (this *SimLogger) stopBufferFlusher() {
+    close(this.flusherExit)
+    <-this.flusherDone
+}
+
+// 写出缓冲，如果日志文件已被其它进程滚动或 closing 为 true 则关闭日志文件
+func (this *SimLogger) flushBuffered(closing bool) {
+    this.syncMutex.Lock()
+    defer this.syncMutex.Unlock()
+
+    if this.syncFile != nil {
+        this.syncFile.Flush()
+        if closing || !this.syncFile.IsFile(this.getFilepath()) {
+            this.syncFile.Close()
+            this.syncFile = nil
+        }
+    }
+}
@@ -0,0 +1,199 @@
+package simlog
+
+import (
+    "bufio"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+import (
+    "github.com/gofrs/flock"
+)
+
+// ManifestFilename 清单的文件名，清单在日志文件所在的目录中
+const ManifestFilename = "manifest.json"
+
+// WithManifest 是否在日志目录中维护滚动出的备份文件的清单 manifest.json（默认为 false），
+// 记录各备份文件的大小、首末日志的时间、条数和 SHA-256，滚动时（持有文件锁）更新，
+// 以便日志收集程序和审计者据此（见 VerifyManifest）发现丢失或损坏的归档。
+// 同一目录下的多个日志文件共用一个清单，需在滚动时读一遍被滚动的文件。
+func WithManifest(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.manifest = enabled
+    })
+}
+
+// Manifest 日志目录中备份文件的清单
+type Manifest struct {
+    Updated time.Time      `json:"updated"` // 最后更新时间
+    Files   []ManifestFile `json:"files"`   // 各备份文件，按文件名排列
+}
+
+// ManifestFile 清单中的一个备份文件
+type ManifestFile struct {
+    Name    string    `json:"name"`    // 文件名（不含目录），如“xxx.log.1”
+    Size    int64     `json:"size"`    // 大小（字节）
+    First   time.Time `json:"first"`   // 第一条日志的时间，没有带时间的日志时为零值
+    Last    time.Time `json:"last"`    // 最后一条日志的时间
+    Lines   int64     `json:"lines"`   // 带时间的日志条数
+    SHA256  string    `json:"sha256"`  // 内容的 SHA-256（十六进制）
+    Rotated time.Time `json:"rotated"` // 滚动出的时间
+}
+
+// ManifestProblem VerifyManifest 发现的一个问题
+type ManifestProblem struct {
+    Name    string // 文件名
+    Problem string // 问题，如“missing”、“size mismatch”、“checksum mismatch”
+}
+
+// ReadManifest 读取日志目录 dir 中的清单
+func ReadManifest(dir string) (*Manifest, error) {
+    data, err := os.ReadFile(filepath.Join(dir, ManifestFilename))
+    if err != nil {
+        return nil, err
+    }
+    var manifest Manifest
+    if err = json.Unmarshal(data, &manifest); err != nil {
+        return nil, err
+    }
+    return &manifest, nil
+}
+
+// VerifyManifest 按清单校验日志目录 dir 中的备份文件，返回丢失、大小或内容不符的，
+// 滚动后又被追加了日志（滚动时其它进程仍在写）的文件只校验清单记录的大小内的内容。
+func VerifyManifest(dir string) ([]ManifestProblem, error) {
+    manifest, err := ReadManifest(dir)
+    if err != nil {
+        return nil, err
+    }
+
+    var problems []ManifestProblem
+    for _, file := range manifest.Files {
+        path := filepath.Join(dir, file.Name)
+        fi, err := os.Stat(path)
+        if err != nil {
+            problems = append(problems, ManifestProblem{Name: file.Name, Problem: "missing"})
+            continue
+        }
+        if fi.Size() < file.Size {
+            problems = append(problems, ManifestProblem{Name: file.Name, Problem: "size mismatch"})
+            continue
+        }
+        if sum, err := fileSHA256(path, file.Size); err != nil {
+            problems = append(problems, ManifestProblem{Name: file.Name, Problem: err.Error()})
+        } else if sum != file.SHA256 {
+            problems = append(problems, ManifestProblem{Name: file.Name, Problem: "checksum mismatch"})
+        }
+    }
+    return problems, nil
+}
+
+// 文件前 size 个字节的 SHA-256
+func fileSHA256(path string, size int64) (string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return "", err
+    }
+    defer f.Close()
+
+    hash := sha256.New()
+    if _, err = io.CopyN(hash, f, size); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// 读一遍将要滚动的日志文件，取得其清单信息（Name 待滚动后设置）
+func describeArchive(path string) (*ManifestFile, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    file := &ManifestFile{Rotated: time.Now()}
+    hash := sha256.New()
+    reader := bufio.NewReader(io.TeeReader(f, hash))
+    for {
+        line, err := reader.ReadString('\n')
+        file.Size += int64(len(line))
+        if logTime, ok := lineTime(line); ok {
+            if file.Lines == 0 {
+                file.First = logTime
+            }
+            file.Last = logTime
+            file.Lines++
+        }
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+    }
+    file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+    return file, nil
+}
+
+// 滚动后更新清单：cur_filepath 的各备份文件的序号加1，超出备份数的移除，archived 为新的“.1”（压缩时加 backupExt），
+// lockFilepath 为清单的文件锁，为空表示不加锁
+func updateManifest(cur_filepath string, backupExt string, archived *ManifestFile, logNumBackups int32, lockFilepath string) error {
+    dir := filepath.Dir(cur_filepath)
+    base := filepath.Base(cur_filepath)
+    manifestPath := filepath.Join(dir, ManifestFilename)
+
+    // 同一目录下的其它日志文件可能同时在滚动
+    if lockFilepath != "" {
+        fileLock := flock.New(lockFilepath)
+        if err := fileLock.Lock(); err != nil {
+            return err
+        }
+        defer fileLock.Unlock()
+    }
+
+    manifest, err := ReadManifest(dir)
+    if err != nil {
+        manifest = new(Manifest)
+    }
+    files := manifest.Files[:0]
+    for _, file := range manifest.Files {
+        if suffix, ok := strings.CutPrefix(file.Name, base+"."); ok {
+            number, ext, compressed := strings.Cut(suffix, ".") // 压缩的备份为“序号.编解码器名”
+            n, e := strconv.Atoi(number)
+            if e == nil {
+                if n+1 >= int(logNumBackups) {
+                    continue // 已被覆盖
+                }
+                file.Name = fmt.Sprintf("%s.%d", base, n+1)
+                if compressed {
+                    file.Name += "." + ext
+                }
+            }
+        }
+        files = append(files, file)
+    }
+    archived.Name = base + ".1" + backupExt
+    manifest.Files = append(files, *archived)
+    sort.Slice(manifest.Files, func(i, j int) bool {
+        return manifest.Files[i].Name < manifest.Files[j].Name
+    })
+    manifest.Updated = time.Now()
+
+    data, err := json.MarshalIndent(manifest, "", "  ")
+    if err != nil {
+        return err
+    }
+    tmpPath := manifestPath + ".tmp"
+    if err = os.WriteFile(tmpPath, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmpPath, manifestPath)
+}
@@ -0,0 +1,77 @@
+package simlog
+
+import (
+    "strings"
+    "sync"
+)
+
+// MemorySink 把日志记录在内存中的结构化观察者，供单元测试断言写了哪些日志，见 NewTestLogger。
+// 可并发使用，零值即可用。
+type MemorySink struct {
+    mutex   sync.Mutex
+    entries []Entry
+}
+
+// NewMemorySink 创建内存中的日志记录
+func NewMemorySink() *MemorySink {
+    return new(MemorySink)
+}
+
+// WithMemorySink 将日志同步地记录到 sink（同 WithEntryObserver(sink.Observe, ...)）
+func WithMemorySink(sink *MemorySink) LogOption {
+    return WithEntryObserver(sink.Observe, ObserverConfig{Name: "memory", Mode: DeliverSync})
+}
+
+// Observe 记录一条日志，为 EntryObserver
+func (this *MemorySink) Observe(entry Entry) error {
+    this.mutex.Lock()
+    this.entries = append(this.entries, entry)
+    this.mutex.Unlock()
+    return nil
+}
+
+// Entries 返回已记录的所有日志（副本），按写日志的先后排列
+func (this *MemorySink) Entries() []Entry {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    return append([]Entry(nil), this.entries...)
+}
+
+// FilterLevel 返回已记录的级别为 logLevel 的日志
+func (this *MemorySink) FilterLevel(logLevel LogLevel) []Entry {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    var entries []Entry
+    for _, entry := range this.entries {
+        if entry.Level == logLevel {
+            entries = append(entries, entry)
+        }
+    }
+    return entries
+}
+
+// Contains 是否记录了日志体中含有 substr 的日志
+func (this *MemorySink) Contains(substr string) bool {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    for _, entry := range this.entries {
+        if strings.Contains(entry.Body, substr) {
+            return true
+        }
+    }
+    return false
+}
+
+// Len 返回已记录的日志条数
+func (this *MemorySink) Len() int {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    return len(this.entries)
+}
+
+// Reset 清空已记录的日志
+func (this *MemorySink) Reset() {
+    this.mutex.Lock()
+    this.entries = nil
+    this.mutex.Unlock()
+}
@@ -0,0 +1,89 @@
+package simlog
+
+import (
+    "math"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// WithMetricsFile 将 Metric 写的指标行写到日志目录中单独的指标文件 filename（而不是日志文件），
+// 指标文件和日志文件的大小、备份数、异步写和滚动锁等设置相同，随日志一起关闭。
+func WithMetricsFile(filename string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.metricsFile = filename
+    })
+}
+
+// Metric 写一行指标，格式为 InfluxDB 的行协议（line protocol）：
+//
+//	name,tag1=v1,tag2=v2 value=1.5 1697446106852856000
+//
+// 标签按名字排序，时间戳为 Unix 纳秒，名字中的逗号和空格、标签中的逗号、空格和等号按行协议转义，
+// 以便小型部署直接由日志文件得到指标和看板（如由 Telegraf 的 tail 输入读取），而无需运行指标系统。
+// 指标行不受日志级别限制，不带日志头，设置了 WithMetricsFile 时写到指标文件，否则写到日志文件；
+// name 为空或 value 为 NaN、无穷大（行协议不支持）时不写。
+func (this *SimLogger) Metric(name string, value float64, tags map[string]string) (int, error) {
+    if name == "" || math.IsNaN(value) || math.IsInf(value, 0) {
+        return 0, nil
+    }
+    line := formatMetric(name, value, tags, time.Now())
+    if this.metrics != nil {
+        return this.metrics.putLog(LL_RAW, line)
+    }
+    return this.putLog(LL_RAW, line)
+}
+
+// 创建写指标文件的日志
+func (this *SimLogger) openMetrics() error {
+    metrics := new(SimLogger)
+    err := metrics.InitE(
+        EnableRegistry(false),
+        WithLogdir(this.getLogDir()),
+        WithFilename(this.opts.metricsFile),
+        WithFilesize(this.opts.logFileSize),
+        WithBackupNumber(this.opts.logNumBackups),
+        EnableAsyncWrite(this.opts.asyncWrite),
+        WithLockDir(this.opts.lockDir),
+        EnableRotationLock(!this.opts.noRotationLock),
+        WithErrorHandler(this.opts.errorHandler),
+    )
+    if err != nil {
+        return err
+    }
+    this.metrics = metrics
+    return nil
+}
+
+// 按行协议格式化一行指标
+func formatMetric(name string, value float64, tags map[string]string, now time.Time) string {
+    var b strings.Builder
+    b.WriteString(metricNameEscaper.Replace(name))
+    keys := make([]string, 0, len(tags))
+    for key := range tags {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        if key == "" || tags[key] == "" {
+            continue // 行协议不允许空的标签名或值
+        }
+        b.WriteByte(',')
+        b.WriteString(metricEscaper.Replace(key))
+        b.WriteByte('=')
+        b.WriteString(metricEscaper.Replace(tags[key]))
+    }
+    b.WriteString(" value=")
+    b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+    b.WriteByte(' ')
+    b.WriteString(strconv.FormatInt(now.UnixNano(), 10))
+    b.WriteByte('\n')
+    return b.String()
+}
+
+// 行协议中名字和标签（名和值）的转义，换行替换为空格以保持一行
+var (
+    metricNameEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\ `, "\r", "")
+    metricEscaper     = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`, "\n", `\ `, "\r", "")
+)
@@ -0,0 +1,76 @@
+package simlog
+
+import (
+    "regexp"
+    "strings"
+)
+
+// MessageKeyFunc 由日志级别和日志体得到逻辑消息的键，
+// 同一逻辑消息的日志体常因 ID 等取值不同而各不相同，摘要等按键而不是日志体分组。
+type MessageKeyFunc func(logLevel LogLevel, logBody string) string
+
+// 注册的消息模板
+type messageTemplateMatcher struct {
+    template string
+    re       *regexp.Regexp
+}
+
+// 匹配格式串中的动词（如 %s、%-8d、%.2f、%v），%% 单独处理
+var formatVerbRegexp = regexp.MustCompile(`%[-+# 0]*[0-9*]*(\.[0-9*]*)?[a-zA-Z]`)
+
+// WithMessageTemplates 注册消息模板，模板为写日志时使用的格式串（如“connect to %s failed”），
+// 日志体匹配某个模板（按注册的顺序）时，以该模板作为消息的键，
+// 不匹配任何模板时使用 WithMessageKeyFunc 设置的函数，未设置时使用默认规则（数字替换为“#”）。
+func WithMessageTemplates(templates ...string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        for _, template := range templates {
+            o.messageTemplates = append(o.messageTemplates, messageTemplateMatcher{
+                template: template,
+                re:       compileMessageTemplate(template),
+            })
+        }
+    })
+}
+
+// WithMessageKeyFunc 设置从日志体提取消息键的函数，比如取日志体中第一个冒号之前的部分，
+// 优先级低于 WithMessageTemplates 注册的模板。
+func WithMessageKeyFunc(messageKeyFunc MessageKeyFunc) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.messageKeyFunc = messageKeyFunc
+    })
+}
+
+// MessageKey 返回日志体所属逻辑消息的键，摘要（WithDigest）按此分组，
+// 供限流、去重等需要按逻辑消息分组的功能使用，以便各功能的分组一致。
+func (this *SimLogger) MessageKey(logLevel LogLevel, logBody string) string {
+    logBody = strings.TrimSpace(logBody)
+    for _, matcher := range this.opts.messageTemplates {
+        if matcher.re.MatchString(logBody) {
+            return matcher.template
+        }
+    }
+    if this.opts.messageKeyFunc != nil {
+        return this.opts.messageKeyFunc(logLevel, logBody)
+    }
+    return messageTemplate(logBody)
+}
+
+// 将格式串编译为正则表达式：动词匹配任意内容，其它部分按字面匹配
+func compileMessageTemplate(template string) *regexp.Regexp {
+    var sb strings.Builder
+    sb.WriteString(`(?s)^`)
+    for _, literal := range strings.Split(strings.TrimSpace(template), "%%") {
+        if sb.Len() > len(`(?s)^`) {
+            sb.WriteString("%")
+        }
+        last := 0
+        for _, loc := range formatVerbRegexp.FindAllStringIndex(literal, -1) {
+            sb.WriteString(regexp.QuoteMeta(literal[last:loc[0]]))
+            sb.WriteString(`.*?`)
+            last = loc[1]
+        }
+        sb.WriteString(regexp.QuoteMeta(literal[last:]))
+    }
+    sb.WriteString(`$`)
+    return regexp.MustCompile(sb.String())
+}
@@ -0,0 +1,279 @@
+package simlog
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "net"
+    "os"
+    "strings"
+    "sync/atomic"
+    "syscall"
+    "time"
+)
+
+// 网络输出的默认队列大小、重连的最小和最大退避，以及关闭时等待发完的时长
+const (
+    defaultNetworkQueueSize = 10000
+    networkMinBackoff       = 100 * time.Millisecond
+    networkMaxBackoff       = 10 * time.Second
+    networkDialTimeout      = 5 * time.Second
+    networkCloseTimeout     = 5 * time.Second
+)
+
+// 写到网络的输出，见 NewNetworkSink
+type networkSink struct {
+    dropped   int64 // 放在最前面，以保证在32位平台上原子操作时的8字节对齐
+    deadline  int64 // 关闭时放弃发送的时间（纳秒），未关闭时为0
+    network   string
+    addr      string
+    formatter *SimLogger                            // 按其选项格式化日志行
+    encode    func(entry *Entry) string             // 编码一条日志，为nil时为 formatter 格式化的日志行
+    write     func(conn net.Conn, msg string) error // 发送一条编码后的日志，为nil时直接写
+    queue     *ringQueue[string]
+    spool     *spoolWriter // 发送失败时的落盘，见 WithSpoolDir
+    conn      net.Conn     // 只在发送时访问，发送协程和重发协程不会同时发送
+    failed    bool         // 上次发送是否失败，只在发送时访问
+    closing   int32
+    done      chan struct{} // 发送协程已退出
+}
+
+// WithNetworkSink 将格式化后的日志行经 TCP 或 UDP 发送到远程的收集程序，同 WithSink(NewNetworkSink(network, addr, opts...))，
+// opts 无效时 Init 失败。
+func WithNetworkSink(network, addr string, opts ...LogOption) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        sink, err := NewNetworkSink(network, addr, opts...)
+        if err != nil {
+            o.optionErr = err
+            return
+        }
+        o.sinks = append(o.sinks, sink)
+    })
+}
+
+// NewNetworkSink 创建经 network（“tcp”或“udp”等）发送到 addr 的输出，日志行按 opts 格式化（如 WithFormat），每条一行，
+// UDP 时每条一个数据报（超过数据报的大小上限的被丢弃）。日志先放入输出的队列（WithLogQueueSize 设置其大小，默认为10000），由发送协程发送，
+// 队列满时丢弃并返回 ErrQueueFull（计入 LogStats.SinkErrors），不阻塞写日志，关闭后返回 ErrClosed。
+// 连接在后台建立，断开或发送失败时按指数退避（100毫秒到10秒）重连，发送失败的那条在重连后重发，
+// 断开时以 ErrSinkUnavailable 通知 opts 中的 WithErrorHandler（未设置时输出到标准错误）。
+// 关闭时最多等待5秒（包括连接和重连的时间）发完队列中的日志。
+// opts 中有 WithSpoolDir 时，发送失败的日志落盘，连接恢复后按先后重发，关闭时未发出的留待下次运行时重发，不丢弃。
+func NewNetworkSink(network, addr string, opts ...LogOption) (Sink, error) {
+    sink, err := newNetworkSink(network, addr, opts, nil, nil)
+    if err != nil {
+        return nil, err
+    }
+    return sink, nil
+}
+
+// 创建网络输出，encode 和 write 为 nil 时按日志行发送
+func newNetworkSink(network, addr string, opts []LogOption, encode func(*Entry) string, write func(net.Conn, string) error) (*networkSink, error) {
+    formatter := new(SimLogger)
+    err := formatter.InitE(append([]LogOption{
+        EnableRegistry(false),
+        EnableFileOutput(false),
+        WithLogQueueSize(defaultNetworkQueueSize),
+    }, opts...)...)
+    if err != nil {
+        return nil, err
+    }
+
+    if encode == nil && write == nil && formatter.opts.networkCodec != nil {
+        write = codecWriter(network, formatter.opts.networkCodec)
+    }
+    sink := &networkSink{
+        network:   network,
+        addr:      addr,
+        formatter: formatter,
+        encode:    encode,
+        write:     write,
+        queue:     newRingQueue[string](int(formatter.opts.logQueueSize)),
+        done:      make(chan struct{}),
+    }
+    if dir := formatter.opts.spoolDir; dir != "" {
+        sink.spool, err = newSpoolWriter(networkSinkWriter{sink}, dir, formatter.opts.spoolMaxAge, formatter.opts.spoolMaxSize)
+        if err != nil {
+            formatter.Close()
+            return nil, err
+        }
+    }
+    go sink.run()
+    return sink, nil
+}
+
+func (this *networkSink) Write(entry Entry) error {
+    if atomic.LoadInt32(&this.closing) == 1 {
+        return ErrClosed
+    }
+    var logLine string
+    if this.encode != nil {
+        logLine = this.encode(&entry)
+    } else {
+        logLine, _ = this.formatter.buildLogLine(&entry, true)
+    }
+    if !this.queue.offer(logLine) {
+        atomic.AddInt64(&this.dropped, 1)
+        return ErrQueueFull
+    }
+    return nil
+}
+
+// Close 关闭队列，等待发送协程发完（最多5秒，包括连接和重连的时间）后关闭连接，
+// 返回因队列满、编码失败或关闭时未发完而丢弃的条数的错误
+func (this *networkSink) Close() error {
+    if !atomic.CompareAndSwapInt32(&this.closing, 0, 1) {
+        return nil
+    }
+    atomic.StoreInt64(&this.deadline, time.Now().Add(networkCloseTimeout).UnixNano())
+    this.queue.close()
+    <-this.done
+    if this.spool != nil {
+        this.spool.close()
+    }
+    if this.conn != nil {
+        this.conn.Close()
+    }
+    this.formatter.Close()
+    if dropped := atomic.LoadInt64(&this.dropped); dropped > 0 {
+        return fmt.Errorf("%w: %d log lines to %s://%s dropped", ErrQueueFull, dropped, this.network, this.addr)
+    }
+    return nil
+}
+
+// 发送协程：取出日志行发送，失败时重连，或有落盘时交给落盘
+func (this *networkSink) run() {
+    defer close(this.done)
+
+    backoff := networkMinBackoff
+    for {
+        logLine, ok := this.queue.pop()
+        if !ok {
+            return
+        }
+        if this.spool != nil {
+            if _, err := this.spool.Write([]byte(logLine)); err != nil {
+                atomic.AddInt64(&this.dropped, 1)
+                this.formatter.reportError(fmt.Errorf("%w: spool log line to %s://%s: %w", ErrSinkUnavailable, this.network, this.addr, err))
+            }
+            continue
+        }
+        for {
+            if remaining, closing := this.remaining(); closing && remaining <= 0 {
+                atomic.AddInt64(&this.dropped, int64(1+this.queue.len())) // 关闭时未发完的均放弃
+                return
+            }
+            if this.send(logLine) == nil {
+                break
+            }
+            sleep := backoff
+            if remaining, closing := this.remaining(); closing && remaining < sleep {
+                sleep = remaining
+            }
+            time.Sleep(sleep)
+            if backoff *= 2; backoff > networkMaxBackoff {
+                backoff = networkMaxBackoff
+            }
+        }
+        backoff = networkMinBackoff
+    }
+}
+
+// 关闭时距放弃发送的剩余时长，第2个返回值为 false 表示未关闭
+func (this *networkSink) remaining() (time.Duration, bool) {
+    deadline := atomic.LoadInt64(&this.deadline)
+    if deadline == 0 {
+        return 0, false
+    }
+    return time.Until(time.Unix(0, deadline)), true
+}
+
+// WithNetworkCodec 网络输出（NewNetworkSink 和 NewUnixSocketSink 的 opts）以名为 name 的编解码器（见 RegisterCodec）
+// 编码每条日志行后发送：数据报时每条一个数据报，流式连接时每条前加4字节（大端）的长度。编解码器未注册时创建输出失败。
+func WithNetworkCodec(name string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        codec, err := lookupCodec(name)
+        if err != nil {
+            o.optionErr = err
+            return
+        }
+        o.networkCodec = codec
+    })
+}
+
+// 编码失败，重发也不会成功，该条被丢弃
+var errUnencodable = errors.New("simlog: encode log line failed")
+
+// 以编解码器编码后发送的 write
+func codecWriter(network string, codec Codec) func(net.Conn, string) error {
+    datagram := strings.HasPrefix(network, "udp") || network == "unixgram"
+    return func(conn net.Conn, logLine string) error {
+        data, err := codec.Encode([]byte(logLine))
+        if err != nil {
+            return fmt.Errorf("%w: %w", errUnencodable, err)
+        }
+        if !datagram {
+            data = append(binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(data)), uint32(len(data))), data...)
+        }
+        _, err = conn.Write(data)
+        return err
+    }
+}
+
+// 发送一条日志行，未连接时先连接，失败时断开连接，关闭时连接和发送的超时不超过剩余时长；
+// 首次失败时通知错误处理函数，恢复时输出到标准错误
+func (this *networkSink) send(logLine string) error {
+    err := this.sendLine(logLine)
+    if err != nil && !this.failed {
+        this.formatter.reportError(fmt.Errorf("%w: send to %s://%s: %w, reconnecting", ErrSinkUnavailable, this.network, this.addr, err))
+    } else if err == nil && this.failed {
+        fmt.Fprintf(os.Stderr, "simlog: reconnected to %s://%s\n", this.network, this.addr)
+    }
+    this.failed = err != nil
+    return err
+}
+
+func (this *networkSink) sendLine(logLine string) error {
+    timeout := networkDialTimeout
+    if remaining, closing := this.remaining(); closing && remaining < timeout {
+        if remaining <= 0 {
+            return os.ErrDeadlineExceeded
+        }
+        timeout = remaining
+    }
+    if this.conn == nil {
+        conn, err := net.DialTimeout(this.network, this.addr, timeout)
+        if err != nil {
+            return err
+        }
+        this.conn = conn
+    }
+    this.conn.SetWriteDeadline(time.Now().Add(timeout))
+    var err error
+    if this.write != nil {
+        err = this.write(this.conn, logLine)
+    } else {
+        _, err = this.conn.Write([]byte(logLine))
+    }
+    if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, errUnencodable) {
+        atomic.AddInt64(&this.dropped, 1) // 超过数据报的大小上限或编码失败，重发也不会成功，连接仍可用
+        return nil
+    }
+    if err != nil {
+        this.conn.Close()
+        this.conn = nil
+        return err
+    }
+    return nil
+}
+
+// 交给落盘的发送，每次 Write 发送一条日志行
+type networkSinkWriter struct {
+    sink *networkSink
+}
+
+func (this networkSinkWriter) Write(p []byte) (int, error) {
+    if err := this.sink.send(string(p)); err != nil {
+        return 0, err
+    }
+    return len(p), nil
+}
@@ -0,0 +1,149 @@
+package simlog
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// 接受连接的 TCP 收集程序，drain 为 false 时不读连接（模拟慢但正常的对端）
+func listenCollector(t *testing.T, drain bool) net.Listener {
+    t.Helper()
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("listen: %s", err.Error())
+    }
+    t.Cleanup(func() { ln.Close() })
+    go func() {
+        for {
+            conn, err := ln.Accept()
+            if err != nil {
+                return
+            }
+            t.Cleanup(func() { conn.Close() })
+            if drain {
+                go io.Copy(io.Discard, conn)
+            }
+        }
+    }()
+    return ln
+}
+
+// 对端不读时 Close 在关闭超时内返回，未发完的计为丢弃
+func TestNetworkSinkCloseDeadline(t *testing.T) {
+    if testing.Short() {
+        t.Skip("waits for the close timeout")
+    }
+    ln := listenCollector(t, false)
+    sink, err := NewNetworkSink("tcp", ln.Addr().String(), WithLogQueueSize(4096), WithErrorHandler(func(error) {}))
+    if err != nil {
+        t.Fatalf("NewNetworkSink: %s", err.Error())
+    }
+
+    body := strings.Repeat("x", 32*1024)
+    for i := 0; i < 4096; i++ { // 128MB，远超套接字缓冲
+        sink.Write(Entry{Time: time.Now(), Level: LL_INFO, Body: body})
+    }
+    start := time.Now()
+    err = sink.Close()
+    if elapsed := time.Since(start); elapsed > networkCloseTimeout+time.Second {
+        t.Errorf("Close took %s, want at most about %s", elapsed, networkCloseTimeout)
+    }
+    dropped := atomic.LoadInt64(&sink.(*networkSink).dropped)
+    if !errors.Is(err, ErrQueueFull) || dropped == 0 {
+        t.Errorf("Close = %v with %d dropped, want dropped lines reported", err, dropped)
+    }
+}
+
+// 有落盘时，连接不上的日志落盘，关闭后不丢弃，下次运行时连接恢复后按先后重发
+func TestNetworkSinkSpool(t *testing.T) {
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("listen: %s", err.Error())
+    }
+    addr := ln.Addr().String()
+    ln.Close() // 收集程序未启动
+    dir := t.TempDir()
+
+    sink, err := NewNetworkSink("tcp", addr, WithSpoolDir(dir, 0, 0), WithErrorHandler(func(error) {}))
+    if err != nil {
+        t.Fatalf("NewNetworkSink: %s", err.Error())
+    }
+    for i := 0; i < 3; i++ {
+        if err := sink.Write(Entry{Time: time.Now(), Level: LL_INFO, Body: fmt.Sprintf("spooled-%d", i)}); err != nil {
+            t.Fatalf("Write: %s", err.Error())
+        }
+    }
+    if err := sink.Close(); err != nil {
+        t.Fatalf("Close = %v, want nil with the lines spooled", err)
+    }
+
+    ln, err = net.Listen("tcp", addr)
+    if err != nil {
+        t.Skipf("listen on %s again: %s", addr, err.Error())
+    }
+    defer ln.Close()
+    sink, err = NewNetworkSink("tcp", addr, WithSpoolDir(dir, 0, 0), WithErrorHandler(func(error) {}))
+    if err != nil {
+        t.Fatalf("NewNetworkSink: %s", err.Error())
+    }
+    defer sink.Close()
+
+    ln.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+    conn, err := ln.Accept()
+    if err != nil {
+        t.Fatalf("accept: %s", err.Error())
+    }
+    defer conn.Close()
+    conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+    scanner := bufio.NewScanner(conn)
+    for i := 0; i < 3; i++ {
+        if !scanner.Scan() {
+            t.Fatalf("read line %d: %v", i, scanner.Err())
+        }
+        if want := fmt.Sprintf("spooled-%d", i); !strings.Contains(scanner.Text(), want) {
+            t.Errorf("line %d = %q, want %s", i, scanner.Text(), want)
+        }
+    }
+}
+
+// 总是编码失败的编解码器
+type failingCodec struct{}
+
+func (failingCodec) Encode(data []byte) ([]byte, error) { return nil, errors.New("encode failed") }
+func (failingCodec) Decode(data []byte) ([]byte, error) { return data, nil }
+
+// 编码失败的日志被丢弃并计数，不重连也不阻塞后面的日志
+func TestNetworkSinkEncodeFailureCounted(t *testing.T) {
+    if err := RegisterCodec("simlog-test-failing", failingCodec{}); err != nil {
+        t.Fatalf("RegisterCodec: %s", err.Error())
+    }
+    ln := listenCollector(t, true)
+    sink, err := NewNetworkSink("tcp", ln.Addr().String(), WithNetworkCodec("simlog-test-failing"))
+    if err != nil {
+        t.Fatalf("NewNetworkSink: %s", err.Error())
+    }
+
+    for i := 0; i < 3; i++ {
+        if err := sink.Write(Entry{Time: time.Now(), Level: LL_INFO, Body: "x"}); err != nil {
+            t.Fatalf("Write: %s", err.Error())
+        }
+    }
+    start := time.Now()
+    err = sink.Close()
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("Close took %s, encode failures should not be retried", elapsed)
+    }
+    if dropped := atomic.LoadInt64(&sink.(*networkSink).dropped); dropped != 3 {
+        t.Errorf("dropped = %d, want 3", dropped)
+    }
+    if err == nil {
+        t.Error("Close returned nil with dropped lines")
+    }
+}
@@ -0,0 +1,188 @@
+package simlog
+
+import (
+    "fmt"
+    "os"
+    "sync/atomic"
+    "time"
+)
+
+// 结构化观察者异步投递时的默认队列大小、最大重试次数和首次重试间隔
+const (
+    defaultObserverQueueSize    = 1024
+    defaultObserverMaxRetries   = 3
+    defaultObserverRetryBackoff = 100 * time.Millisecond
+)
+
+// DeliveryMode 结构化观察者的投递方式
+type DeliveryMode int32
+
+const (
+    DeliverSync        DeliveryMode = 0 // 在写日志的协程中同步调用，出错不重试（同 WithLogObserver）
+    DeliverAsync       DeliveryMode = 1 // 尽力而为：放入观察者的队列由其协程调用，队列满或出错时丢弃，不影响写日志
+    DeliverAtLeastOnce DeliveryMode = 2 // 至少一次：同 DeliverAsync，但出错时按退避重试，队列满时写日志阻塞等待
+)
+
+// EntryObserver 结构化的日志观察者，返回错误表示投递失败（如写 Kafka 失败），
+// 异步投递时 entry 为副本，可在返回后继续使用，但不应修改其中的 Fields 和 Labels。
+type EntryObserver func(entry Entry) error
+
+// ObserverConfig 结构化观察者的配置
+type ObserverConfig struct {
+    Name         string        // 名字，用于 ObserverStats 和失败时的标准错误输出
+    Mode         DeliveryMode  // 投递方式（默认为 DeliverSync）
+    QueueSize    int           // 异步投递的队列大小（默认为1024）
+    MaxRetries   int           // DeliverAtLeastOnce 时失败后的最大重试次数（默认为3），重试仍失败则计为失败
+    RetryBackoff time.Duration // DeliverAtLeastOnce 时首次重试的间隔（默认为100毫秒），之后每次翻倍
+}
+
+// ObserverStat 一个结构化观察者的投递统计
+type ObserverStat struct {
+    Name      string
+    Mode      DeliveryMode
+    Delivered int64 // 投递成功的日志条数
+    Failed    int64 // 投递失败（含重试后仍失败）的日志条数
+    Dropped   int64 // 因队列满或关闭而未投递的日志条数
+    Retries   int64 // 重试次数
+    Queued    int   // 队列中尚未投递的日志条数
+}
+
+// WithEntryObserver 增加一个结构化的日志观察者（可多次调用增加多个），投递方式见 DeliveryMode，
+// 各观察者的投递统计见 ObserverStats，以便据此判断转发到 Kafka 等的告警是否可信。
+// 关闭日志时等待异步队列中的日志投递完（DeliverAtLeastOnce 时包括重试）。
+func WithEntryObserver(observer EntryObserver, config ObserverConfig) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.entryObservers = append(o.entryObservers, observerSpec{observer: observer, config: config})
+    })
+}
+
+// 选项中的一个结构化观察者
+type observerSpec struct {
+    observer EntryObserver
+    config   ObserverConfig
+}
+
+// 运行中的一个结构化观察者
+type entryObserver struct {
+    delivered int64 // 放在最前面，以保证在32位平台上原子操作时的8字节对齐
+    failed    int64
+    dropped   int64
+    retries   int64
+    observer  EntryObserver
+    config    ObserverConfig
+    queue     *ringQueue[Entry] // 异步投递的队列，同步投递时为nil
+    done      chan struct{}     // 投递协程已退出
+}
+
+// 创建各结构化观察者，异步投递的启动投递协程
+func (this *SimLogger) startObservers() {
+    for _, spec := range this.opts.entryObservers {
+        o := &entryObserver{observer: spec.observer, config: spec.config}
+        if o.config.Mode != DeliverSync {
+            if o.config.QueueSize <= 0 {
+                o.config.QueueSize = defaultObserverQueueSize
+            }
+            if o.config.MaxRetries <= 0 {
+                o.config.MaxRetries = defaultObserverMaxRetries
+            }
+            if o.config.RetryBackoff <= 0 {
+                o.config.RetryBackoff = defaultObserverRetryBackoff
+            }
+            o.queue = newRingQueue[Entry](o.config.QueueSize)
+            o.done = make(chan struct{})
+            go o.run()
+        }
+        this.observers = append(this.observers, o)
+    }
+}
+
+// 关闭各异步投递的队列，等待投递完
+func (this *SimLogger) stopObservers() {
+    for _, o := range this.observers {
+        if o.queue != nil {
+            o.queue.close()
+            <-o.done
+        }
+    }
+}
+
+// 投递一条日志给各结构化观察者
+func (this *SimLogger) notifyObservers(entry *Entry) {
+    for _, o := range this.observers {
+        switch o.config.Mode {
+        case DeliverSync:
+            o.deliver(*entry)
+        case DeliverAtLeastOnce:
+            if !o.queue.push(*entry) {
+                atomic.AddInt64(&o.dropped, 1) // 已关闭
+            }
+        default:
+            if !o.queue.offer(*entry) {
+                atomic.AddInt64(&o.dropped, 1)
+                this.reportCountedError(fmt.Errorf("%w: observer %s", ErrQueueFull, o.config.Name))
+            }
+        }
+    }
+}
+
+// 投递协程
+func (this *entryObserver) run() {
+    defer close(this.done)
+    for {
+        entry, ok := this.queue.pop()
+        if !ok {
+            return
+        }
+        this.deliver(entry)
+    }
+}
+
+// 调用观察者，DeliverAtLeastOnce 时失败后按退避重试，panic 计为失败
+func (this *entryObserver) deliver(entry Entry) {
+    backoff := this.config.RetryBackoff
+    for attempt := 0; ; attempt++ {
+        err := this.call(entry)
+        if err == nil {
+            atomic.AddInt64(&this.delivered, 1)
+            return
+        }
+        if this.config.Mode != DeliverAtLeastOnce || attempt >= this.config.MaxRetries {
+            if atomic.AddInt64(&this.failed, 1) == 1 {
+                fmt.Fprintf(os.Stderr, "simlog: observer %s failed: %s\n", this.config.Name, err.Error())
+            }
+            return
+        }
+        atomic.AddInt64(&this.retries, 1)
+        time.Sleep(backoff)
+        backoff *= 2
+    }
+}
+
+func (this *entryObserver) call(entry Entry) (err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            err = fmt.Errorf("simlog: observer panic: %v", r)
+        }
+    }()
+    return this.observer(entry)
+}
+
+// ObserverStats 返回各结构化观察者的投递统计，按 WithEntryObserver 的顺序
+func (this *SimLogger) ObserverStats() []ObserverStat {
+    stats := make([]ObserverStat, 0, len(this.observers))
+    for _, o := range this.observers {
+        stat := ObserverStat{
+            Name:      o.config.Name,
+            Mode:      o.config.Mode,
+            Delivered: atomic.LoadInt64(&o.delivered),
+            Failed:    atomic.LoadInt64(&o.failed),
+            Dropped:   atomic.LoadInt64(&o.dropped),
+            Retries:   atomic.LoadInt64(&o.retries),
+        }
+        if o.queue != nil {
+            stat.Queued = o.queue.len()
+        }
+        stats = append(stats, stat)
+    }
+    return stats
+}
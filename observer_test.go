@@ -0,0 +1,35 @@
+package simlog
+
+import (
+    "testing"
+    "time"
+)
+
+// 异步投递的观察者在关闭之前就收到日志：投递协程等在空队列上时放入日志须唤醒它
+func TestAsyncObserverDeliversBeforeClose(t *testing.T) {
+    for _, mode := range []DeliveryMode{DeliverAsync, DeliverAtLeastOnce} {
+        received := make(chan string, 1)
+        logger := new(SimLogger)
+        err := logger.InitE(EnableFileOutput(false), EnableRegistry(false), WithEntryObserver(func(entry Entry) error {
+            received <- entry.Body
+            return nil
+        }, ObserverConfig{Name: "test", Mode: mode}))
+        if err != nil {
+            t.Fatalf("InitE: %s", err.Error())
+        }
+
+        for _, body := range []string{"first", "second"} {
+            time.Sleep(20 * time.Millisecond) // 让投递协程等在空队列上
+            logger.Info(body)
+            select {
+            case got := <-received:
+                if got != body {
+                    t.Errorf("mode %d: observer got %q, want %q", mode, got, body)
+                }
+            case <-time.After(5 * time.Second):
+                t.Fatalf("mode %d: observer did not receive %q before Close", mode, body)
+            }
+        }
+        logger.Close()
+    }
+}
@@ -0,0 +1,35 @@
+package simlog
+
+// Output 附加输出：一个输出及其日志级别，见 WithAdditionalOutputs
+type Output struct {
+    Sink  Sink     // 输出，如 NewStdoutSink 创建的
+    Level LogLevel // 只写级别数值不大于 Level 的日志，如 LL_WARNING 只写 WARNING、ERROR 和 FATAL，LL_RAW 写所有日志
+}
+
+// WithAdditionalOutputs 增加附加输出，一次写日志同时写到滚动的日志文件和各附加输出，各附加输出有自己的日志级别，如：
+//
+//	stdout, _ := simlog.NewStdoutSink()
+//	logger.Init(simlog.WithAdditionalOutputs(simlog.Output{Sink: stdout, Level: simlog.LL_WARNING}))
+//
+// 附加输出的级别只能比日志的级别（见 SetLogLevel）更严，日志的级别过滤掉的日志不会写到附加输出。
+// 其它同 WithSink。
+func WithAdditionalOutputs(outputs ...Output) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        for _, output := range outputs {
+            o.sinks = append(o.sinks, &leveledSink{Sink: output.Sink, level: output.Level})
+        }
+    })
+}
+
+// 按日志级别过滤的输出
+type leveledSink struct {
+    Sink
+    level LogLevel
+}
+
+func (this *leveledSink) Write(entry Entry) error {
+    if entry.Level > this.level {
+        return nil
+    }
+    return this.Sink.Write(entry)
+}
@@ -0,0 +1,77 @@
+package simlog
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "sync/atomic"
+)
+
+// 持久化的运行时设置
+type runtimeSettings struct {
+    Level  LogLevel `json:"level"`  // 日志级别
+    Trace  bool     `json:"trace"`  // 是否输出跟踪日志
+    Caller bool     `json:"caller"` // 是否记录调用者
+}
+
+// WithPersistRuntimeSettings 将运行中对日志级别、跟踪日志和记录调用者的调整（SetLogLevel、EnableTraceLog、EnableLogCaller，
+// 包括经 simloghttp.Control 的调整）保存到状态文件 path，下次 Init 时恢复，并覆盖选项中的对应设置，
+// 以便运维在排查期间临时调高的日志级别在进程崩溃重启后依然有效。排查结束后应调回或删除状态文件。
+// 状态文件不存在时不恢复，读或写失败时只向标准错误输出提示，不影响写日志。
+func WithPersistRuntimeSettings(path string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.persistPath = path
+    })
+}
+
+// 从状态文件恢复运行时设置（由 Init 调用）
+func (this *SimLogger) restoreRuntimeSettings() {
+    data, err := os.ReadFile(this.opts.persistPath)
+    if err != nil {
+        if !os.IsNotExist(err) {
+            fmt.Fprintf(os.Stderr, "simlog: restore runtime settings failed: %s\n", err.Error())
+        }
+        return
+    }
+
+    var settings runtimeSettings
+    if err = json.Unmarshal(data, &settings); err != nil {
+        fmt.Fprintf(os.Stderr, "simlog: restore runtime settings from %s failed: %s\n", this.opts.persistPath, err.Error())
+        return
+    }
+    atomic.StoreInt32(&this.opts.logLevel, int32(settings.Level))
+    atomic.StoreInt32(&this.opts.enableTraceLog, boolToInt32(settings.Trace))
+    atomic.StoreInt32(&this.opts.logCaller, boolToInt32(settings.Caller))
+}
+
+// 保存运行时设置到状态文件，先写临时文件再改名，以免崩溃时留下不完整的状态文件
+func (this *SimLogger) saveRuntimeSettings() {
+    if this.loggerCore == nil || this.opts.persistPath == "" {
+        return
+    }
+
+    this.persistMutex.Lock()
+    defer this.persistMutex.Unlock()
+    settings := runtimeSettings{
+        Level:  LogLevel(atomic.LoadInt32(&this.opts.logLevel)),
+        Trace:  this.IsEnabledTraceLog(),
+        Caller: this.EnabledLogCaller(),
+    }
+    data, _ := json.Marshal(&settings)
+    tmpPath := this.opts.persistPath + ".tmp"
+    err := os.WriteFile(tmpPath, append(data, '\n'), 0644)
+    if err == nil {
+        err = os.Rename(tmpPath, this.opts.persistPath)
+    }
+    if err != nil {
+        os.Remove(tmpPath)
+        fmt.Fprintf(os.Stderr, "simlog: save runtime settings failed: %s\n", err.Error())
+    }
+}
+
+func boolToInt32(b bool) int32 {
+    if b {
+        return 1
+    }
+    return 0
+}
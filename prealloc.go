@@ -0,0 +1,36 @@
+package simlog
+
+import (
+    "bufio"
+    "io"
+)
+
+// WithPrealloc 是否在 Init 时预分配写日志用的内存（默认为 false）：
+// 日志队列（其存储总是在 Init 时分配）之外，预分配写协程的一批日志的切片（大小为 WithBatchNumber）和写缓冲（大小为 WithWriteBufferSize），
+// 并在之后重复使用（写缓冲在滚动重新打开日志文件时亦不再重新分配），同时写一遍这些内存，使操作系统提前分配物理页，
+// 以免启动后的第一波突发日志引起内存分配和 GC 的尖峰。代价是 Init 稍慢，且常驻这些内存。
+func WithPrealloc(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.prealloc = enabled
+    })
+}
+
+// Init 时预分配
+func (this *SimLogger) preallocate() {
+    if this.opts.writeBufferSize > 0 && !this.opts.noFileOutput {
+        size := int(this.opts.writeBufferSize)
+        this.writeBuffer = bufio.NewWriterSize(io.Discard, size)
+        // 缓冲为空时写入不小于缓冲大小的数据会绕过缓冲直接写，所以只写 size-1 个字节，以写到缓冲的每一页
+        this.writeBuffer.Write(make([]byte, size-1))
+        this.writeBuffer.Reset(io.Discard)
+    }
+    if this.opts.asyncWrite && this.opts.backend == nil && !this.opts.coalesce {
+        batchNumber := 1
+        if this.opts.batchNumber > 0 {
+            batchNumber = int(this.opts.batchNumber)
+        }
+        this.batchBuffer = make([]string, batchNumber)
+        clear(this.batchBuffer) // 写一遍，使操作系统提前分配物理页
+        this.batchBuffer = this.batchBuffer[:0]
+    }
+}
@@ -0,0 +1,80 @@
+package simlog
+
+import (
+    "fmt"
+    "os"
+    "sort"
+    "sync"
+)
+
+// ProfileEnv 覆盖 WithProfile 所选配置的环境变量，如 SIMLOG_PROFILE=dev
+const ProfileEnv = "SIMLOG_PROFILE"
+
+// ProfileFunc 返回一个配置的选项，每次 Init 调用一次，以便配置中的输出（如 NewStdoutSink）每个日志各有一个
+type ProfileFunc func() []LogOption
+
+var (
+    profilesMutex sync.Mutex
+    profiles      = map[string]ProfileFunc{
+        // 开发：同 DevMode，DEBUG 级别
+        "dev": func() []LogOption {
+            return []LogOption{DevMode(), WithLogLevel(LL_DEBUG)}
+        },
+        // 预发布：同 ProductionMode，但记录调用者，DEBUG 级别
+        "staging": func() []LogOption {
+            return []LogOption{ProductionMode(), EnableLogCaller(true), WithLogLevel(LL_DEBUG)}
+        },
+        // 生产：同 ProductionMode，INFO 级别
+        "prod": func() []LogOption {
+            return []LogOption{ProductionMode(), WithLogLevel(LL_INFO)}
+        },
+    }
+)
+
+// RegisterProfile 注册（或替换）名为 name 的配置，配置可组合格式、输出、级别等任意选项，如：
+//
+//	simlog.RegisterProfile("prod", func() []simlog.LogOption {
+//	    stdout, _ := simlog.NewStdoutSink(simlog.WithFormat(simlog.FormatJSON))
+//	    return []simlog.LogOption{simlog.ProductionMode(), simlog.WithAdditionalOutputs(simlog.Output{Sink: stdout, Level: simlog.LL_ERROR})}
+//	})
+//
+// 内置“dev”、“staging”和“prod”三个配置，可被替换。
+func RegisterProfile(name string, profile ProfileFunc) {
+    profilesMutex.Lock()
+    defer profilesMutex.Unlock()
+    profiles[name] = profile
+}
+
+// Profiles 返回已注册的配置名，按名字排列
+func Profiles() []string {
+    profilesMutex.Lock()
+    defer profilesMutex.Unlock()
+    names := make([]string, 0, len(profiles))
+    for name := range profiles {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// WithProfile 使用名为 name 的配置（见 RegisterProfile），设置了环境变量 SIMLOG_PROFILE 时使用其指定的配置，
+// 以便同一个程序按环境切换日志的格式、输出和级别，而不必在代码中到处判断环境。
+// 其后的选项仍然生效，可覆盖配置中的个别项。配置不存在时 Init 失败。
+func WithProfile(name string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        selected := name
+        if env := os.Getenv(ProfileEnv); env != "" {
+            selected = env
+        }
+        profilesMutex.Lock()
+        profile, ok := profiles[selected]
+        profilesMutex.Unlock()
+        if !ok {
+            o.optionErr = fmt.Errorf("simlog: unknown profile %q", selected)
+            return
+        }
+        for _, opt := range profile() {
+            opt.apply(o)
+        }
+    })
+}
@@ -0,0 +1,67 @@
+package simlog
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "sync/atomic"
+    "syscall"
+    "time"
+)
+
+// 只读降级时探测日志文件是否恢复可写的默认间隔
+const defaultReadOnlyProbeInterval = 10 * time.Second
+
+// WithReadOnlyFallback 设置日志文件所在的文件系统变为只读（EROFS）时的降级输出（默认为标准错误）和探测间隔（默认为10秒）。
+// 检测到只读后，日志改写到 fallback（如标准错误或内存中的环形缓冲），不再每条日志都去尝试打开日志文件，
+// 每隔 probeInterval 探测一次，日志文件恢复可写后自动切回。降级期间写到 fallback 的日志行数见 Stats 的 Fallback。
+func WithReadOnlyFallback(fallback io.Writer, probeInterval time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.readOnlyFallback = fallback
+        o.readOnlyProbeInterval = probeInterval
+    })
+}
+
+// 是否为只读文件系统的错误
+func isReadOnlyError(err error) bool {
+    return errors.Is(err, syscall.EROFS)
+}
+
+// err 为只读文件系统的错误时进入（或延续）只读降级，并返回 true
+func (this *SimLogger) enterReadOnly(err error) bool {
+    if err == nil || !isReadOnlyError(err) {
+        return false
+    }
+    probeInterval := this.opts.readOnlyProbeInterval
+    if probeInterval <= 0 {
+        probeInterval = defaultReadOnlyProbeInterval
+    }
+    if atomic.SwapInt64(&this.readOnlyProbe, time.Now().Add(probeInterval).UnixNano()) == 0 {
+        fmt.Fprintf(os.Stderr, "simlog: log file://%s is on a read-only file system, falling back until it is writable again\n", this.getFilepath())
+    }
+    return true
+}
+
+// 是否处于只读降级且未到探测时间，到了探测时间时返回 false，以便尝试写一次日志文件
+func (this *SimLogger) inReadOnly() bool {
+    probe := atomic.LoadInt64(&this.readOnlyProbe)
+    return probe != 0 && time.Now().UnixNano() < probe
+}
+
+// 写日志文件成功后调用，退出只读降级
+func (this *SimLogger) leaveReadOnly() {
+    if atomic.LoadInt64(&this.readOnlyProbe) != 0 && atomic.SwapInt64(&this.readOnlyProbe, 0) != 0 {
+        fmt.Fprintf(os.Stderr, "simlog: log file://%s is writable again\n", this.getFilepath())
+    }
+}
+
+// 只读降级期间写降级输出，lines 为 logData 中的日志行数
+func (this *SimLogger) writeFallback(lines int, logData string) (int, error) {
+    fallback := this.opts.readOnlyFallback
+    if fallback == nil {
+        fallback = os.Stderr
+    }
+    atomic.AddInt64(&this.numFallback, int64(lines))
+    return io.WriteString(fallback, logData)
+}
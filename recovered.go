@@ -0,0 +1,49 @@
+package simlog
+
+import (
+    "fmt"
+    "runtime/debug"
+)
+
+// Recovered 以 ERROR 级别记录 recover() 得到的 panic 值 v 和调用栈 stack，
+// 按 v 的类型分别输出，而不是用 fmt.Sprint 混在一起，便于区分 panic 的来源：
+//
+//	panic (error *fs.PathError): open /x: no such file or directory
+//	panic (stringer main.State): running
+//	panic (value main.Point): main.Point{X:1, Y:2}
+//
+// 其后为调用栈，stack 为空时取 Recovered 处的调用栈。一般用法：
+//
+//	defer func() {
+//	    if r := recover(); r != nil {
+//	        logger.Recovered(r, debug.Stack())
+//	    }
+//	}()
+func (this *SimLogger) Recovered(v interface{}, stack []byte) (int, error) {
+    if !this.IsEnabledErrorLog() {
+        return 0, nil
+    }
+    caller := this.getCaller(LL_ERROR, this.opts.skip-1) // 少了 SkipError 和 skipLog 两层，多了本函数一层
+    if len(stack) == 0 {
+        stack = debug.Stack()
+    }
+    logBody := formatPanicValue(v) + "\n" + string(stack)
+    if stack[len(stack)-1] == '\n' {
+        return this.output(LL_ERROR, caller, logBody, false)
+    }
+    return this.output(LL_ERROR, caller, logBody, true)
+}
+
+// 按类型格式化 panic 值
+func formatPanicValue(v interface{}) string {
+    switch value := v.(type) {
+    case nil:
+        return "panic (nil)"
+    case error:
+        return fmt.Sprintf("panic (error %T): %s", value, value.Error())
+    case fmt.Stringer:
+        return fmt.Sprintf("panic (stringer %T): %s", value, value.String())
+    default:
+        return fmt.Sprintf("panic (value %T): %#v", value, value)
+    }
+}
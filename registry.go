@@ -0,0 +1,82 @@
+package simlog
+
+import (
+    "errors"
+    "sync"
+)
+
+// 已初始化但尚未关闭的 SimLogger 和 Backend，供 CloseAll 使用
+var registry struct {
+    mutex    sync.Mutex
+    loggers  []*SimLogger // 按 Init 的顺序
+    backends []*Backend   // 按 NewBackend 的顺序
+}
+
+// EnableRegistry 是否登记到全局登记处以便 CloseAll 关闭（默认为 true），
+// 不需要由 CloseAll 关闭的短期日志可设为 false。
+func EnableRegistry(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.noRegistry = !enabled
+    })
+}
+
+// CloseAll 按依赖顺序关闭所有已初始化但尚未关闭的 SimLogger 和 Backend：
+// 先按 Init 的相反顺序关闭 SimLogger（写完各自队列中的日志），再关闭 Backend，
+// 以便在 main 中 defer simlog.CloseAll()，而不必逐个跟踪日志对象。返回所有关闭错误。
+func CloseAll() error {
+    var errs []error
+
+    registry.mutex.Lock()
+    loggers := registry.loggers
+    backends := registry.backends
+    registry.loggers = nil
+    registry.backends = nil
+    registry.mutex.Unlock()
+
+    for i := len(loggers) - 1; i >= 0; i-- {
+        if err := loggers[i].Close(); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    for _, backend := range backends {
+        if err := backend.Close(); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    return errors.Join(errs...)
+}
+
+func registerLogger(logger *SimLogger) {
+    registry.mutex.Lock()
+    defer registry.mutex.Unlock()
+    registry.loggers = append(registry.loggers, logger)
+}
+
+// 注销 logger（关闭时），logger 可以是子日志，按共用的 loggerCore 查找
+func unregisterLogger(logger *SimLogger) {
+    registry.mutex.Lock()
+    defer registry.mutex.Unlock()
+    for i, l := range registry.loggers {
+        if l.loggerCore == logger.loggerCore {
+            registry.loggers = append(registry.loggers[:i], registry.loggers[i+1:]...)
+            return
+        }
+    }
+}
+
+func registerBackend(backend *Backend) {
+    registry.mutex.Lock()
+    defer registry.mutex.Unlock()
+    registry.backends = append(registry.backends, backend)
+}
+
+func unregisterBackend(backend *Backend) {
+    registry.mutex.Lock()
+    defer registry.mutex.Unlock()
+    for i, b := range registry.backends {
+        if b == backend {
+            registry.backends = append(registry.backends[:i], registry.backends[i+1:]...)
+            return
+        }
+    }
+}
@@ -0,0 +1,183 @@
+package simlog
+
+import (
+    "sync"
+    "sync/atomic"
+)
+
+// 无锁的多生产者单消费者环形队列（基于 Dmitry Vyukov 的有界队列），用于异步写的日志队列，
+// 代替带缓冲的 channel：每秒百万条日志以上时 channel 的收发开销很明显，
+// 且可廉价地取得队列占用（len）。
+// 队列满时生产者阻塞，队列空时消费者阻塞，语义同带缓冲的 channel；关闭后 push 返回 false。
+type ringQueue[T any] struct {
+    tail   uint64 // 下一个写入位置（生产者竞争）
+    _      [56]byte
+    head   uint64 // 下一个读取位置（只有消费者修改）
+    _      [56]byte
+    mask   uint64
+    slots  []ringSlot[T]
+    closed int32
+
+    consumer        consumerGuard // -tags simlogdebug 时检查是否只有一个消费者
+    consumerWaiting int32         // 消费者是否在等待非空
+    notEmpty        chan struct{} // 唤醒等待的消费者
+    producerWaiting int32         // 等待非满的生产者数
+    fullMutex       sync.Mutex
+    notFull         *sync.Cond // 唤醒等待的生产者
+}
+
+type ringSlot[T any] struct {
+    seq  uint64 // 等于位置时可写，等于位置+1时可读
+    item T
+}
+
+// 创建容量至少为 size（向上取整为2的幂）的环形队列
+func newRingQueue[T any](size int) *ringQueue[T] {
+    capacity := 2
+    for capacity < size {
+        capacity <<= 1
+    }
+    this := &ringQueue[T]{
+        mask:     uint64(capacity - 1),
+        slots:    make([]ringSlot[T], capacity),
+        notEmpty: make(chan struct{}, 1),
+    }
+    for i := range this.slots {
+        this.slots[i].seq = uint64(i)
+    }
+    this.notFull = sync.NewCond(&this.fullMutex)
+    return this
+}
+
+// 放入一项，队列满时阻塞，队列已关闭时返回 false
+func (this *ringQueue[T]) push(item T) bool {
+    for {
+        if atomic.LoadInt32(&this.closed) == 1 {
+            return false
+        }
+        if this.tryPush(item) {
+            this.wakeConsumer()
+            return true
+        }
+
+        // 队列满，等待消费者取走
+        this.fullMutex.Lock()
+        atomic.AddInt32(&this.producerWaiting, 1)
+        if this.full() && atomic.LoadInt32(&this.closed) == 0 {
+            this.notFull.Wait()
+        }
+        atomic.AddInt32(&this.producerWaiting, -1)
+        this.fullMutex.Unlock()
+    }
+}
+
+// 不阻塞地放入一项并唤醒等待的消费者，队列满或已关闭时返回 false
+func (this *ringQueue[T]) offer(item T) bool {
+    if atomic.LoadInt32(&this.closed) == 1 || !this.tryPush(item) {
+        return false
+    }
+    this.wakeConsumer()
+    return true
+}
+
+// 唤醒等待非空的消费者
+func (this *ringQueue[T]) wakeConsumer() {
+    if atomic.LoadInt32(&this.consumerWaiting) == 1 {
+        select {
+        case this.notEmpty <- struct{}{}:
+        default:
+        }
+    }
+}
+
+// 不阻塞地放入一项，队列满时返回 false（不唤醒消费者，由调用者唤醒或消费者轮询）
+func (this *ringQueue[T]) tryPush(item T) bool {
+    pos := atomic.LoadUint64(&this.tail)
+    for {
+        slot := &this.slots[pos&this.mask]
+        seq := atomic.LoadUint64(&slot.seq)
+        if seq == pos {
+            if atomic.CompareAndSwapUint64(&this.tail, pos, pos+1) {
+                slot.item = item
+                atomic.StoreUint64(&slot.seq, pos+1)
+                return true
+            }
+            pos = atomic.LoadUint64(&this.tail)
+        } else if seq < pos {
+            return false // 满
+        } else {
+            pos = atomic.LoadUint64(&this.tail)
+        }
+    }
+}
+
+// 不阻塞地取出一项（只能由消费者调用），队列空时第2个返回值为 false
+func (this *ringQueue[T]) tryPop() (T, bool) {
+    var zero T
+    this.consumer.enter()
+    slot := &this.slots[this.head&this.mask]
+    if atomic.LoadUint64(&slot.seq) != this.head+1 {
+        this.consumer.leave()
+        return zero, false
+    }
+    item := slot.item
+    slot.item = zero
+    atomic.StoreUint64(&slot.seq, this.head+this.mask+1)
+    atomic.AddUint64(&this.head, 1)
+    this.consumer.leave()
+
+    if atomic.LoadInt32(&this.producerWaiting) > 0 {
+        this.fullMutex.Lock()
+        this.notFull.Broadcast()
+        this.fullMutex.Unlock()
+    }
+    return item, true
+}
+
+// 取出一项（只能由消费者调用），队列空时阻塞，队列已关闭且为空时第2个返回值为 false
+func (this *ringQueue[T]) pop() (T, bool) {
+    for {
+        if item, ok := this.tryPop(); ok {
+            return item, true
+        }
+
+        atomic.StoreInt32(&this.consumerWaiting, 1)
+        if item, ok := this.tryPop(); ok { // 设置等待标志后再检查一次，以免错过唤醒
+            atomic.StoreInt32(&this.consumerWaiting, 0)
+            return item, true
+        }
+        if atomic.LoadInt32(&this.closed) == 1 {
+            atomic.StoreInt32(&this.consumerWaiting, 0)
+            var zero T
+            return zero, false
+        }
+        <-this.notEmpty
+        atomic.StoreInt32(&this.consumerWaiting, 0)
+    }
+}
+
+// 关闭队列，消费者取完剩余的项后 pop 返回 false
+func (this *ringQueue[T]) close() {
+    atomic.StoreInt32(&this.closed, 1)
+    select {
+    case this.notEmpty <- struct{}{}:
+    default:
+    }
+    this.fullMutex.Lock()
+    this.notFull.Broadcast()
+    this.fullMutex.Unlock()
+}
+
+// 队列中的项数（快照）
+func (this *ringQueue[T]) len() int {
+    tail := atomic.LoadUint64(&this.tail)
+    head := atomic.LoadUint64(&this.head)
+    if tail <= head {
+        return 0
+    }
+    return int(tail - head)
+}
+
+func (this *ringQueue[T]) full() bool {
+    return this.len() > int(this.mask)
+}
@@ -0,0 +1,185 @@
+package simlog
+
+import (
+    "sync"
+    "testing"
+    "time"
+)
+
+// 多个生产者和一个消费者：每项恰好取出一次，同一生产者的项按放入的顺序取出
+func TestRingQueueManyProducers(t *testing.T) {
+    const producers = 8
+    const perProducer = 20000
+
+    type item struct {
+        producer int
+        seq      int
+    }
+    queue := newRingQueue[item](64)
+
+    var wg sync.WaitGroup
+    for p := 0; p < producers; p++ {
+        wg.Add(1)
+        go func(p int) {
+            defer wg.Done()
+            for i := 0; i < perProducer; i++ {
+                if !queue.push(item{producer: p, seq: i}) {
+                    t.Errorf("push returned false before close")
+                    return
+                }
+            }
+        }(p)
+    }
+    go func() {
+        wg.Wait()
+        queue.close()
+    }()
+
+    next := make([]int, producers)
+    for {
+        it, ok := queue.pop()
+        if !ok {
+            break
+        }
+        if it.seq != next[it.producer] {
+            t.Fatalf("producer %d: got seq %d, want %d", it.producer, it.seq, next[it.producer])
+        }
+        next[it.producer]++
+    }
+    for p, n := range next {
+        if n != perProducer {
+            t.Errorf("producer %d: got %d items, want %d", p, n, perProducer)
+        }
+    }
+    if n := queue.len(); n != 0 {
+        t.Errorf("len after drain = %d, want 0", n)
+    }
+}
+
+// 队列满时 push 阻塞，消费者取走一项后被唤醒
+func TestRingQueueFullBlocksPush(t *testing.T) {
+    queue := newRingQueue[int](2)
+    queue.push(1)
+    queue.push(2)
+    if queue.tryPush(3) {
+        t.Fatal("tryPush on a full queue succeeded")
+    }
+
+    pushed := make(chan bool)
+    go func() {
+        pushed <- queue.push(3)
+    }()
+    select {
+    case <-pushed:
+        t.Fatal("push on a full queue did not block")
+    case <-time.After(50 * time.Millisecond):
+    }
+
+    if it, ok := queue.pop(); !ok || it != 1 {
+        t.Fatalf("pop = %d, %v, want 1, true", it, ok)
+    }
+    select {
+    case ok := <-pushed:
+        if !ok {
+            t.Fatal("blocked push returned false")
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("blocked push was not woken after pop")
+    }
+    for _, want := range []int{2, 3} {
+        if it, ok := queue.pop(); !ok || it != want {
+            t.Fatalf("pop = %d, %v, want %d, true", it, ok, want)
+        }
+    }
+}
+
+// 关闭时阻塞的生产者被唤醒并返回 false，关闭后 push 和 offer 也返回 false
+func TestRingQueueCloseWakesProducers(t *testing.T) {
+    queue := newRingQueue[int](2)
+    queue.push(1)
+    queue.push(2)
+
+    const blocked = 4
+    results := make(chan bool, blocked)
+    for i := 0; i < blocked; i++ {
+        go func() {
+            results <- queue.push(3)
+        }()
+    }
+    time.Sleep(50 * time.Millisecond)
+    queue.close()
+
+    for i := 0; i < blocked; i++ {
+        select {
+        case ok := <-results:
+            if ok {
+                t.Error("push blocked across close returned true")
+            }
+        case <-time.After(5 * time.Second):
+            t.Fatal("blocked push was not woken by close")
+        }
+    }
+    if queue.push(4) {
+        t.Error("push after close returned true")
+    }
+    if queue.offer(4) {
+        t.Error("offer after close returned true")
+    }
+}
+
+// 关闭后 pop 先取完剩余的项再返回 false，阻塞在 pop 上的消费者被关闭唤醒
+func TestRingQueuePopAfterClose(t *testing.T) {
+    queue := newRingQueue[int](8)
+    for i := 1; i <= 3; i++ {
+        queue.push(i)
+    }
+    queue.close()
+    for want := 1; want <= 3; want++ {
+        if it, ok := queue.pop(); !ok || it != want {
+            t.Fatalf("pop = %d, %v, want %d, true", it, ok, want)
+        }
+    }
+    if _, ok := queue.pop(); ok {
+        t.Fatal("pop on a closed and drained queue returned true")
+    }
+
+    empty := newRingQueue[int](8)
+    popped := make(chan bool)
+    go func() {
+        _, ok := empty.pop()
+        popped <- ok
+    }()
+    time.Sleep(50 * time.Millisecond)
+    empty.close()
+    select {
+    case ok := <-popped:
+        if ok {
+            t.Error("pop woken by close returned true")
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("blocked pop was not woken by close")
+    }
+}
+
+// offer 唤醒等在空队列上的消费者
+func TestRingQueueOfferWakesConsumer(t *testing.T) {
+    queue := newRingQueue[int](8)
+    popped := make(chan int)
+    go func() {
+        it, _ := queue.pop()
+        popped <- it
+    }()
+    time.Sleep(50 * time.Millisecond)
+
+    if !queue.offer(7) {
+        t.Fatal("offer on an empty queue returned false")
+    }
+    select {
+    case it := <-popped:
+        if it != 7 {
+            t.Errorf("pop = %d, want 7", it)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("offer did not wake the blocked consumer")
+    }
+}
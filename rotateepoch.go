@@ -0,0 +1,46 @@
+package simlog
+
+import (
+    "os"
+    "strconv"
+    "strings"
+)
+
+// 滚动日志文件的选举：多个进程写同一个日志文件时，可能都判断到文件已满而先后滚动，
+// 后加到文件锁的进程若再滚动一次，会把刚滚动出的备份再移一位，丢掉最老的备份，当前文件也被滚动为一个几乎空的备份。
+// 加文件锁后，只有打开的仍是当前日志文件的进程（本轮的所有者）执行改名，其它进程说明本轮已被滚动过，只需重新打开；
+// 所有者滚动后将文件锁中的滚动轮次加1，见 RotationEpoch。
+
+// 加文件锁后判断本进程是否为本轮滚动的所有者：f 仍为 curFilepath 指向的文件
+func ownsRotation(curFilepath string, f *os.File) bool {
+    fi, err := f.Stat()
+    if err != nil {
+        return true // 无法判断时按原来的方式滚动
+    }
+    pfi, err := os.Stat(curFilepath)
+    if err != nil {
+        return false // 已被其它进程改名，且还没有进程重新创建
+    }
+    return os.SameFile(fi, pfi)
+}
+
+// RotationEpoch 返回日志文件的滚动轮次，即所有进程对其滚动的总次数（记录在文件锁中，见 LockFilepath），
+// 从未滚动过时为0，日志收集程序可据此判断自上次查看后是否发生了滚动。lockDir 同 WithLockDir，为空表示和日志文件在同一目录。
+func RotationEpoch(logFilepath, lockDir string) int64 {
+    return readRotationEpoch(LockFilepath(logFilepath, lockDir))
+}
+
+func readRotationEpoch(lockFilepath string) int64 {
+    data, err := os.ReadFile(lockFilepath)
+    if err != nil {
+        return 0
+    }
+    epoch, _ := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+    return epoch
+}
+
+// 滚动后将滚动轮次加1，调用者需持有文件锁
+func bumpRotationEpoch(lockFilepath string) {
+    epoch := readRotationEpoch(lockFilepath) + 1
+    os.WriteFile(lockFilepath, []byte(strconv.FormatInt(epoch, 10)+"\n"), 0644)
+}
@@ -0,0 +1,54 @@
+package simlog
+
+import (
+    "os"
+    "sync/atomic"
+    "time"
+)
+
+// WithMinRotateInterval 设置两次滚动日志文件的最小间隔（默认为0，表示不限）。
+// 日志文件很小而写日志的进程很多时，可能每秒滚动几百次，反复改名和争抢文件锁；
+// 设置后间隔内不再滚动（日志文件会暂时超过大小），多个进程间以第1个备份文件（日志文件名加 .1）的修改时间作为上次滚动的时间。
+func WithMinRotateInterval(interval time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.minRotateInterval = interval
+    })
+}
+
+// 本进程距上次滚动是否未到最小间隔，未到时不必再去加文件锁
+func (this *SimLogger) rotateSuppressed() bool {
+    interval := this.opts.minRotateInterval
+    if interval <= 0 {
+        return false
+    }
+    lastRotate := atomic.LoadInt64(&this.lastRotate)
+    return lastRotate != 0 && time.Now().UnixNano()-lastRotate < int64(interval)
+}
+
+// 加文件锁后判断：其它进程距上次滚动是否未到最小间隔，
+// 刚滚动出的备份文件的修改时间即为滚动前最后一次写入的时间
+func (this *SimLogger) rotateSuppressedByOthers(curFilepath string) bool {
+    interval := this.opts.minRotateInterval
+    if interval <= 0 {
+        return false
+    }
+    fi, err := os.Stat(curFilepath + ".1")
+    if err != nil && this.opts.backupCodec != nil {
+        fi, err = os.Stat(curFilepath + ".1" + this.backupExt()) // 压缩的备份保留了修改时间
+    }
+    if err != nil {
+        return false
+    }
+    if since := time.Since(fi.ModTime()); since >= 0 && since < interval {
+        atomic.StoreInt64(&this.lastRotate, fi.ModTime().UnixNano())
+        return true
+    }
+    return false
+}
+
+// 滚动后记录本进程的滚动时间
+func (this *SimLogger) markRotated() {
+    if this.opts.minRotateInterval > 0 {
+        atomic.StoreInt64(&this.lastRotate, time.Now().UnixNano())
+    }
+}
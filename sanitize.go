@@ -0,0 +1,171 @@
+package simlog
+
+import (
+    "fmt"
+    "strings"
+    "sync/atomic"
+    "unicode/utf8"
+)
+
+// SanitizeMode 日志体中控制字符的处理方式
+type SanitizeMode int32
+
+const (
+    SanitizeNone   SanitizeMode = 0 // 不处理（默认）
+    SanitizeStrip  SanitizeMode = 1 // 删除控制字符，非法的 UTF-8 字节替换为 U+FFFD
+    SanitizeEscape SanitizeMode = 2 // 转义控制字符和非法的 UTF-8 字节，比如换行转为“\n”，ESC 转为“\x1b”
+)
+
+// 截断标记
+const truncatedMark = "..."
+
+// WithSanitize 设置日志体中控制字符（包括 ANSI 转义序列的 ESC 和 Unicode 双向控制字符）的处理方式，
+// 防止用户输入伪造日志行或在 tail 时破坏终端，日志体末尾的换行符会被保留，裸日志不做处理。
+func WithSanitize(mode SanitizeMode) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        atomic.StoreInt32(&o.sanitizeMode, int32(mode))
+    })
+}
+
+// WithMaxBodyLength 设置日志体的最大字节数（不包括末尾的换行符），超出时在字符边界上截断并加上“...”，
+// 小于等于0表示不限制（默认），裸日志不做处理。
+func WithMaxBodyLength(maxBodyLength int32) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        atomic.StoreInt32(&o.maxBodyLength, maxBodyLength)
+    })
+}
+
+// 取得控制字符的处理方式
+func (this *SimLogger) GetSanitizeMode() SanitizeMode {
+    return SanitizeMode(atomic.LoadInt32(&this.opts.sanitizeMode))
+}
+
+// 设置控制字符的处理方式
+func (this *SimLogger) SetSanitizeMode(mode SanitizeMode) {
+    this.checkInit()
+    atomic.StoreInt32(&this.opts.sanitizeMode, int32(mode))
+}
+
+// 取得日志体的最大字节数
+func (this *SimLogger) GetMaxBodyLength() int32 {
+    return atomic.LoadInt32(&this.opts.maxBodyLength)
+}
+
+// 设置日志体的最大字节数
+func (this *SimLogger) SetMaxBodyLength(maxBodyLength int32) {
+    this.checkInit()
+    atomic.StoreInt32(&this.opts.maxBodyLength, maxBodyLength)
+}
+
+// 按设置处理日志体
+func (this *SimLogger) sanitizeBody(logBody string) string {
+    mode := SanitizeMode(atomic.LoadInt32(&this.opts.sanitizeMode))
+    maxBodyLength := int(atomic.LoadInt32(&this.opts.maxBodyLength))
+    if mode == SanitizeNone && maxBodyLength <= 0 {
+        return logBody
+    }
+
+    // 保留末尾的换行符
+    n := len(logBody)
+    for n > 0 && logBody[n-1] == '\n' {
+        n--
+    }
+    body, lineFeeds := logBody[:n], logBody[n:]
+    if mode != SanitizeNone {
+        body = SanitizeString(body, mode)
+    }
+    if maxBodyLength > 0 {
+        body = TruncateString(body, maxBodyLength)
+    }
+    return body + lineFeeds
+}
+
+// 是否为需要处理的控制字符
+func isControlRune(r rune) bool {
+    switch {
+    case r < 0x20 && r != '\t':
+        return true
+    case r >= 0x7f && r <= 0x9f: // DEL 和 C1 控制字符
+        return true
+    case r >= 0x202a && r <= 0x202e: // 双向嵌入和覆盖
+        return true
+    case r >= 0x2066 && r <= 0x2069: // 双向隔离
+        return true
+    }
+    return false
+}
+
+// SanitizeString 按 mode 处理字符串中的控制字符和非法的 UTF-8 字节
+func SanitizeString(s string, mode SanitizeMode) string {
+    if mode == SanitizeNone {
+        return s
+    }
+
+    // 快速路径：无需处理时不分配内存
+    clean := true
+    for i := 0; i < len(s); i++ {
+        if c := s[i]; c < 0x20 && c != '\t' || c >= 0x7f {
+            clean = false
+            break
+        }
+    }
+    if clean {
+        return s
+    }
+
+    var sb strings.Builder
+    sb.Grow(len(s))
+    for i := 0; i < len(s); {
+        r, size := utf8.DecodeRuneInString(s[i:])
+        if r == utf8.RuneError && size == 1 {
+            if mode == SanitizeEscape {
+                sb.WriteString(`\x`)
+                sb.WriteString(hex2(s[i]))
+            } else {
+                sb.WriteRune(utf8.RuneError)
+            }
+        } else if isControlRune(r) {
+            if mode == SanitizeEscape {
+                switch r {
+                case '\n':
+                    sb.WriteString(`\n`)
+                case '\r':
+                    sb.WriteString(`\r`)
+                default:
+                    if r < 0x80 {
+                        sb.WriteString(`\x`)
+                        sb.WriteString(hex2(byte(r)))
+                    } else {
+                        sb.WriteString(fmt.Sprintf(`\u%04x`, r))
+                    }
+                }
+            }
+        } else {
+            sb.WriteString(s[i : i+size])
+        }
+        i += size
+    }
+    return sb.String()
+}
+
+// TruncateString 将字符串截断为不超过 maxLength 个字节（包括截断标记“...”），截断发生在字符边界上
+func TruncateString(s string, maxLength int) string {
+    if maxLength <= 0 || len(s) <= maxLength {
+        return s
+    }
+    if maxLength <= len(truncatedMark) {
+        return truncatedMark[:maxLength]
+    }
+
+    n := maxLength - len(truncatedMark)
+    for n > 0 && !utf8.RuneStart(s[n]) {
+        n--
+    }
+    return s[:n] + truncatedMark
+}
+
+// 一个字节的两位十六进制表示
+func hex2(c byte) string {
+    const digits = "0123456789abcdef"
+    return string([]byte{digits[c>>4], digits[c&0x0f]})
+}
@@ -0,0 +1,35 @@
+package simlog
+
+import (
+    "io"
+    "sync/atomic"
+)
+
+// WithShadowSink 设置影子输出（双写），每条日志在写入主日志文件后会再复制一份写到 shadowSink，
+// 比如新的 JSON 文件或网络收集器，用于在切换之前验证新的日志管道。
+// 影子输出的错误只计数（参见 GetShadowErrors），不会影响主日志文件的写入及其返回值。
+func WithShadowSink(shadowSink io.Writer) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.shadowSink = shadowSink
+    })
+}
+
+// 取得影子输出的写失败次数
+func (this *SimLogger) GetShadowErrors() int64 {
+    return atomic.LoadInt64(&this.numShadowErrors)
+}
+
+// 写影子输出，错误仅计数
+func (this *SimLogger) writeShadow(logLines string) {
+    defer func() {
+        if err := recover(); err != nil {
+            atomic.AddInt64(&this.numShadowErrors, 1)
+        }
+    }()
+
+    if this.opts.shadowSink != nil {
+        if _, err := io.WriteString(this.opts.shadowSink, logLines); err != nil {
+            atomic.AddInt64(&this.numShadowErrors, 1)
+        }
+    }
+}
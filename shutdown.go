@@ -0,0 +1,32 @@
+package simlog
+
+import (
+    "sync/atomic"
+)
+
+// WithShutdownLevel 异步写时，关闭日志（排空日志队列）期间丢弃队列中比 level 更详细的日志（如为 LL_INFO 时丢弃 DEBUG、DETAIL 和 TRACE 日志），
+// 以限制积压了大量日志时的关闭耗时，同时保留事后分析需要的日志：NOTICE 及以上级别和裸日志总是被写入（level 比 LL_NOTICE 更严重时按 LL_NOTICE）。
+// 被丢弃的条数见 CloseWithProgress 返回的 DrainStats 的 Throttled（不计入 Dropped）。默认不丢弃。
+func WithShutdownLevel(level LogLevel) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        if level < LL_NOTICE {
+            level = LL_NOTICE
+        }
+        o.shutdownThrottle = true
+        o.shutdownLevel = level
+    })
+}
+
+// 写协程是否因关闭时的 WithShutdownLevel 丢弃这条日志
+func (this *SimLogger) throttledOnClose(level LogLevel) bool {
+    return this.opts.shutdownThrottle && level > this.opts.shutdownLevel && level != LL_RAW &&
+        atomic.LoadInt32(&this.closed) == 1
+}
+
+// 写协程因关闭时的 WithShutdownLevel 丢弃了 n 条日志
+func (this *SimLogger) addThrottled(n int) {
+    if n > 0 {
+        atomic.AddInt64(&this.numThrottled, int64(n))
+        this.addProcessed(n)
+    }
+}
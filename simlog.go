@@ -12,16 +12,23 @@
 //
 // 注意：
 // 1）默认不记录源代码文件名和行号，因为记录源代码文件和行号可能影响性能，如需要可调用EnableLogCaller(true)打开
-// 2）日志时间记录到微秒
+// 2）日志时间默认记录到微秒，可通过WithTimePrecision改为毫秒或纳秒
 // 3）如果有再包装，则应设置好skip值，设置方法参考skip成员的说明，不然记录的源代码文件名和行号将不正确
 package simlog
 
+//go:generate go run gen_levels.go
+
 import (
+    "bufio"
+    "errors"
     "fmt"
+    "io"
     "os"
     "path/filepath"
     "runtime"
     "strconv"
+    "strings"
+    "sync"
     "sync/atomic"
     "time"
 )
@@ -29,62 +36,229 @@ import (
     "github.com/gofrs/flock"
 )
 
-// LogLevel 日志级别（Log Level）
+// LogLevel 日志级别（Log Level），LL_XXX 常量由 gen_levels.go 生成（见 level_methods.go）
 type LogLevel int
 
-// 调用函数 GetLogLevelName，可取得对应级别的字符串值
-const (
-    LL_FATAL   LogLevel = 0
-    LL_ERROR   LogLevel = 1
-    LL_WARNING LogLevel = 2
-    LL_NOTICE  LogLevel = 3
-    LL_INFO    LogLevel = 4 // 默认日志级别
-    LL_DEBUG   LogLevel = 5
-    LL_DETAIL  LogLevel = 6 // 比DEBUG更详细的级别
-    LL_TRACE   LogLevel = 7 // 跟踪日志，独立的日志级别
-    LL_RAW     LogLevel = 8 // 裸日志
-)
-
 type LogOption interface {
     apply(*logOptions)
 }
 
 type logOptions struct {
-    lockOSThread   bool   // 是否独占线程
-    asyncWrite     bool   // 是否异步写
-    logQueueSize   int32  // 日志队列大小（asyncWrite为true时有效）
-    batchNumber    int32  // 异步写时的一次批量数（asyncWrite为true时有效）
-    logCaller      int32  // 是否记录调用者（在go中取源代码文件名和行号有性能影响，所以默认是关闭的）
-    printScreen    int32  // 是否屏幕打印（默认为false）
-    enableTraceLog int32  // 是否开启跟踪日志，不能通过logLevel来控制跟踪日志
-    enableLineFeed int32  // 是否自动换行（默认为false，即不自动换行）
-    enableRawLog   int32  // 是否允许裸日志
-    rawLogWithTime int32  // 裸日志是否带日期时间头
-    logLevel       int32  // 日志级别（默认为LL_INFO）
-    logFileSize    int64  // 单个日志文件大小（参考值，实际可能超出，默认为100M）
-    logNumBackups  int32  // 日志文件备份数（默认为包括当前的在内的共10个）
-    logFilename    string // 日志文件名（不包含目录部分）
-    logDir         string // 日志目录（不包含文件名部分）、
-    subSuffix      string // 日志文件名子后缀：filename-SUBSUFFIX.log，默认为空表示无子后缀
-    subPrefix      string // 日志文件名子前缀：SUBPREFIX-filename.log，默认为空表示无子后缀
-    tag            string // 默认为空，如果不为空，则会作为日志头的一部分，比如可为一个 IP 地址，用来标识日志源于哪
-    skip           int32  // 源代码所在跳（默认为3，但如果有对SimLogger包装调用，则包装一层应当设置为4，包装两层设置为5，依次类推）
-    logObserver    LogObserver
+    lockOSThread          bool                // 是否独占线程
+    asyncWrite            bool                // 是否异步写
+    lazyFileOpen          bool                // 是否延迟到第一次实际写时才创建日志文件（默认为false）
+    noFileOutput          bool                // 是否不写日志文件（默认为false，即写日志文件）
+    writeBufferSize       int32               // 写缓冲大小（默认为0，表示不缓冲）
+    flushInterval         time.Duration       // 同步写且有写缓冲时定时写出缓冲的间隔（默认为1秒）
+    logQueueSize          int32               // 日志队列大小（asyncWrite为true时有效，向上取整为2的幂）
+    batchNumber           int32               // 异步写时的一次批量数（asyncWrite为true时有效）
+    logCaller             int32               // 是否记录调用者（在go中取源代码文件名和行号有性能影响，所以默认是关闭的）
+    callerMinLevel        int32               // 记录调用者的最低级别（默认为LL_TRACE，即所有级别均记录）
+    callerTrimPrefix      string              // 日志头中的调用者文件名要去掉的前缀（默认为空，表示只输出文件名）
+    syslogPriority        bool                // 是否在行首加 syslog 风格的优先级（默认为false）
+    syslogFacility        SyslogFacility      // syslog 的设施
+    compressThreshold     int                 // 日志体超过多少字节时压缩（默认为0，表示不压缩）
+    bodyCodecName         string              // 压缩日志体的编解码器名，为空表示 zstd，见 WithBodyCodec
+    bodyCodec             Codec               // 压缩日志体的编解码器
+    networkCodec          Codec               // 网络输出编码日志的编解码器，见 WithNetworkCodec
+    backupCodecName       string              // 压缩备份文件的编解码器名，为空表示不压缩，见 WithBackupCodec
+    backupCodec           Codec               // 压缩备份文件的编解码器
+    denyKeys              map[string]struct{} // 敏感键（小写），其值输出为“***”
+    kvExtraction          int32               // 是否从日志体中提取键值对作为结构化字段（默认为false）
+    labelSamplings        []labelSampling     // 按标签采样的规则
+    labelRateLimits       []*labelRateLimit   // 按标签限流的规则
+    strictErrors          bool                // 异步写时写日志的函数是否等待并返回实际的写结果（默认为false）
+    printScreen           int32               // 是否屏幕打印（默认为false）
+    screenColor           int32               // 打屏时是否按日志级别着色（默认为false）
+    enableTraceLog        int32               // 是否开启跟踪日志，不能通过logLevel来控制跟踪日志
+    enableLineFeed        int32               // 是否自动换行（默认为false，即不自动换行）
+    enableRawLog          int32               // 是否允许裸日志
+    rawLogWithTime        int32               // 裸日志是否带日期时间头
+    enableChecksum        int32               // 是否在行尾追加校验和（默认为false）
+    timePrecision         int32               // 日志时间秒以下的精度（默认为TimeMicro）
+    timeZoneFormat        int32               // 日志时间中时区的输出方式（默认为TimeZoneNone）
+    enableElapsedTime     int32               // 是否在日志头中输出自Init起经过的单调时长（默认为false）
+    levelNumber           int32               // 是否在日志头的级别名之后输出级别的数值（默认为false）
+    format                Format              // 日志行的输出格式（默认为FormatText）
+    persistPath           string              // 保存运行时设置的状态文件（默认为空，表示不保存）
+    spoolDir              string              // 网络输出失败时的落盘目录（默认为空，表示不落盘）
+    spoolMaxAge           time.Duration       // 落盘文件的最长保留时长（默认为0，表示不限）
+    spoolMaxSize          int64               // 落盘的最大总字节数（默认为0，表示不限）
+    readOnlyFallback      io.Writer           // 文件系统只读时的降级输出（默认为nil，表示标准错误）
+    readOnlyProbeInterval time.Duration       // 只读降级时探测日志文件的间隔（默认为0，表示10秒）
+    minRotateInterval     time.Duration       // 两次滚动的最小间隔（默认为0，表示不限）
+    clockSkewThreshold    int64               // 墙上时钟回退检测阈值（默认为0，表示不检测）
+    sanitizeMode          int32               // 日志体中控制字符的处理方式（默认为SanitizeNone）
+    maxBodyLength         int32               // 日志体最大字节数（默认为0，表示不限制）
+    logLevel              int32               // 日志级别（默认为LL_INFO）
+    fatalPolicy           int32               // 记录致命错误日志后的处理策略（默认为FatalExit）
+    fatalExitCode         int32               // 策略为FatalExit时的进程退出码（默认为1）
+    logFileSize           int64               // 单个日志文件大小（参考值，实际可能超出，默认为100M）
+    strictFilesize        bool                // 是否严格限制日志文件大小，即写入前预判并先滚动（默认为false）
+    logNumBackups         int32               // 日志文件备份数（默认为包括当前的在内的共10个）
+    logFilename           string              // 日志文件名（不包含目录部分）
+    logDir                string              // 日志目录（不包含文件名部分）、
+    subSuffix             string              // 日志文件名子后缀：filename-SUBSUFFIX.log，默认为空表示无子后缀
+    subPrefix             string              // 日志文件名子前缀：SUBPREFIX-filename.log，默认为空表示无子后缀
+    tag                   string              // 默认为空，如果不为空，则会作为日志头的一部分，比如可为一个 IP 地址，用来标识日志源于哪
+    tags                  []string            // 多个标签（默认为空），跟在 tag 之后，每个标签各占一对方括号
+    serviceName           string              // 服务名（默认为空），不为空时作为日志头的一部分，和 tag 区分开，便于多个程序的日志汇入同一索引后按服务过滤
+    serviceVersion        string              // 服务版本（默认为空），仅在 serviceName 不为空时有效
+    skip                  int32               // 源代码所在跳（默认为3，但如果有对SimLogger包装调用，则包装一层应当设置为4，包装两层设置为5，依次类推）
+    logObserver           LogObserver
+    writeCallback         WriteCallback
+    shadowSink            io.Writer                // 影子输出（双写），为nil表示不双写
+    screenWriter          io.Writer                // 日志打屏的输出，为nil表示标准输出
+    writeTimeout          time.Duration            // 远端输出每批日志的写超时时长（默认为0，表示不设超时）
+    backend               *Backend                 // 共享的写日志后端（默认为nil，表示使用自己的日志队列和写协程）
+    noRegistry            bool                     // 是否不登记到全局登记处（默认为false，即由 CloseAll 关闭）
+    coalesce              bool                     // 是否合并进程内写同一个日志文件的写（默认为false）
+    levelSniffing         bool                     // 是否从 Write 写入的内容中识别级别前缀（默认为false）
+    entryObservers        []observerSpec           // 结构化的日志观察者，见 WithEntryObserver
+    writeTime             bool                     // 异步写时是否同时记录写入时间（默认为false）
+    indexEvery            int                      // 滚动时为备份文件建立索引的间隔条数（默认为0，表示不建立）
+    inheritedControl      bool                     // 是否接受父进程经 SIMLOG_CONTROL_FD 传来的日志控制（默认为false）
+    formatChecks          bool                     // 是否检查 Xf 系列的格式串和参数是否匹配（默认为false）
+    cpuBudget             time.Duration            // 每秒在写日志中花费的时间的上限，超过时自适应限流，为0表示不限流
+    sinks                 []Sink                   // 日志文件之外的输出，见 WithSink
+    labelRoutes           []labelRoute             // 按标签路由的规则
+    manifest              bool                     // 是否在日志目录中维护备份文件的清单（默认为false）
+    optionErr             error                    // 选项的错误（如 WithProfile 的配置不存在），由 validate 返回
+    errorHandler          ErrorHandler             // 内部失败的处理函数，见 WithErrorHandler
+    prealloc              bool                     // 是否在 Init 时预分配写日志用的内存（默认为false）
+    metricsFile           string                   // 指标文件名，为空表示指标写到日志文件，见 WithMetricsFile
+    lifecycleFile         string                   // 生命周期事件文件，为空表示不记录，见 WithLifecycleFile
+    clusterStatsInterval  time.Duration            // 写共享统计文件的间隔，为0表示不写，见 WithClusterStats
+    logDirCandidates      []string                 // 按顺序查找的候选日志目录，见 WithLogDirCandidates
+    logDirReresolve       time.Duration            // 重新查找日志目录的间隔，为0表示只在 Init 时查找
+    lockDir               string                   // 滚动时的文件锁所在的目录，为空表示和日志文件在同一目录
+    noRotationLock        bool                     // 滚动时是否不加文件锁（只有一个进程写日志文件时）
+    dateSubdirs           bool                     // 是否按日期分子目录存放日志文件（默认为false）
+    openRetryMin          time.Duration            // 异步写时打开日志文件失败的首次重试间隔（默认为100毫秒，0表示不重试）
+    openRetryMax          time.Duration            // 异步写时打开日志文件失败的最大重试间隔（默认为10秒）
+    retroactiveFilter     bool                     // 降低日志级别时是否清除日志队列中尚未写的更低级别的日志（默认为false）
+    shutdownThrottle      bool                     // 关闭时是否丢弃日志队列中比 shutdownLevel 更详细的日志（默认为false）
+    shutdownLevel         LogLevel                 // 关闭时写入的最详细的日志级别，见 WithShutdownLevel
+    healthMaxDropped      int64                    // Healthy 允许的两次检查之间丢弃的日志行数（默认为0，小于0表示不限制）
+    healthMaxShadowErrors int64                    // Healthy 允许的两次检查之间影子输出写失败次数（默认为0，小于0表示不限制）
+    digestInterval        time.Duration            // 汇总WARNING和ERROR日志并输出摘要的周期（默认为0，表示不汇总）
+    callerStats           bool                     // 是否按调用者统计输出的日志（默认为false）
+    callerStatsInterval   time.Duration            // 定时输出调用者统计的间隔（默认为0，表示不输出）
+    debugBufferSize       int                      // 内存中保留的调试日志的最大字节数（默认为0，表示不保留）
+    messageTemplates      []messageTemplateMatcher // 注册的消息模板，用于得到消息的键
+    messageKeyFunc        MessageKeyFunc           // 提取消息键的函数（默认为nil，表示使用默认规则）
 }
 
 // SimLogger 简单日志
 // 使用之前，应先调用SimLogger的Init进行初始化
 // logCaller和printScreen等类型使用int32而不是bool，
 // 是为方便原子修改值，比如实时安全地调整日志级别。
+// WithCode 等返回的子日志和父日志共用同一个 loggerCore（选项、队列和日志文件等），
+// 只是附加的日志头字段不同。loggerCore 由 Init 创建，为兼容，Init 之前调用原有的成员不会 panic：
+// 写的日志被丢弃，IsEnabledXXXLog 等返回 false，GetLogLevel 等返回0，SetLogLevel 等不起作用（Init 时按 opts 设置），
+// Close 什么也不做；新增的其它成员须在 Init 之后调用。
 type SimLogger struct {
-    opts     logOptions
-    logQueue chan string // 日志队列
-    logExit  chan int    // 写协程退出信号
+    *loggerCore
+    code       string                 // 错误码（事件ID），不为空时作为日志头的一部分，见 WithCode
+    labels     map[string]string      // 标签，不输出，只用于路由、采样和限流决策，见 WithLabels
+    fields     map[string]interface{} // 结构化字段，见 WithFields
+    fieldsText string                 // 文本格式时加在日志体之前的字段
+}
+
+// 日志的共享部分，由 Init 创建
+type loggerCore struct {
+    numWritten         int64                     // 已写入的日志行数（放在最前面，以保证在32位平台上原子操作时的8字节对齐）
+    numDropped         int64                     // 丢弃（写失败或关闭后写入）的日志行数
+    numShadowErrors    int64                     // 影子输出写失败次数
+    numQueued          int64                     // 放入日志队列的日志条数
+    numProcessed       int64                     // 写协程已处理（写入或丢弃）的日志条数
+    numWriterRestarts  int64                     // 写协程重启次数
+    healthDropped      int64                     // 上次 Healthy 时的 numDropped
+    healthShadowErrors int64                     // 上次 Healthy 时的 numShadowErrors
+    numPurged          int64                     // 因 WithRetroactiveFilter 从日志队列中清除的日志条数
+    numThrottled       int64                     // 因 WithShutdownLevel 在关闭时丢弃的日志条数
+    numFlushed         int64                     // 已写出写缓冲的日志条数（按 numProcessed 计），见 Barrier
+    numFallback        int64                     // 因文件系统只读而写到降级输出的日志行数
+    lastRotate         int64                     // 本进程上次滚动日志文件的时间（纳秒），见 WithMinRotateInterval
+    readOnlyProbe      int64                     // 只读降级时下次探测日志文件的时间（纳秒），为0表示未降级
+    numGoverned        int64                     // 因 WithCPUBudget 限流而未输出的日志条数
+    numSinkErrors      int64                     // WithSink 的输出写或关闭失败次数
+    nextLogDirResolve  int64                     // 下次重新查找日志目录的时间（纳秒），见 WithLogDirReresolve
+    lastSinkEvent      int64                     // 上次记录输出失败的生命周期事件的时间（纳秒）
+    numRotations       int64                     // 本进程滚动日志文件的次数
+    governorSpent      int64                     // 当前统计周期内在写日志中花费的时间（纳秒）
+    governorWindow     int64                     // 当前统计周期的开始时间（纳秒）
+    closed             int32                     // 是否已关闭
+    governorStep       int32                     // 限流档位，为0表示未限流
+    startTime          time.Time                 // Init的时间，用于计算单调时长
+    clockMutex         sync.Mutex                // 保护lastLogTime
+    lastLogTime        time.Time                 // 上一条日志的时间，用于检测时钟回退
+    syncMutex          sync.Mutex                // 保护syncFile
+    syncFile           *logFile                  // 同步写且有写缓冲时保持打开的日志文件
+    flusherExit        chan struct{}             // 通知定时写出缓冲的协程退出
+    flusherDone        chan struct{}             // 定时写出缓冲的协程已退出
+    digestMutex        sync.Mutex                // 保护digestCounts
+    digestCounts       map[digestKey]int64       // 当前周期内各消息模板的条数
+    digestExit         chan struct{}             // 通知定时输出摘要的协程退出
+    digestDone         chan struct{}             // 定时输出摘要的协程已退出
+    debugBuffer        *debugBuffer              // 内存中的调试日志，见 WithDebugBuffer
+    metrics            *SimLogger                // 写指标文件的日志，见 WithMetricsFile
+    clusterStatsExit   chan struct{}             // 通知定时写共享统计文件的协程退出
+    clusterStatsDone   chan struct{}             // 定时写共享统计文件的协程已退出
+    callerStatsMutex   sync.Mutex                // 保护callerStats
+    callerStats        map[callerKey]*CallerStat // 各调用者输出的日志统计，见 WithCallerStats
+    callerStatsExit    chan struct{}             // 通知定时输出调用者统计的协程退出
+    callerStatsDone    chan struct{}             // 定时输出调用者统计的协程已退出
+    backendDetached    bool                      // 是否已从共享后端摘下，只在后端的写协程中访问
+    opts               logOptions
+    logQueue           *ringQueue[queuedLog] // 日志队列
+    logExit            chan struct{}         // 写协程退出信号
+    writerState        int32                 // 写协程状态，见 writerRunning 等
+    writerError        error                 // 写协程最近一次的错误，由 progressMutex 保护
+    progressMutex      sync.Mutex            // 保护writerError，并和 progressCond 一起用于 Flush 等待
+    progressCond       *sync.Cond            // 写协程处理了一批日志、写出了缓冲或状态变化时广播
+    flushWaiters       int32                 // 正在等待的 Barrier 个数，不为0时写协程每批都写出缓冲
+    batchError         error                 // 写协程正在写的这批日志的第一个写错误，只在写协程中访问
+    pauseMutex         sync.RWMutex          // 写日志文件时持读锁，SnapshotTo 持写锁以暂停写日志文件
+    persistMutex       sync.Mutex            // 串行化保存运行时设置，见 WithPersistRuntimeSettings
+    spool              *spoolWriter          // 影子输出的落盘，见 WithSpoolDir
+    baseFilepath       string                // 不带日期子目录的日志文件的绝对路径，见 getBaseFilepath
+    resolvedLogDir     atomic.Value          // 当前的日志目录（string），见 getLogDir
+    writeBuffer        *bufio.Writer         // 预分配的写缓冲，各次打开的日志文件共用，见 WithPrealloc
+    batchBuffer        []string              // 预分配的一批日志的切片，只在写协程中使用，见 WithPrealloc
+    observers          []*entryObserver      // 运行中的结构化观察者，见 WithEntryObserver
+}
+
+// DrainStats 关闭时排空日志队列的统计
+type DrainStats struct {
+    Flushed   int64         // 排空期间写入的日志行数
+    Dropped   int64         // 排空期间丢弃的日志行数
+    Throttled int64         // 排空期间因 WithShutdownLevel 丢弃的日志条数
+    Duration  time.Duration // 排空耗时
+    Err       error         // 不为 nil 表示写协程有异常或有日志被丢弃
+}
+
+// DrainProgress 排空进度回调，remaining 为日志队列中剩余的日志条数
+type DrainProgress func(remaining int)
+
+// 排空进度回调间隔
+const drainProgressInterval = 100 * time.Millisecond
+
+// WriteCallback 写结果回调，每次实际写日志文件后被调用，
+// lines 为本次写的日志行数，bytes 为实际写入的字节数，err 不为 nil 表示写失败（包括打开日志文件失败）。
+// 异步写时在写协程中被调用，同步写时在写日志的协程中被调用，因此应尽快返回。
+type WriteCallback func(lines, bytes int, err error)
+
+func WithWriteCallback(writeCallback WriteCallback) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.writeCallback = writeCallback
+    })
 }
 
 // LogObserver 日志观察者，通过设置 LogObserver 可截获日志，比如将截获的日志写入到 Kafka 等
 type LogObserver func(logLevel LogLevel, logHeader string, logBody string)
 
+// WithLogObserver 设置日志观察者，在写日志的协程中同步调用，需要异步投递、重试或失败统计时见 WithEntryObserver
 func WithLogObserver(logObserver LogObserver) LogOption {
     return newFuncLogOption(func(o *logOptions) {
         o.logObserver = logObserver
@@ -97,12 +271,29 @@ func EnableLockOSThread(enabled bool) LogOption {
     })
 }
 
+// WithLazyFileOpen 为 true 时日志文件延迟到第一次实际写日志时才打开（创建），
+// 而不是在写协程启动时，这样不输出日志的短命令行程序不会在日志目录留下空文件。
+func WithLazyFileOpen(lazy bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.lazyFileOpen = lazy
+    })
+}
+
 func EnableAsyncWrite(enabled bool) LogOption {
     return newFuncLogOption(func(o *logOptions) {
         o.asyncWrite = enabled
     })
 }
 
+// WithRetroactiveFilter 异步写时，运行中降低日志级别（如 SetLogLevel(LL_INFO)）或关闭跟踪日志后，
+// 日志队列中尚未写的、新级别下不再输出的日志也被清除（不写入日志文件），以立即止住调试日志的洪流，
+// 而不必等待积压的日志写完。被清除的日志条数见 Stats 的 Purged。
+func WithRetroactiveFilter(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.retroactiveFilter = enabled
+    })
+}
+
 func WithLogQueueSize(logQueueSize int32) LogOption {
     return newFuncLogOption(func(o *logOptions) {
         if logQueueSize < 0 {
@@ -125,6 +316,27 @@ func WithTag(tag string) LogOption {
     })
 }
 
+// WithService 设置服务名和版本，在日志头中输出为“[svc:NAME@VERSION]”，
+// 版本为空时输出为“[svc:NAME]”，name 为空时取程序文件名。
+func WithService(name, version string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        if name == "" {
+            name = filepath.Base(os.Args[0])
+        }
+        o.serviceName = name
+        o.serviceVersion = version
+    })
+}
+
+// WithTags 设置多个标签，比如 WithTags("ip:10.0.0.1", "dc:sz", "pool:api")，
+// 在日志头中跟在 WithTag 设置的标签之后，每个标签各占一对方括号：“[ip:10.0.0.1][dc:sz][pool:api]”，
+// 在 JSON 等结构化格式中输出为数组。
+func WithTags(tags ...string) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.tags = append([]string(nil), tags...)
+    })
+}
+
 func WithLogdir(logdir string) LogOption {
     return newFuncLogOption(func(o *logOptions) {
         o.logDir = logdir
@@ -149,12 +361,33 @@ func WithSubSuffix(subSuffix string) LogOption {
     })
 }
 
+// EnableStrictFilesize 是否严格限制日志文件大小（默认为 false，即日志文件大小只是参考值，实际可能超出），
+// 开启后每次写入前判断，写入后将超过日志文件大小时先滚动，再写入新文件，
+// 以便下游系统可以拒绝超过硬限制的文件；单条（或一批）日志本身超过日志文件大小时仍会超出。
+func EnableStrictFilesize(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.strictFilesize = enabled
+    })
+}
+
 func WithFilesize(filesize int64) LogOption {
     return newFuncLogOption(func(o *logOptions) {
         o.logFileSize = filesize
     })
 }
 
+// WithOpenRetry 设置异步写时打开日志文件失败后的重试间隔，从 minBackoff 开始每次翻倍，最大为 maxBackoff，
+// 重试期间日志暂存在日志队列中，关闭日志时放弃重试。minBackoff 小于等于0表示不重试（写协程退出，同旧版本）。
+func WithOpenRetry(minBackoff, maxBackoff time.Duration) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.openRetryMin = minBackoff
+        o.openRetryMax = maxBackoff
+        if o.openRetryMax < minBackoff {
+            o.openRetryMax = minBackoff
+        }
+    })
+}
+
 func WithBatchNumber(batchNumber int32) LogOption {
     return newFuncLogOption(func(o *logOptions) {
         o.batchNumber = batchNumber
@@ -171,6 +404,14 @@ func EnableLogCaller(enabled bool) LogOption {
     })
 }
 
+// WithCallerMinLevel 设置记录调用者的最低级别，比如为 LL_WARNING 时只有 WARNING 及以上级别才取调用者，
+// INFO 和 DEBUG 等不取，以较小的代价获得大部分调试价值，需同时开启 EnableLogCaller 才有效。
+func WithCallerMinLevel(logLevel LogLevel) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        atomic.StoreInt32(&o.callerMinLevel, int32(logLevel))
+    })
+}
+
 func EnableLineFeed(enabled bool) LogOption {
     return newFuncLogOption(func(o *logOptions) {
         if enabled {
@@ -191,6 +432,29 @@ func EnableTraceLog(enabled bool) LogOption {
     })
 }
 
+// WithLogLevel 设置日志级别（默认为LL_INFO），运行时可用 SetLogLevel 修改
+func WithLogLevel(logLevel LogLevel) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        atomic.StoreInt32(&o.logLevel, int32(logLevel))
+    })
+}
+
+// WithScreenWriter 设置日志打屏的输出（默认为标准输出），比如 os.Stderr
+func WithScreenWriter(screenWriter io.Writer) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.screenWriter = screenWriter
+    })
+}
+
+// EnableFileOutput 为 false 时不写日志文件（默认为 true），
+// 日志只打屏（需开启 EnablePrintScreen）和写影子输出，此时异步写不生效。
+// WASM（GOOS=js 或 wasip1）下总是不写日志文件并打屏。
+func EnableFileOutput(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.noFileOutput = !enabled
+    })
+}
+
 func EnablePrintScreen(enabled bool) LogOption {
     return newFuncLogOption(func(o *logOptions) {
         if enabled {
@@ -221,64 +485,307 @@ func EnableRawLogTime(enabled bool) LogOption {
     })
 }
 
-func (this *SimLogger) Close() {
+// Close 关闭日志，写完队列中的日志，
+// 写协程曾异常退出、关闭时未在运行或有日志在排空期间被丢弃时返回错误。
+func (this *SimLogger) Close() error {
+    return this.CloseWithProgress(nil).Err
+}
+
+// CloseWithProgress 关闭日志，在排空日志队列期间每100毫秒回调一次 progress 报告剩余条数（progress 可为 nil），
+// 返回排空统计，以便编排系统据此决定是否延长优雅退出时间。
+// 同步写模式下没有日志队列，返回的统计值均为0。
+func (this *SimLogger) CloseWithProgress(progress DrainProgress) DrainStats {
+    var stats DrainStats
+
+    this.checkInit()
+    if this.loggerCore == nil || !atomic.CompareAndSwapInt32(&this.closed, 0, 1) {
+        return stats // 未 Init 或重复关闭
+    }
+    if !this.opts.noRegistry {
+        unregisterLogger(this)
+    }
+    if this.opts.inheritedControl {
+        detachInheritedControl(this)
+    }
+    if this.opts.digestInterval > 0 {
+        this.stopDigest() // 最后一个周期的摘要需在关闭日志队列之前输出
+    }
+    if this.opts.callerStats {
+        this.stopCallerStats()
+    }
     if this.opts.asyncWrite {
-        close(this.logQueue)
-        <-this.logExit
-        close(this.logExit)
+        start := time.Now()
+        numWritten := atomic.LoadInt64(&this.numWritten)
+        numDropped := atomic.LoadInt64(&this.numDropped)
+        numThrottled := atomic.LoadInt64(&this.numThrottled)
+        ticker := time.NewTicker(drainProgressInterval)
+        defer ticker.Stop()
+
+        var exited <-chan struct{}
+        if this.opts.backend != nil {
+            exited = this.opts.backend.detach(this)
+        } else {
+            this.logQueue.close()
+            exited = this.logExit
+        }
+    drain:
+        for {
+            select {
+            case <-exited:
+                break drain
+            case <-ticker.C:
+                if progress != nil {
+                    progress(this.queueLen())
+                }
+            }
+        }
+        if this.opts.backend != nil {
+            this.opts.backend.unref()
+            if this.opts.coalesce {
+                detachCoalescer(this)
+            }
+        }
+
+        // 写协程异常退出时，队列中剩余的均被丢弃
+        atomic.AddInt64(&this.numDropped, int64(this.queueLen()))
+        stats.Flushed = atomic.LoadInt64(&this.numWritten) - numWritten
+        stats.Dropped = atomic.LoadInt64(&this.numDropped) - numDropped
+        stats.Throttled = atomic.LoadInt64(&this.numThrottled) - numThrottled
+        stats.Duration = time.Since(start)
+        if err := this.getWriterError(); err != nil {
+            stats.Err = err
+        } else if stats.Dropped > 0 {
+            stats.Err = fmt.Errorf("simlog: %d log lines dropped while closing", stats.Dropped)
+        }
+    } else if this.opts.writeBufferSize > 0 {
+        this.stopBufferFlusher()
+    }
+    if shadowSink, ok := this.opts.shadowSink.(*deadlineWriter); ok {
+        if shadowSink.flushPending() != nil {
+            atomic.AddInt64(&this.numShadowErrors, 1)
+        }
+    }
+    if this.spool != nil {
+        this.spool.close()
+    }
+    if len(this.observers) > 0 {
+        this.stopObservers()
+    }
+    if len(this.opts.sinks) > 0 || len(this.opts.labelRoutes) > 0 {
+        this.closeSinks()
+    }
+    if this.metrics != nil {
+        this.metrics.Close()
+    }
+    if this.clusterStatsExit != nil {
+        this.stopClusterStats()
     }
+    if this.opts.lifecycleFile != "" {
+        written := atomic.LoadInt64(&this.numWritten)
+        dropped := atomic.LoadInt64(&this.numDropped)
+        if dropped > 0 {
+            this.recordLifecycle(LifecycleEvent{Event: LifecycleDropped, Dropped: dropped})
+        }
+        this.recordLifecycle(LifecycleEvent{Event: LifecycleClosed, Written: written, Dropped: dropped})
+    }
+    return stats
 }
 
 // Init应在SimLogger所有其它成员被调用之前调用，
 // SetSubSuffix成员除外，SetSubSuffix只有在Init之前调用才有效。
+// 失败时返回false，需要知道失败原因时使用 InitE。
 func (this *SimLogger) Init(opts ...LogOption) bool {
+    return this.InitE(opts...) == nil
+}
+
+// InitE 同 Init，失败时返回错误，如日志文件名的前后缀含路径分隔符或控制字符、共享后端已关闭等
+func (this *SimLogger) InitE(opts ...LogOption) error {
+    this.checkReinit()
+    this.loggerCore = new(loggerCore)
+    this.code = ""
+    this.labels = nil
+    this.fields = nil
+    this.fieldsText = ""
     this.opts = defaultLogOptions()
+    atomic.StoreInt32(&this.closed, 0)
+    this.startTime = time.Now()
+    this.governorWindow = this.startTime.UnixNano()
 
     for _, opt := range opts {
         opt.apply(&this.opts)
     }
+    if this.opts.tag != "" {
+        this.opts.tags = append([]string{this.opts.tag}, this.opts.tags...)
+    }
     if this.opts.logFilename == "" {
         this.opts.logFilename = GetLogFilename(this.opts.subPrefix, this.opts.subSuffix)
     }
-    if this.opts.asyncWrite {
+    if err := this.opts.validate(); err != nil {
+        return err
+    }
+    this.initLogDir()
+    if this.opts.lockDir != "" && !this.opts.noRotationLock {
+        if err := os.MkdirAll(this.opts.lockDir, 0755); err != nil {
+            return err
+        }
+    }
+    this.baseFilepath = this.getBaseFilepath()
+    if this.opts.metricsFile != "" && !this.opts.noFileOutput {
+        if err := this.openMetrics(); err != nil {
+            return err
+        }
+    }
+    if this.opts.persistPath != "" {
+        this.restoreRuntimeSettings()
+    }
+    if this.opts.shadowSink != nil && this.opts.spoolDir != "" {
+        spool, err := newSpoolWriter(this.opts.shadowSink, this.opts.spoolDir, this.opts.spoolMaxAge, this.opts.spoolMaxSize)
+        if err != nil {
+            return err
+        }
+        this.spool = spool
+        this.opts.shadowSink = spool
+    }
+    if this.opts.shadowSink != nil && this.opts.writeTimeout > 0 {
+        this.opts.shadowSink = newDeadlineWriter(this.opts.shadowSink, this.opts.writeTimeout)
+    }
+    if consoleOnly {
+        // 没有可用的文件系统，退化为打屏
+        this.opts.noFileOutput = true
+        atomic.StoreInt32(&this.opts.printScreen, 1)
+    }
+    if this.opts.noFileOutput {
+        this.opts.asyncWrite = false
+        this.opts.writeBufferSize = 0
+        this.opts.backend = nil
+        this.opts.coalesce = false
+    }
+    if this.opts.backend != nil {
+        this.opts.coalesce = false
+    }
+    if this.opts.backend != nil || this.opts.coalesce {
+        this.opts.asyncWrite = true
+    }
+    if this.opts.prealloc {
+        this.preallocate()
+    }
+    if !this.opts.asyncWrite && this.opts.writeBufferSize > 0 {
+        this.startBufferFlusher()
+    }
+    this.progressCond = sync.NewCond(&this.progressMutex)
+    if this.opts.coalesce {
+        this.opts.backend = attachCoalescer(this)
+    } else if this.opts.backend != nil {
+        if !this.opts.backend.attach(this) {
+            return errors.New("simlog: backend is closed")
+        }
+    } else if this.opts.asyncWrite {
         logQueueSize := 1
         if this.opts.logQueueSize > 0 {
             logQueueSize = int(this.opts.logQueueSize)
         }
-        this.logExit = make(chan int)
-        this.logQueue = make(chan string, logQueueSize)
+        this.logExit = make(chan struct{})
+        this.logQueue = newRingQueue[queuedLog](logQueueSize)
         go this.writeLogCoroutine()
     }
-    return true
+    if this.opts.digestInterval > 0 {
+        this.startDigest()
+    }
+    if this.opts.callerStats {
+        this.startCallerStats()
+    }
+    if this.opts.clusterStatsInterval > 0 && !this.opts.noFileOutput {
+        this.startClusterStats()
+    }
+    if len(this.opts.entryObservers) > 0 {
+        this.startObservers()
+    }
+    if this.opts.inheritedControl {
+        attachInheritedControl(this)
+    }
+    if this.opts.debugBufferSize > 0 {
+        this.debugBuffer = newDebugBuffer(this.opts.debugBufferSize)
+    }
+    if !this.opts.noRegistry {
+        registerLogger(this)
+    }
+    this.recordLifecycle(LifecycleEvent{Event: LifecycleStarted})
+    return nil
+}
+
+// WithCode 返回附带错误码（事件ID）的子日志，错误码在日志头中紧跟日志级别，如“[ERROR][code:E1234]”，
+// 以便告警按结构化的错误码而不是日志文本匹配。子日志和本日志共用选项、队列和日志文件，
+// 可用作 logger.WithCode("E1234").Errorf(...)，也可保存下来重复使用。
+func (this *SimLogger) WithCode(code string) *SimLogger {
+    child := *this
+    child.code = code
+    return &child
+}
+
+// GetCode 返回错误码，未设置时为空
+func (this *SimLogger) GetCode() string {
+    return this.code
 }
 
 // 调用者所在跳，
 // 如果直接使用SimLogger的写日志函数，则默认值3即可，
 // 否则每包一层skip值就得加一，否则将不能正确显示源代码文件名和行号。
 func (this *SimLogger) SetSkip(skip int32) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return // 未 Init，见 SimLogger
+    }
     atomic.StoreInt32(&this.opts.skip, skip)
 }
 
 func (this *SimLogger) GetSkip() int32 {
+    if this.loggerCore == nil {
+        return 0 // 未 Init，见 SimLogger
+    }
     return atomic.LoadInt32(&this.opts.skip)
 }
 
+// 取得服务名和服务版本
+func (this *SimLogger) GetService() (string, string) {
+    return this.opts.serviceName, this.opts.serviceVersion
+}
+
+// 取得记录调用者的最低级别
+func (this *SimLogger) GetCallerMinLevel() LogLevel {
+    return LogLevel(atomic.LoadInt32(&this.opts.callerMinLevel))
+}
+
+// 设置记录调用者的最低级别
+func (this *SimLogger) SetCallerMinLevel(logLevel LogLevel) {
+    this.checkInit()
+    atomic.StoreInt32(&this.opts.callerMinLevel, int32(logLevel))
+}
+
 // 是否开启了记录调用者
 func (this *SimLogger) EnabledLogCaller() bool {
-    return atomic.LoadInt32(&this.opts.logCaller) == 1
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.logCaller) == 1
 }
 
 // enabled为true表示是否记录源代码文件和行号
 func (this *SimLogger) EnableLogCaller(enabled bool) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return // 未 Init，见 SimLogger
+    }
     if enabled {
         atomic.StoreInt32(&this.opts.logCaller, 1)
     } else {
         atomic.StoreInt32(&this.opts.logCaller, 0)
     }
+    this.saveRuntimeSettings()
 }
 
 // withTime 如果为 true 则会加上日期时间头
 func (this *SimLogger) EnableRawLog(enabled, withTime bool) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return // 未 Init，见 SimLogger
+    }
     if enabled {
         atomic.StoreInt32(&this.opts.enableRawLog, 1)
     } else {
@@ -293,11 +800,15 @@ func (this *SimLogger) EnableRawLog(enabled, withTime bool) {
 
 // 是否开启了日志打屏
 func (this *SimLogger) EnabledPrintScreen() bool {
-    return atomic.LoadInt32(&this.opts.printScreen) == 1
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.printScreen) == 1
 }
 
 // enabled为true表示日志打屏
 func (this *SimLogger) EnablePrintScreen(enabled bool) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return // 未 Init，见 SimLogger
+    }
     if enabled {
         atomic.StoreInt32(&this.opts.printScreen, 1)
     } else {
@@ -307,26 +818,35 @@ func (this *SimLogger) EnablePrintScreen(enabled bool) {
 
 // 是否打开了跟踪日志
 func (this *SimLogger) EnabledTraceLog() bool {
-    return atomic.LoadInt32(&this.opts.enableTraceLog) == 1
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.enableTraceLog) == 1
 }
 
 // enabled为true表示开启跟踪日志，
 // 注意SetLogLevel不能控制跟踪日志的开启。
 func (this *SimLogger) EnableTraceLog(enabled bool) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return // 未 Init，见 SimLogger
+    }
     if enabled {
         atomic.StoreInt32(&this.opts.enableTraceLog, 1)
     } else {
         atomic.StoreInt32(&this.opts.enableTraceLog, 0)
     }
+    this.saveRuntimeSettings()
 }
 
 // 是否开启了自动换行
 func (this *SimLogger) EnabledLineFeed() bool {
-    return atomic.LoadInt32(&this.opts.enableLineFeed) == 1
+    return this.loggerCore != nil && atomic.LoadInt32(&this.opts.enableLineFeed) == 1
 }
 
 // 是否自动换行，enabled为true表示开启自动换行
 func (this *SimLogger) EnableLineFeed(enabled bool) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return // 未 Init，见 SimLogger
+    }
     if enabled {
         atomic.StoreInt32(&this.opts.enableLineFeed, 1)
     } else {
@@ -336,699 +856,813 @@ func (this *SimLogger) EnableLineFeed(enabled bool) {
 
 // 取得当前日志级别
 func (this *SimLogger) GetLogLevel() int32 {
+    if this.loggerCore == nil {
+        return 0 // 未 Init，见 SimLogger
+    }
     return atomic.LoadInt32(&this.opts.logLevel)
 }
 
 // 设置日志级别
 func (this *SimLogger) SetLogLevel(logLevel LogLevel) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return // 未 Init，见 SimLogger
+    }
     atomic.StoreInt32(&this.opts.logLevel, int32(logLevel))
+    this.saveRuntimeSettings()
 }
 
 // 取得单个日志文件大小
 func (this *SimLogger) GetLogFileSize() int64 {
+    if this.loggerCore == nil {
+        return 0 // 未 Init，见 SimLogger
+    }
     return atomic.LoadInt64(&this.opts.logFileSize)
 }
 
 // 设置单个日志文件字节数（参考值）
 func (this *SimLogger) SetLogFileSize(logFileSize int64) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return // 未 Init，见 SimLogger
+    }
     atomic.StoreInt64(&this.opts.logFileSize, logFileSize)
 }
 
 // 取得日志备份数
 func (this *SimLogger) GetNumBackups() int32 {
+    if this.loggerCore == nil {
+        return 0 // 未 Init，见 SimLogger
+    }
     return atomic.LoadInt32(&this.opts.logNumBackups)
 }
 
 // 设置日志文件备份数
 func (this *SimLogger) SetNumBackups(logNumBackups int) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return // 未 Init，见 SimLogger
+    }
     atomic.StoreInt32(&this.opts.logNumBackups, int32(logNumBackups))
 }
 
-// 写裸日志
-
-func (this *SimLogger) Raw(a ...interface{}) (int, error) {
-    return this.log(LL_RAW, "", 0, a...)
-}
-
-func (this *SimLogger) Rawln(a ...interface{}) (int, error) {
-    return this.logln(LL_RAW, "", 0, a...)
+// Enabled 是否输出 logLevel 级别的日志，跟踪日志由 EnableTraceLog 控制，裸日志总是输出。
+// 只有一次原子读和分支，可被内联到调用处，和 IsEnabledDebugLog 等一样适合在调用处先行判断，
+// 因为变参在调用前就已求值（装箱为 interface{}），所以参数计算代价大的日志应写作：
+//
+//	if logger.Enabled(simlog.LL_DEBUG) {
+//	    logger.Debugf("state: %s", dumpState())
+//	}
+//
+// 这样日志被关闭时参数一概不计算。
+func (this *SimLogger) Enabled(logLevel LogLevel) bool {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return false // 未 Init，见 SimLogger
+    }
+    switch logLevel {
+    case LL_TRACE:
+        return this.IsEnabledTraceLog()
+    case LL_RAW:
+        return true
+    default:
+        return int32(logLevel) <= atomic.LoadInt32(&this.opts.logLevel)
+    }
 }
 
-func (this *SimLogger) Rawf(format string, a ...interface{}) (int, error) {
-    return this.logf(LL_RAW, "", 0, format, a...)
+// 返回调用者，未开启记录调用者或 logLevel 低于记录调用者的最低级别时返回零值
+func (this *SimLogger) getCaller(logLevel LogLevel, skip int32) Caller {
+    var caller Caller
+    if atomic.LoadInt32(&this.opts.logCaller) == 1 && int32(logLevel) <= atomic.LoadInt32(&this.opts.callerMinLevel) {
+        caller.pc, caller.File, caller.Line, _ = runtime.Caller(int(skip))
+    }
+    return caller
 }
 
-// 写跟踪日志（Trace）
+// 组装日志行头
+func (this *SimLogger) formatLogLineHeader(entry *Entry) string {
+    if entry.Level == LL_RAW {
+        enableRawLog := atomic.LoadInt32(&this.opts.enableRawLog)
+        if enableRawLog == 1 {
+            rawLogWithTime := atomic.LoadInt32(&this.opts.rawLogWithTime)
+            if rawLogWithTime == 1 {
+                return getLogTime(entry.Time, this.GetTimePrecision(), this.GetTimeZoneFormat())
+            }
+        }
+        return ""
+    } else {
+        var service string
+        var tag string
+        var fileline string
 
-func (this *SimLogger) Trace(a ...interface{}) (int, error) {
-    return this.SkipTrace(this.opts.skip, a...)
-}
+        if entry.Service != "" {
+            if entry.Version != "" {
+                service = "[svc:" + entry.Service + "@" + entry.Version + "]"
+            } else {
+                service = "[svc:" + entry.Service + "]"
+            }
+        }
+        for _, t := range entry.Tags {
+            tag = tag + "[" + t + "]"
+        }
+        if !entry.Caller.empty() {
+            fileline = "[" + this.callerFilename(entry.Caller.File) + ":" + strconv.FormatInt(int64(entry.Caller.Line), 10) + "]"
+        }
 
-func (this *SimLogger) Traceln(a ...interface{}) (int, error) {
-    return this.SkipTraceln(this.opts.skip, a...)
+        datetime := getLogTime(entry.Time, this.GetTimePrecision(), this.GetTimeZoneFormat())
+        if entry.Elapsed > 0 {
+            datetime += formatElapsed(entry.Elapsed)
+        }
+        logLevelName := this.formatLevel(entry.Level)
+        if entry.Code != "" {
+            logLevelName += "[code:" + entry.Code + "]"
+        }
+        if this.opts.syslogPriority {
+            datetime = this.syslogPriority(entry.Level) + datetime
+        }
+        return datetime + service + tag + logLevelName + fileline
+    }
 }
 
-func (this *SimLogger) Tracef(format string, a ...interface{}) (int, error) {
-    return this.SkipTracef(this.opts.skip, format, a...)
+// 实际接口 Writer：
+// type Writer interface {
+//   Write(p []byte) (n int, err error)
+// }
+//
+// 按裸日志写，开启 EnableLevelSniffing 时识别到级别前缀的按该级别带日志头写。
+func (this *SimLogger) Write(p []byte) (int, error) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return 0, nil // 未 Init，见 SimLogger
+    }
+    this.checkOpen()
+    if this.opts.levelSniffing {
+        if n, err, ok := this.writeSniffed(p); ok {
+            return n, err
+        }
+    }
+    return this.putLog(LL_RAW, string(p))
 }
 
-// 写跟踪日志（SkipTrace）
-
-func (this *SimLogger) IsEnabledTraceLog() bool {
-    return atomic.LoadInt32(&this.opts.enableTraceLog) == 1
-}
+// 放入日志队列或直接写日志文件，logLevel 用于 WithRetroactiveFilter
+func (this *SimLogger) putLog(logLevel LogLevel, logLine string) (int, error) {
+    defer func() {
+        if err := recover(); err != nil {
+            atomic.AddInt64(&this.numDropped, 1)
+        }
+    }()
 
-func (this *SimLogger) SkipTrace(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledTraceLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.log(LL_TRACE, file, line, a...)
+    // 日志打屏
+    if atomic.LoadInt32(&this.opts.printScreen) == 1 {
+        screenLine := this.colorize(logLevel, logLine)
+        if this.opts.screenWriter != nil {
+            io.WriteString(this.opts.screenWriter, screenLine)
+        } else {
+            fmt.Print(screenLine)
+        }
+    }
+    if this.opts.noFileOutput {
+        this.writeShadow(logLine)
+        return len(logLine), nil
+    } else if this.opts.backend != nil {
+        // 先计数再放入，以便 Barrier 取得的条数包括所有已放入的（参见 Barrier）
+        queued := this.newQueuedLog(logLevel, logLine)
+        atomic.AddInt64(&this.numQueued, 1)
+        if atomic.LoadInt32(&this.closed) == 1 || !this.opts.backend.put(this, queued) {
+            return this.dropClosed(queued) // 已关闭（已从后端摘下）或后端已停止
+        }
+        return this.waitDone(queued, this.opts.backend.exit)
+    } else if this.opts.asyncWrite {
+        queued := this.newQueuedLog(logLevel, logLine)
+        atomic.AddInt64(&this.numQueued, 1)
+        if !this.logQueue.push(queued) {
+            return this.dropClosed(queued) // 已关闭
+        }
+        return this.waitDone(queued, this.logExit)
     }
-}
 
-func (this *SimLogger) SkipTraceln(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledTraceLog() {
-        return 0, nil
+    this.pauseMutex.RLock()
+    defer this.pauseMutex.RUnlock()
+    if this.inReadOnly() {
+        n, e := this.writeFallback(1, logLine)
+        this.writeShadow(logLine)
+        return n, e
+    }
+    var n int
+    var e error
+    if this.opts.writeBufferSize > 0 {
+        n, e = this.writeLogBuffered(logLine)
     } else {
-        file, line := this.getCaller(skip)
-        return this.logln(LL_TRACE, file, line, a...)
+        n, e, _ = this.writeLog(nil, logLine)
     }
-}
-
-func (this *SimLogger) SkipTracef(skip int32, format string, a ...interface{}) (int, error) {
-    if !this.IsEnabledTraceLog() {
-        return 0, nil
+    if this.enterReadOnly(e) {
+        n, e = this.writeFallback(1, logLine)
     } else {
-        file, line := this.getCaller(skip)
-        return this.logf(LL_TRACE, file, line, format, a...)
+        if e == nil {
+            this.leaveReadOnly()
+        }
+        this.afterWrite(1, n, e)
     }
+    this.writeShadow(logLine)
+    return n, e
 }
 
-// 写详细日志（Detail）
-
-func (this *SimLogger) IsEnabledDetailLog() bool {
-    return atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_DETAIL)
-}
-
-func (this *SimLogger) Detail(a ...interface{}) (int, error) {
-    return this.SkipDetail(this.opts.skip, a...)
-}
-
-func (this *SimLogger) Detailln(a ...interface{}) (int, error) {
-    return this.SkipDetailln(this.opts.skip, a...)
-}
-
-func (this *SimLogger) Detailf(format string, a ...interface{}) (int, error) {
-    return this.SkipDetailf(this.opts.skip, format, a...)
-}
-
-// 写详细日志（SkipDetail）
+// 第3个参数指示是否有滚动，如果为true则表示滚动了
+func (this *SimLogger) writeLog(file *logFile, logLine string) (int, error, bool) {
+    // 写日志文件
+    // 日志写文件
+    // 0644 -> rw-r--r--
+    var f *logFile
+    var e error
 
-func (this *SimLogger) SkipDetail(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledDetailLog() {
-        return 0, nil
+    if file != nil {
+        // 外部传入
+        f = file
     } else {
-        file, line := this.getCaller(skip)
-        return this.log(LL_DETAIL, file, line, a...)
+        // 本地创建
+        f, e = this.openLogFile()
+        if e != nil {
+            return 0, e, false
+        }
+    }
+    if file == nil {
+        // 本地创建
+        defer f.Close()
     }
-}
 
-func (this *SimLogger) SkipDetailln(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledDetailLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logln(LL_DETAIL, file, line, a...)
+    logFileSize, e := f.Size()
+    if e != nil {
+        return 0, e, false
+    }
+    if this.opts.strictFilesize && logFileSize > 0 && logFileSize+int64(len(logLine)) > this.opts.logFileSize {
+        // 写入后将超过日志文件大小，先滚动再写入新文件
+        f.Flush()
+        if this.rotateLog(f.path, f.file) {
+            nf, e := this.openLogFile()
+            if e != nil {
+                return 0, e, true
+            }
+            defer nf.Close()
+            n, e := nf.WriteString(logLine)
+            return n, e, true
+        }
     }
-}
 
-func (this *SimLogger) SkipDetailf(skip int32, format string, a ...interface{}) (int, error) {
-    if !this.IsEnabledDetailLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logf(LL_DETAIL, file, line, format, a...)
+    rotated := false
+    n, e := f.WriteString(logLine)
+    if logFileSize+int64(n) >= this.opts.logFileSize {
+        f.Flush() // 滚动前写出缓冲的日志
+        rotated = this.rotateLog(f.path, f.file)
     }
+    return n, e, rotated
 }
 
-// 写调试日志（Debug）
-
-func (this *SimLogger) IsEnabledDebugLog() bool {
-    return atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_DEBUG)
+func (this *SimLogger) getFilepath() string {
+    if this.opts.dateSubdirs {
+        return fmt.Sprintf("%s/%s/%s", this.getLogDir(), dateSubdir(time.Now()), this.opts.logFilename)
+    }
+    return fmt.Sprintf("%s/%s", this.getLogDir(), this.opts.logFilename)
 }
 
-func (this *SimLogger) Debug(a ...interface{}) (int, error) {
-    return this.SkipDebug(this.opts.skip, a...)
-}
+// 以下 skipLog 等为 Skip* 系列的慢路径，Skip* 先只做级别判断（一次原子读和分支），
+// 日志被关闭时直接返回，取调用者、格式化等都在慢路径中进行。
+// 因为多了慢路径这一层调用，所以取调用者时 skip 加1。
 
-func (this *SimLogger) Debugln(a ...interface{}) (int, error) {
-    return this.SkipDebugln(this.opts.skip, a...)
+func (this *SimLogger) skipLog(logLevel LogLevel, skip int32, lineFeed bool, a []interface{}) (int, error) {
+    caller := this.getCaller(logLevel, skip+1)
+    return this.output(logLevel, caller, fmt.Sprint(a...), lineFeed)
 }
 
-func (this *SimLogger) Debugf(format string, a ...interface{}) (int, error) {
-    return this.SkipDebugf(this.opts.skip, format, a...)
+func (this *SimLogger) skipLogf(logLevel LogLevel, skip int32, format string, a []interface{}) (int, error) {
+    caller := this.getCaller(logLevel, skip+1)
+    return this.output(logLevel, caller, this.sprintf(int(skip)+2, format, a), false)
 }
 
-// 写调试日志（SkipDebug）
-
-func (this *SimLogger) SkipDebug(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledDebugLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.log(LL_DEBUG, file, line, a...)
-    }
+func (this *SimLogger) skipFatal(skip int32, lineFeed bool, a []interface{}) (int, error) {
+    caller := this.getCaller(LL_FATAL, skip+1)
+    return this.fatal(caller, fmt.Sprint(a...), lineFeed)
 }
 
-func (this *SimLogger) SkipDebugln(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledDebugLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logln(LL_DEBUG, file, line, a...)
-    }
+func (this *SimLogger) skipFatalf(skip int32, format string, a []interface{}) (int, error) {
+    caller := this.getCaller(LL_FATAL, skip+1)
+    return this.fatal(caller, this.sprintf(int(skip)+2, format, a), false)
 }
 
-func (this *SimLogger) SkipDebugf(skip int32, format string, a ...interface{}) (int, error) {
-    if !this.IsEnabledDebugLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logf(LL_DEBUG, file, line, format, a...)
-    }
+func (this *SimLogger) skipCapture(skip int32, lineFeed bool, a []interface{}) {
+    this.captureDebug(this.getCaller(LL_DEBUG, skip+1), fmt.Sprint(a...), lineFeed)
 }
 
-// 写信息日志（Info）
-
-func (this *SimLogger) IsEnabledInfoLog() bool {
-    return atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_INFO)
+func (this *SimLogger) skipCapturef(skip int32, format string, a []interface{}) {
+    this.captureDebug(this.getCaller(LL_DEBUG, skip+1), fmt.Sprintf(format, a...), false)
 }
 
-func (this *SimLogger) Info(a ...interface{}) (int, error) {
-    return this.SkipInfo(this.opts.skip, a...)
+func (this *SimLogger) log(logLevel LogLevel, caller Caller, a ...interface{}) (int, error) {
+    return this.output(logLevel, caller, fmt.Sprint(a...), false)
 }
 
-func (this *SimLogger) Infoln(a ...interface{}) (int, error) {
-    return this.SkipInfoln(this.opts.skip, a...)
+func (this *SimLogger) logln(logLevel LogLevel, caller Caller, a ...interface{}) (int, error) {
+    return this.output(logLevel, caller, fmt.Sprint(a...), true)
 }
 
-func (this *SimLogger) Infof(format string, a ...interface{}) (int, error) {
-    return this.SkipInfof(this.opts.skip, format, a...)
+// logLevel: 日志级别
+// caller: 调用者（源代码文件名和行号等）
+func (this *SimLogger) logf(logLevel LogLevel, caller Caller, format string, a ...interface{}) (int, error) {
+    return this.output(logLevel, caller, this.sprintf(4, format, a), false) // 调用者为 Rawf 等的调用者
 }
 
-// 写信息日志（SkipInfo）
-
-func (this *SimLogger) SkipInfo(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledInfoLog() {
+// Output 以指定的级别和调用者输出一条日志，供桥接其它日志库（如 logrus）使用，
+// 调用者由桥接方提供（为零值表示没有），未开启记录调用者或级别低于 WithCallerMinLevel 时不记录。
+// 注意级别为 LL_FATAL 时只记录日志，不执行 FatalPolicy，由被桥接的日志库自行处理。
+func (this *SimLogger) Output(logLevel LogLevel, caller Caller, logBody string) (int, error) {
+    if !this.Enabled(logLevel) {
         return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.log(LL_INFO, file, line, a...)
     }
+    if atomic.LoadInt32(&this.opts.logCaller) != 1 || int32(logLevel) > atomic.LoadInt32(&this.opts.callerMinLevel) {
+        caller = Caller{}
+    }
+    return this.output(logLevel, caller, logBody, true)
 }
 
-func (this *SimLogger) SkipInfoln(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledInfoLog() {
+// 构建日志行并输出，
+// lineFeed 为 true 表示总是在行尾加换行符，否则由 EnabledLineFeed 决定。
+func (this *SimLogger) output(logLevel LogLevel, caller Caller, logBody string, lineFeed bool) (int, error) {
+    this.checkInit()
+    if this.loggerCore == nil {
+        return 0, nil // 未 Init，见 SimLogger
+    }
+    this.checkOpen()
+    if !this.filterLabels(logLevel) {
         return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logln(LL_INFO, file, line, a...)
     }
+    if this.opts.cpuBudget > 0 {
+        start := time.Now()
+        if !this.governed(logLevel) {
+            this.chargeCPU(start) // 限流时也要按周期调整档位
+            return 0, nil
+        }
+        defer this.chargeCPU(start)
+    }
+    entry := this.newEntry(logLevel, caller, logBody)
+    if skew := this.detectClockSkew(entry.Time); skew > 0 {
+        this.putClockSkewMarker(entry.Time, skew)
+    }
+    logLine, logLineHeader := this.buildLogLine(&entry, lineFeed)
+    logBody = entry.Body
+    if this.debugBuffer != nil && logLevel <= LL_DEBUG {
+        this.debugBuffer.add(logLine)
+    }
+    if this.opts.logObserver != nil {
+        this.opts.logObserver(logLevel, logLineHeader, logBody)
+    }
+    if len(this.observers) > 0 {
+        this.notifyObservers(&entry)
+    }
+    if this.opts.digestInterval > 0 {
+        this.addDigest(logLevel, logBody)
+    }
+    if this.opts.callerStats && !entry.Caller.empty() {
+        this.addCallerStat(entry.Caller, len(logLine))
+    }
+    entry.line = logLine
+    return this.writeSinks(&entry)
 }
 
-func (this *SimLogger) SkipInfof(skip int32, format string, a ...interface{}) (int, error) {
-    if !this.IsEnabledInfoLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logf(LL_INFO, file, line, format, a...)
+// 构建日志行，同时返回日志行头
+func (this *SimLogger) buildLogLine(entry *Entry, lineFeed bool) (string, string) {
+    var logLine string
+    logLineHeader := this.formatLogLineHeader(entry)
+    logBody := entry.Body
+    if this.opts.compressThreshold > 0 && entry.Level != LL_RAW && len(logBody) > this.opts.compressThreshold {
+        logBody = this.compressBody(logBody)
     }
-}
-
-// 写注意日志（Notice）
-
-func (this *SimLogger) IsEnabledNoticeLog() bool {
-    return atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_NOTICE)
-}
-
-func (this *SimLogger) Notice(a ...interface{}) (int, error) {
-    return this.SkipNotice(this.opts.skip, a...)
-}
 
-func (this *SimLogger) Noticeln(a ...interface{}) (int, error) {
-    return this.SkipNoticeln(this.opts.skip, a...)
-}
-
-func (this *SimLogger) Noticef(format string, a ...interface{}) (int, error) {
-    return this.SkipNoticef(this.opts.skip, format, a...)
-}
-
-// 写注意日志（SkipNotice）
-
-func (this *SimLogger) SkipNotice(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledNoticeLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.log(LL_NOTICE, file, line, a...)
+    if this.opts.format == FormatJSON && entry.Level != LL_RAW {
+        return this.formatJSONLine(entry, logBody), logLineHeader
     }
-}
-
-func (this *SimLogger) SkipNoticeln(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledNoticeLog() {
-        return 0, nil
+    if lineFeed || this.EnabledLineFeed() {
+        logLine = logLineHeader + logBody + "\n"
     } else {
-        file, line := this.getCaller(skip)
-        return this.logln(LL_NOTICE, file, line, a...)
+        logLine = logLineHeader + logBody
     }
-}
-
-func (this *SimLogger) SkipNoticef(skip int32, format string, a ...interface{}) (int, error) {
-    if !this.IsEnabledNoticeLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logf(LL_NOTICE, file, line, format, a...)
+    if entry.Level != LL_RAW && atomic.LoadInt32(&this.opts.enableChecksum) == 1 {
+        logLine = appendChecksum(logLine)
     }
-}
-
-// 写警示日志（Warning）
-
-func (this *SimLogger) IsEnabledWarningLog() bool {
-    return atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_WARNING)
-}
-
-func (this *SimLogger) Warning(a ...interface{}) (int, error) {
-    return this.SkipWarning(this.opts.skip, a...)
-}
-
-func (this *SimLogger) Warningln(a ...interface{}) (int, error) {
-    return this.SkipWarningln(this.opts.skip, a...)
-}
-
-func (this *SimLogger) Warningf(format string, a ...interface{}) (int, error) {
-    return this.SkipWarningf(this.opts.skip, format, a...)
-}
-
-// 写警示日志（SkipWarning）
-
-func (this *SimLogger) SkipWarning(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledWarningLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.log(LL_WARNING, file, line, a...)
+    return logLine, logLineHeader
+}
+
+// 输出 simlog 自身产生的日志（如时钟回退标记、摘要），不受日志级别限制，也不通知观察者
+func (this *SimLogger) putInternalLog(now time.Time, logLevel LogLevel, logBody string) (int, error) {
+    entry := Entry{
+        Time:    now,
+        Level:   logLevel,
+        Service: this.opts.serviceName,
+        Version: this.opts.serviceVersion,
+        Tags:    this.opts.tags,
+        Body:    logBody,
     }
-}
-
-func (this *SimLogger) SkipWarningln(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledWarningLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logln(LL_WARNING, file, line, a...)
+    if len(entry.Tags) > 0 {
+        entry.Tag = entry.Tags[0]
     }
-}
-
-func (this *SimLogger) SkipWarningf(skip int32, format string, a ...interface{}) (int, error) {
-    if !this.IsEnabledWarningLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logf(LL_WARNING, file, line, format, a...)
+    if this.EnabledElapsedTime() {
+        entry.Elapsed = now.Sub(this.startTime)
     }
-}
-
-// 写错误日志（Error）
-
-func (this *SimLogger) IsEnabledErrorLog() bool {
-    return atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_ERROR)
-}
-
-func (this *SimLogger) Error(a ...interface{}) (int, error) {
-    return this.SkipError(this.opts.skip, a...)
-}
-
-func (this *SimLogger) Errorln(a ...interface{}) (int, error) {
-    return this.SkipErrorln(this.opts.skip, a...)
-}
-
-func (this *SimLogger) Errorf(format string, a ...interface{}) (int, error) {
-    return this.SkipErrorf(this.opts.skip, format, a...)
-}
-
-// 写错误日志（SkipError）
-
-func (this *SimLogger) SkipError(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledErrorLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.log(LL_ERROR, file, line, a...)
+    if this.opts.format == FormatJSON {
+        return this.putLog(logLevel, this.formatJSONLine(&entry, entry.Body))
     }
-}
-
-func (this *SimLogger) SkipErrorln(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledErrorLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logln(LL_ERROR, file, line, a...)
+    logLine := this.formatLogLineHeader(&entry) + entry.Body + "\n"
+    if atomic.LoadInt32(&this.opts.enableChecksum) == 1 {
+        logLine = appendChecksum(logLine)
     }
+    return this.putLog(logLevel, logLine)
 }
 
-func (this *SimLogger) SkipErrorf(skip int32, format string, a ...interface{}) (int, error) {
-    if !this.IsEnabledErrorLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        return this.logf(LL_ERROR, file, line, format, a...)
+// 返回true表示滚动了
+func (this *SimLogger) rotateLog(cur_filepath string, f *os.File) bool {
+    // 进入滚动逻辑
+    // 先加文件锁，进一步判断
+    // syscall.LOCK_EX: 排他锁
+    //err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX) // syscall.LOCK_NB
+    //if err != nil {
+    //    return false
+    //}
+    if this.rotateSuppressed() {
+        return false
     }
-}
-
-// 写致命错误日志（Fatal），
-// 注意在调用后进程会退出。
-
-func (this *SimLogger) IsEnabledFatalLog() bool {
-    return atomic.LoadInt32(&this.opts.logLevel) >= int32(LL_FATAL)
-}
-
-func (this *SimLogger) Fatal(a ...interface{}) (int, error) {
-    return this.SkipFatal(this.opts.skip, a...)
-}
-
-func (this *SimLogger) Fatalln(a ...interface{}) (int, error) {
-    return this.SkipFatalln(this.opts.skip, a...)
-}
-
-func (this *SimLogger) Fatalf(format string, a ...interface{}) (int, error) {
-    return this.SkipFatalf(this.opts.skip, format, a...)
-}
-
-// 写致命错误日志（SkipFatal）
+    var err error
+    lockFilepath := this.lockFilepath(cur_filepath)
+    if !this.opts.noRotationLock {
+        fileLock := flock.New(lockFilepath)
+        err = fileLock.Lock()
+        if err != nil {
+            this.reportError(fmt.Errorf("%w: lock by %s: %w", ErrRotateFailed, lockFilepath, err))
+            return false
+        }
+        //fmt.Fprintf(os.Stdout, "simlog lock by %s ok\n", lockFilepath)
 
-func (this *SimLogger) SkipFatal(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledFatalLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        n, err := this.log(LL_FATAL, file, line, a...)
-        os.Exit(1) // 致使错误
-        return n, err
+        //defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+        defer fileLock.Unlock()
+        //defer os.Remove(lockFilepath)
     }
-}
-
-func (this *SimLogger) SkipFatalln(skip int32, a ...interface{}) (int, error) {
-    if !this.IsEnabledFatalLog() {
-        return 0, nil
-    } else {
-        file, line := this.getCaller(skip)
-        n, err := this.logln(LL_FATAL, file, line, a...)
-        os.Exit(1) // 致使错误
-        return n, err
+    if !ownsRotation(cur_filepath, f) {
+        return true // 其它进程已滚动了本轮，重新打开即可
+    }
+    if this.rotateSuppressedByOthers(cur_filepath) {
+        return false
     }
-}
 
-func (this *SimLogger) SkipFatalf(skip int32, format string, a ...interface{}) (int, error) {
-    if !this.IsEnabledFatalLog() {
-        return 0, nil
+    logFileSize := atomic.LoadInt64(&this.opts.logFileSize)
+    logNumBackups := atomic.LoadInt32(&this.opts.logNumBackups)
+    logFileSize, err = GetFileSize(cur_filepath)
+    if err != nil || logFileSize < logFileSize {
+        return false
+    }
+    indexed := this.opts.indexEvery > 0 && logNumBackups > 0
+    if indexed {
+        this.indexRotatedFile(cur_filepath)
+    }
+    var archived *ManifestFile
+    if this.opts.manifest && logNumBackups > 0 {
+        if archived, err = describeArchive(cur_filepath); err != nil {
+            this.reportError(fmt.Errorf("%w: describe file://%s for manifest: %w", ErrRotateFailed, cur_filepath, err))
+        }
+    }
+    backupExt := this.backupExt()
+    for i := logNumBackups - 1; i > 0; i-- { // 滚动
+        newFilepath := fmt.Sprintf("%s.%d", cur_filepath, i)
+        oldFilepath := fmt.Sprintf("%s.%d", cur_filepath, i-1)
+        os.Rename(oldFilepath, newFilepath)
+        if indexed {
+            renameIndex(oldFilepath, newFilepath)
+        }
+        if backupExt != "" {
+            os.Rename(oldFilepath+backupExt, newFilepath+backupExt)
+        }
+    }
+    if logNumBackups > 0 {
+        newFilepath := fmt.Sprintf("%s.%d", cur_filepath, 1)
+        if err = os.Rename(cur_filepath, newFilepath); err != nil {
+            this.reportError(fmt.Errorf("%w: %w", ErrRotateFailed, err))
+        }
+        if indexed {
+            renameIndex(cur_filepath, newFilepath)
+        }
+        if backupExt != "" && err == nil {
+            if err = compressBackup(newFilepath, this.opts.backupCodec, backupExt); err != nil {
+                this.reportError(fmt.Errorf("%w: compress file://%s: %w", ErrRotateFailed, newFilepath, err))
+                backupExt = "" // 保留未压缩的
+            }
+        }
     } else {
-        file, line := this.getCaller(skip)
-        n, err := this.logf(LL_FATAL, file, line, format, a...)
-        os.Exit(1) // 致使错误
-        return n, err
+        os.Remove(cur_filepath)
+    }
+    if archived != nil {
+        if err = updateManifest(cur_filepath, backupExt, archived, logNumBackups, this.manifestLockFilepath(cur_filepath)); err != nil {
+            this.reportError(fmt.Errorf("%w: update manifest of file://%s: %w", ErrRotateFailed, cur_filepath, err))
+        }
     }
+    if !this.opts.noRotationLock {
+        bumpRotationEpoch(lockFilepath)
+    }
+    this.markRotated()
+    atomic.AddInt64(&this.numRotations, 1)
+    this.recordLifecycle(LifecycleEvent{Event: LifecycleRotated, Size: logFileSize})
+
+    return true
 }
 
-// 返回调用者所在源代码文件名和行号
-func (this *SimLogger) getCaller(skip int32) (string, int) {
-    var file string
-    var line int = 0
-    if atomic.LoadInt32(&this.opts.logCaller) == 1 {
-        _, file, line, _ = runtime.Caller(int(skip))
+// 写协程：监督实际写日志的 runWriter，runWriter 异常退出（打开日志文件失败或 panic）时，
+// 记录错误并在退避后重启，而不是让日志悄无声息地变成黑洞（写日志成功但什么也没写入）。
+func (this *SimLogger) writeLogCoroutine() {
+    backoff := this.opts.openRetryMin
+    if backoff <= 0 {
+        backoff = 100 * time.Millisecond
     }
-    return file, line
-}
 
-// 组装日志行头
-func (this *SimLogger) formatLogLineHeader(logLevel LogLevel, file string, line int) string {
-    if logLevel == LL_RAW {
-        enableRawLog := atomic.LoadInt32(&this.opts.enableRawLog)
-        if enableRawLog == 1 {
-            rawLogWithTime := atomic.LoadInt32(&this.opts.rawLogWithTime)
-            if rawLogWithTime == 1 {
-                return getLogTime()
-            }
+    for {
+        queueClosed, err := this.runWriter()
+        if queueClosed {
+            break
         }
-        return ""
-    } else {
-        var tag string
-        var fileline string
 
-        if this.opts.tag != "" {
-            tag = "[" + this.opts.tag + "]"
+        this.setWriterState(writerRestarting, err)
+        if atomic.LoadInt32(&this.closed) == 1 {
+            break // 关闭期间不再重启，队列中剩余的由 Close 计为丢弃
         }
-        if file != "" && line > 0 {
-            fileline = "[" + filepath.Base(file) + ":" + strconv.FormatInt(int64(line), 10) + "]"
+        atomic.AddInt64(&this.numWriterRestarts, 1)
+        fmt.Fprintf(os.Stderr, "simlog: log writer of file://%s failed: %s, restarting\n", this.getFilepath(), err.Error())
+        time.Sleep(backoff)
+        if backoff *= 2; backoff > this.opts.openRetryMax && this.opts.openRetryMax > 0 {
+            backoff = this.opts.openRetryMax
         }
-
-        datetime := getLogTime()
-        logLevelName := "[" + GetLogLevelName(logLevel) + "]"
-        return datetime + tag + logLevelName + fileline
     }
+    if state := atomic.LoadInt32(&this.writerState); state != writerRestarting {
+        this.setWriterState(writerStopped, nil)
+    }
+    close(this.logExit)
 }
 
-// 实际接口 Writer：
-// type Writer interface {
-//   Write(p []byte) (n int, err error)
-// }
-func (this *SimLogger) Write(p []byte) (int, error) {
-    return this.putLog(string(p))
-}
+// 从日志队列中取日志写入日志文件，直到日志队列关闭（返回 true）或出错
+func (this *SimLogger) runWriter() (queueClosed bool, err error) {
+    var file *logFile     // 日志文件
+    var logLines []string // 正在写的一批日志
+    var dones []chan error
+    batchNumber := 1
 
-func (this *SimLogger) putLog(logLine string) (int, error) {
     defer func() {
-        if err := recover(); err != nil {
+        if r := recover(); r != nil {
+            err = fmt.Errorf("simlog: log writer panic: %v", r)
+            atomic.AddInt64(&this.numDropped, int64(len(logLines)))
+            this.addProcessed(len(logLines))
+            notifyDones(dones, err)
+        }
+        if file != nil {
+            file.Close()
         }
     }()
 
-    // 日志打屏
-    if atomic.LoadInt32(&this.opts.printScreen) == 1 {
-        fmt.Print(logLine)
-    }
-    if this.opts.asyncWrite {
-        this.logQueue <- logLine // Panic if logQueue is closed
-        return len(logLine), nil
-    } else {
-        n, e, _ := this.writeLog(nil, logLine)
-        return n, e
-    }
-}
-
-// 第3个参数指示是否有滚动，如果为true则表示滚动了
-func (this *SimLogger) writeLog(file *os.File, logLine string) (int, error, bool) {
-    // 写日志文件
-    // 日志写文件
-    // 0644 -> rw-r--r--
-    var f *os.File
-    var e error
-
-    if file != nil {
-        // 外部传入
-        f = file
-    } else {
-        // 本地创建
-        f, e = os.OpenFile(this.getFilepath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-        if e != nil {
-            return 0, e, false
+    if !this.opts.lazyFileOpen {
+        if file, err = this.openLogFileWithRetry(); err != nil && !this.enterReadOnly(err) {
+            return false, err
         }
     }
-    if file == nil {
-        // 本地创建
-        defer f.Close()
+    this.setWriterState(writerRunning, nil)
+    if this.opts.lockOSThread {
+        runtime.LockOSThread()
+        defer runtime.UnlockOSThread()
     }
 
-    fi, e := f.Stat()
-    if e != nil {
-        return 0, e, false
-    } else {
-        rotated := false
-        logFileSize := fi.Size()
-        n, e := f.WriteString(logLine)
-
-        if logFileSize >= this.opts.logFileSize {
-            rotated = this.rotateLog(this.getFilepath(), f)
+    if this.opts.batchNumber > 0 {
+        batchNumber = int(this.opts.batchNumber)
+    }
+    for {
+        var ok bool
+        logLines, dones, ok = this.takeLogLines(batchNumber)
+        if len(logLines) > 0 {
+            this.batchError = nil
+            file, err = this.writeLogLines(file, logLines)
+            numLines := len(logLines)
+            if this.batchBuffer != nil {
+                clear(logLines) // 重复使用，不再引用已写的日志
+            }
+            logLines = nil
+            this.addProcessed(numLines)
+            notifyDones(dones, this.batchError)
+            dones = nil
+            if err != nil {
+                return false, err
+            }
+        }
+        if !ok {
+            return true, nil
         }
-        return n, e, rotated
     }
 }
 
-func (this *SimLogger) getFilepath() string {
-    return fmt.Sprintf("%s/%s", this.opts.logDir, this.opts.logFilename)
-}
-
-func (this *SimLogger) log(logLevel LogLevel, file string, line int, a ...interface{}) (int, error) {
-    var logLine string
-    logLineHeader := this.formatLogLineHeader(logLevel, file, line)
-    logBody := fmt.Sprint(a...)
+// 日志队列中的一条日志
+type queuedLog struct {
+    level LogLevel   // 日志级别，用于 WithRetroactiveFilter
+    line  string     // 日志行
+    done  chan error // 写完后通知写的结果，只在 WithStrictErrors 时不为 nil
+}
+
+// 从日志队列中取一批日志：至少一条（队列为空时阻塞），最多 batchNumber 条，
+// 队列中不足 batchNumber 条时不等待，有多少取多少。
+// 开启了 WithRetroactiveFilter 时跳过当前级别不再输出的日志，关闭时跳过 WithShutdownLevel 丢弃的日志，因此返回的日志可能为空。
+// 第2个返回值为false表示日志队列已关闭。
+// 第2个返回值为这批日志中等待写的结果的通知（见 WithStrictErrors）。
+func (this *SimLogger) takeLogLines(batchNumber int) ([]string, []chan error, bool) {
+    var numPurged, numThrottled int
+    var dones []chan error
+    queued, ok := this.logQueue.pop() // block
+    if !ok {
+        return nil, nil, false
+    }
 
-    // 构建日志行
-    if this.EnabledLineFeed() {
-        logLine = logLineHeader + logBody + "\n"
-    } else {
-        logLine = logLineHeader + logBody
+    logLines := this.batchBuffer[:0:cap(this.batchBuffer)]
+    if logLines == nil {
+        logLines = make([]string, 0, batchNumber)
     }
-    if this.opts.logObserver != nil {
-        this.opts.logObserver(logLevel, logLineHeader, logBody)
+    for {
+        if this.opts.retroactiveFilter && !this.Enabled(queued.level) {
+            numPurged++
+            notifyDone(queued.done, nil)
+        } else if this.throttledOnClose(queued.level) {
+            numThrottled++
+            notifyDone(queued.done, nil)
+        } else {
+            if this.opts.writeTime {
+                queued.line = this.stampWriteTime(queued.level, queued.line, time.Now())
+            }
+            logLines = append(logLines, queued.line)
+            if queued.done != nil {
+                dones = append(dones, queued.done)
+            }
+        }
+        if len(logLines) >= batchNumber {
+            break
+        }
+        if queued, ok = this.logQueue.tryPop(); !ok {
+            break
+        }
     }
-    return this.putLog(logLine)
+    this.addPurged(numPurged)
+    this.addThrottled(numThrottled)
+    return logLines, dones, true
 }
 
-func (this *SimLogger) logln(logLevel LogLevel, file string, line int, a ...interface{}) (int, error) {
-    var logLine string
-    logLineHeader := this.formatLogLineHeader(logLevel, file, line)
-    logBody := fmt.Sprint(a...)
+// 批量写日志，file 为 nil 时先打开日志文件，如果发生了滚动则重新打开日志文件，
+// 返回的 error 不为 nil 表示打开日志文件失败。
+// 一批日志跨越滚动边界时拆开写，边界之后的日志写入滚动后的新文件，使每个文件的大小和时间范围准确。
+func (this *SimLogger) writeLogLines(file *logFile, logLines []string) (*logFile, error) {
+    var err error
+    batchLines := len(logLines)
 
-    // 构建日志行
-    logLine = logLineHeader + logBody + "\n"
-    if this.opts.logObserver != nil {
-        this.opts.logObserver(logLevel, logLineHeader, logBody)
-    }
-    return this.putLog(logLine)
-}
+    this.pauseMutex.RLock()
+    defer this.pauseMutex.RUnlock()
 
-// logLevel: 日志级别
-// file: 源代码文件名（不包含目录部分）
-// line: 源代码行号
-func (this *SimLogger) logf(logLevel LogLevel, file string, line int, format string, a ...interface{}) (int, error) {
-    var logLine string
-    logLineHeader := this.formatLogLineHeader(logLevel, file, line)
-    logBody := fmt.Sprintf(format, a...)
+    for len(logLines) > 0 {
+        if file != nil && this.pathChanged(file) {
+            file.Close() // 跨天了或日志目录变了，写到新的目录
+            file = nil
+        }
+        if file == nil && this.inReadOnly() {
+            logData := strings.Join(logLines, "")
+            this.writeFallback(len(logLines), logData)
+            this.writeShadow(logData)
+            break
+        }
+        if file == nil {
+            if file, err = this.openLogFileWithRetry(); err != nil {
+                if this.enterReadOnly(err) {
+                    continue // 改写降级输出
+                }
+                this.afterWrite(len(logLines), 0, err)
+                return nil, err
+            }
+        }
 
-    // 构建日志行
-    if this.EnabledLineFeed() {
-        logLine = logLineHeader + logBody + "\n"
-    } else {
-        logLine = logLineHeader + logBody
+        numLines := this.linesBeforeBoundary(file, logLines)
+        logData := strings.Join(logLines[:numLines], "")
+        n, e, rotated := this.writeLog(file, logData)
+        if this.enterReadOnly(e) {
+            this.writeFallback(numLines, logData)
+            rotated = true // 关闭日志文件，探测时重新打开
+        } else {
+            if e == nil {
+                this.leaveReadOnly()
+            }
+            this.afterWrite(numLines, n, e)
+        }
+        this.writeShadow(logData)
+        logLines = logLines[numLines:]
+        if rotated {
+            file.Close()
+            file = nil
+        }
     }
-    if this.opts.logObserver != nil {
-        this.opts.logObserver(logLevel, logLineHeader, logBody)
+    if file == nil {
+        this.markFlushed(batchLines) // 滚动时已关闭
+        if this.inReadOnly() {
+            return nil, nil
+        }
+        return this.openLogFileWithRetry()
+    }
+    if atomic.LoadInt32(&this.flushWaiters) > 0 || this.queueLen() == 0 {
+        this.flushLogFile(file, batchLines) // 一波日志写完了，或有 Barrier 在等待
     }
-    return this.putLog(logLine)
+    return file, nil
 }
 
-// 返回true表示滚动了
-func (this *SimLogger) rotateLog(cur_filepath string, f *os.File) bool {
-    // 进入滚动逻辑
-    // 先加文件锁，进一步判断
-    // syscall.LOCK_EX: 排他锁
-    //err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX) // syscall.LOCK_NB
-    //if err != nil {
-    //    return false
-    //}
-    lockFilepath := cur_filepath + ".lock"
-    fileLock := flock.New(lockFilepath)
-    err := fileLock.Lock()
+// 返回 logLines 中写入 file 的前多少条到达滚动边界（至少为1）：
+// 严格限制日志文件大小时为不超过日志文件大小的条数，否则包括使文件大小达到日志文件大小的那一条。
+func (this *SimLogger) linesBeforeBoundary(file *logFile, logLines []string) int {
+    size, err := file.Size()
     if err != nil {
-        fmt.Fprintf(os.Stderr, "simlog lock by %s fail: %s\n", lockFilepath, err.Error())
-        return false
+        return len(logLines)
     }
-    //fmt.Fprintf(os.Stdout, "simlog lock by %s ok\n", lockFilepath)
-
-    //defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
-    defer fileLock.Unlock()
-    //defer os.Remove(lockFilepath)
 
-    logFileSize := atomic.LoadInt64(&this.opts.logFileSize)
-    logNumBackups := atomic.LoadInt32(&this.opts.logNumBackups)
-    logFileSize, err = GetFileSize(cur_filepath)
-    if err != nil || logFileSize < logFileSize {
-        return false
-    }
-    for i := logNumBackups - 1; i > 0; i-- { // 滚动
-        newFilepath := fmt.Sprintf("%s/%s.%d", this.opts.logDir, this.opts.logFilename, i)
-        oldFilepath := fmt.Sprintf("%s/%s.%d", this.opts.logDir, this.opts.logFilename, i-1)
-        os.Rename(oldFilepath, newFilepath)
+    for i, logLine := range logLines {
+        size += int64(len(logLine))
+        if this.opts.strictFilesize && size > this.opts.logFileSize {
+            if i == 0 {
+                return 1
+            }
+            return i
+        }
+        if size >= this.opts.logFileSize {
+            return i + 1
+        }
     }
-    if logNumBackups > 0 {
-        newFilepath := fmt.Sprintf("%s/%s.%d", this.opts.logDir, this.opts.logFilename, 1)
-        os.Rename(cur_filepath, newFilepath)
-    } else {
-        os.Remove(cur_filepath)
+    return len(logLines)
+}
+
+// 异步写时打开日志文件，失败时（如网络存储上的目录暂时不可用）按指数退避重试，
+// 重试期间日志暂存在日志队列中（队列满时写日志会阻塞），
+// 只在日志已关闭时才放弃并返回错误，而不是让写协程永久退出。
+func (this *SimLogger) openLogFileWithRetry() (*logFile, error) {
+    var failures int
+    backoff := this.opts.openRetryMin
+
+    for {
+        file, err := this.openLogFile()
+        if err == nil {
+            if failures > 0 {
+                fmt.Fprintf(os.Stderr, "simlog: open log file://%s ok after %d failures\n", this.getFilepath(), failures)
+                this.setWriterState(writerRunning, nil)
+            }
+            return file, nil
+        }
+        if atomic.LoadInt32(&this.closed) == 1 || backoff <= 0 || this.opts.backend != nil {
+            return nil, err // 共享后端的写协程不能因一个日志文件而阻塞，下一批日志时再打开
+        }
+        if isReadOnlyError(err) {
+            return nil, err // 由调用者改写降级输出，按探测间隔再打开
+        }
+        if failures == 0 {
+            fmt.Fprintf(os.Stderr, "simlog: open log file://%s failed: %s, retrying\n", this.getFilepath(), err.Error())
+            this.setWriterState(writerFailing, err)
+        }
+        failures++
+        time.Sleep(backoff)
+        if backoff *= 2; backoff > this.opts.openRetryMax {
+            backoff = this.opts.openRetryMax
+        }
     }
-
-    return true
 }
 
-func (this *SimLogger) writeLogCoroutine() {
-    var err error
-    var file *os.File // 日志文件
-    exit := false
-    rotated := false // 标记日志是否滚动
-    batchNumber := 1
-
-    file, err = os.OpenFile(this.getFilepath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+// 每次实际写日志文件后调用，用于统计和回调写结果
+func (this *SimLogger) afterWrite(lines, bytes int, err error) {
     if err != nil {
-        fmt.Printf("Open or create log file://%s failed: %s\n", this.getFilepath(), err.Error())
-    } else {
-        if this.opts.lockOSThread {
-            runtime.LockOSThread()
-            defer runtime.UnlockOSThread()
+        if this.opts.asyncWrite && this.batchError == nil {
+            this.batchError = err // 只在写协程中访问
         }
+        atomic.AddInt64(&this.numDropped, int64(lines))
+    } else {
+        atomic.AddInt64(&this.numWritten, int64(lines))
+    }
+    if this.opts.writeCallback != nil {
+        this.opts.writeCallback(lines, bytes, err)
+    }
+}
 
-        if this.opts.batchNumber > 0 {
-            batchNumber = int(this.opts.batchNumber)
-        }
-        for {
-            var logLines string
-
-            for i := 0; i < batchNumber; i++ {
-                if len(this.logQueue) == 0 {
-                    if logLines != "" {
-                        // 不满处理
-                        _, _, rotated = this.writeLog(file, logLines)
-                        logLines = ""
-
-                        if rotated {
-                            file.Close()
-                            file, err = os.OpenFile(this.getFilepath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-                            if err != nil {
-                                fmt.Printf("Open or create log file://%s failed: %s\n", this.getFilepath(), err.Error())
-                                exit = true
-                                break
-                            }
-                        }
-                    }
-                }
-                logLine, ok := <-this.logQueue // block
-                if !ok {
-                    exit = true
-                    break
-                }
-                logLines = logLines + logLine
-            }
-            // 满处理
-            if len(logLines) > 0 {
-                _, _, rotated = this.writeLog(file, logLines)
-                logLines = ""
-
-                if rotated {
-                    file.Close()
-                    file, err = os.OpenFile(this.getFilepath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-                    if err != nil {
-                        fmt.Printf("Open or create log file://%s failed: %s\n", this.getFilepath(), err.Error())
-                        exit = true
-                        break
-                    }
-                }
-            }
-            if exit {
-                break
-            }
+// 打开（不存在时创建）日志文件，设置了写缓冲时带缓冲
+// 0644 -> rw-r--r--
+func (this *SimLogger) openLogFile() (*logFile, error) {
+    path := this.getFilepath()
+    if this.opts.dateSubdirs {
+        if err := this.makeDateSubdir(path); err != nil {
+            return nil, err
         }
     }
-    this.logExit <- 1
+    f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+    if err != nil {
+        return nil, err
+    }
+    if this.writeBuffer != nil {
+        this.writeBuffer.Reset(f)
+        return &logFile{file: f, path: path, writer: this.writeBuffer}, nil
+    }
+    return newLogFile(f, path, int(this.opts.writeBufferSize)), nil
 }
 
 //
@@ -1042,6 +1676,7 @@ func defaultLogOptions() logOptions {
         logQueueSize:   100000,
         batchNumber:    100,
         logCaller:      0,
+        callerMinLevel: int32(LL_TRACE),
         printScreen:    0,
         enableTraceLog: 0,
         enableLineFeed: 0,
@@ -1049,9 +1684,14 @@ func defaultLogOptions() logOptions {
         rawLogWithTime: 0,
         skip:           3,
         logLevel:       int32(LL_INFO),
+        fatalPolicy:    int32(FatalExit),
+        fatalExitCode:  1,
         logDir:         GetLogDir(),
         logFileSize:    1024 * 1024 * 200, // 200 MB
         logNumBackups:  10,
+        flushInterval:  time.Second,
+        openRetryMin:   100 * time.Millisecond,
+        openRetryMax:   10 * time.Second,
         logObserver:    nil,
     }
 }
@@ -1074,11 +1714,29 @@ func newFuncLogOption(f func(*logOptions)) *funcLogOption {
  * 以下为全局函数区
  */
 
-// 返回记录日志的时间，格式为：YYYY-MM-DD hh:mm:ss uuuuuu
-func getLogTime() string {
-    now := time.Now()
-    return fmt.Sprintf("[%04d-%02d-%02d %02d:%02d:%02d %06d]",
-        now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond()/1000)
+// 返回记录日志的时间，格式为：YYYY-MM-DD hh:mm:ss uuuuuu，
+// 秒之后的部分按 precision 为3位（毫秒）、6位（微秒）或9位（纳秒），
+// 按 timeZone 在最后加上时区偏移（如“+0800”）或时区缩写（如“CST”）。
+func getLogTime(now time.Time, precision TimePrecision, timeZone TimeZoneFormat) string {
+    var zone string
+
+    switch timeZone {
+    case TimeZoneOffset:
+        zone = now.Format(" -0700")
+    case TimeZoneName:
+        zone = now.Format(" MST")
+    }
+    switch precision {
+    case TimeMilli:
+        return fmt.Sprintf("[%04d-%02d-%02d %02d:%02d:%02d %03d%s]",
+            now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond()/1000000, zone)
+    case TimeNano:
+        return fmt.Sprintf("[%04d-%02d-%02d %02d:%02d:%02d %09d%s]",
+            now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond(), zone)
+    default:
+        return fmt.Sprintf("[%04d-%02d-%02d %02d:%02d:%02d %06d%s]",
+            now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond()/1000, zone)
+    }
 }
 
 // 取得指定文件的文件大小
@@ -1093,17 +1751,7 @@ func GetFileSize(filepath string) (int64, error) {
 
 // 根据日志级别得到对应级别名
 func GetLogLevelName(logLevel LogLevel) string {
-    logLevelNameArray := [...]string{
-        "FATAL",
-        "ERROR",
-        "WARNING",
-        "NOTICE",
-        "INFO",
-        "DEBUG",
-        "DETAIL",
-        "TRACE",
-        "RAW"}
-    return logLevelNameArray[int(logLevel)]
+    return logLevelNames[int(logLevel)]
 }
 
 // 自动取日志目录，
@@ -0,0 +1,57 @@
+package simlog
+
+import (
+    "bytes"
+    "os/exec"
+    "path/filepath"
+    "runtime"
+    "testing"
+)
+
+// Init 之前调用原有的成员不应 panic，写的日志被丢弃
+func TestBeforeInit(t *testing.T) {
+    if usageChecks {
+        t.Skip("simlogdebug panics on use before Init")
+    }
+    var logger SimLogger
+
+    logger.SetLogLevel(LL_DEBUG)
+    logger.EnableLineFeed(true)
+    logger.SetSkip(4)
+    if logger.GetLogLevel() != 0 || logger.GetSkip() != 0 || logger.EnabledLineFeed() {
+        t.Error("options set before Init should not take effect")
+    }
+    if logger.IsEnabledInfoLog() || logger.IsEnabledFatalLog() || logger.IsEnabledTraceLog() || logger.Enabled(LL_RAW) {
+        t.Error("logs should be disabled before Init")
+    }
+    for _, write := range []func() (int, error){
+        func() (int, error) { return logger.Infof("%d", 1) },
+        func() (int, error) { return logger.Raw("raw") },
+        func() (int, error) { return logger.Fatal("fatal") },
+        func() (int, error) { return logger.Write([]byte("line\n")) },
+        func() (int, error) { return logger.Output(LL_ERROR, Caller{}, "output") },
+        func() (int, error) { return logger.WithCode("E1").Errorf("coded") },
+    } {
+        if n, err := write(); n != 0 || err != nil {
+            t.Errorf("write before Init: got (%d, %v), want (0, nil)", n, err)
+        }
+    }
+    logger.Close()
+}
+
+// Enabled 和 IsEnabledXXXLog 须可被内联，调用处先行判断才几乎没有开销
+func TestEnabledInlinable(t *testing.T) {
+    if testing.Short() {
+        t.Skip("builds the package with -gcflags=-m")
+    }
+    goTool := filepath.Join(runtime.GOROOT(), "bin", "go")
+    out, err := exec.Command(goTool, "build", "-gcflags=-m", ".").CombinedOutput()
+    if err != nil {
+        t.Fatalf("go build -gcflags=-m: %v\n%s", err, out)
+    }
+    for _, name := range []string{"Enabled", "IsEnabledTraceLog", "IsEnabledDebugLog", "IsEnabledInfoLog"} {
+        if !bytes.Contains(out, []byte("can inline (*SimLogger)."+name+"\n")) {
+            t.Errorf("(*SimLogger).%s is not inlinable", name)
+        }
+    }
+}
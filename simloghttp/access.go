@@ -0,0 +1,108 @@
+package simloghttp
+
+import (
+    "net/http"
+    "strconv"
+    "time"
+)
+import (
+    "github.com/eyjian/simlog"
+)
+
+// AccessSubSuffix NewAccessLogger 默认的子后缀，访问日志文件名如“server-access.log”
+const AccessSubSuffix = "access"
+
+// NewAccessLogger 创建写访问日志的 SimLogger，日志文件名带子后缀 subSuffix（为空时为“access”），
+// 以便访问日志和应用日志分别滚动，opts 同 SimLogger.InitE（在子后缀之后应用，可覆盖）。
+func NewAccessLogger(subSuffix string, opts ...simlog.LogOption) (*simlog.SimLogger, error) {
+    if subSuffix == "" {
+        subSuffix = AccessSubSuffix
+    }
+    logger := new(simlog.SimLogger)
+    if err := logger.InitE(append([]simlog.LogOption{simlog.WithSubSuffix(subSuffix)}, opts...)...); err != nil {
+        return nil, err
+    }
+    return logger, nil
+}
+
+// AccessLog 包装 next，每个请求处理完后写一行访问日志到 logger，如：
+//
+//	method=GET path=/api/users?id=1 status=200 bytes=512 duration=1.234ms remote=10.0.0.1:52314
+//
+// 日志体为“key=value”形式，可由 EnableKVExtraction 提取为结构化字段；
+// 级别按状态码：5xx 为 ERROR，4xx 为 WARNING，其它为 INFO。用法：
+//
+//	accessLog, _ := simloghttp.NewAccessLogger("")
+//	http.ListenAndServe(":8080", simloghttp.AccessLog(accessLog, mux))
+func AccessLog(logger *simlog.SimLogger, next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        start := time.Now()
+        rw := &accessWriter{ResponseWriter: w}
+        next.ServeHTTP(rw, r)
+
+        status := rw.status
+        if status == 0 {
+            status = http.StatusOK // 未写任何内容
+        }
+        logLevel := simlog.LL_INFO
+        if status >= http.StatusInternalServerError {
+            logLevel = simlog.LL_ERROR
+        } else if status >= http.StatusBadRequest {
+            logLevel = simlog.LL_WARNING
+        }
+        if !logger.Enabled(logLevel) {
+            return
+        }
+        logger.Output(logLevel, simlog.Caller{}, "method="+r.Method+
+            " path="+quoteValue(r.URL.RequestURI())+
+            " status="+strconv.Itoa(status)+
+            " bytes="+strconv.FormatInt(rw.bytes, 10)+
+            " duration="+time.Since(start).String()+
+            " remote="+quoteValue(r.RemoteAddr))
+    })
+}
+
+// 含空白或引号的值加引号
+func quoteValue(value string) string {
+    for i := 0; i < len(value); i++ {
+        if c := value[i]; c <= ' ' || c == '"' {
+            return strconv.Quote(value)
+        }
+    }
+    return value
+}
+
+// accessWriter 记录状态码和响应字节数的 http.ResponseWriter
+type accessWriter struct {
+    http.ResponseWriter
+    status int
+    bytes  int64
+}
+
+func (this *accessWriter) WriteHeader(status int) {
+    if this.status == 0 {
+        this.status = status
+    }
+    this.ResponseWriter.WriteHeader(status)
+}
+
+func (this *accessWriter) Write(p []byte) (int, error) {
+    if this.status == 0 {
+        this.status = http.StatusOK
+    }
+    n, err := this.ResponseWriter.Write(p)
+    this.bytes += int64(n)
+    return n, err
+}
+
+// Flush 实现 http.Flusher，以便 server-sent events 等流式响应经过包装后仍可写出
+func (this *accessWriter) Flush() {
+    if flusher, ok := this.ResponseWriter.(http.Flusher); ok {
+        flusher.Flush()
+    }
+}
+
+// Unwrap 供 http.ResponseController 取得被包装的 http.ResponseWriter
+func (this *accessWriter) Unwrap() http.ResponseWriter {
+    return this.ResponseWriter
+}
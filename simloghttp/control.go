@@ -0,0 +1,122 @@
+package simloghttp
+
+import (
+    "crypto/subtle"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+)
+import (
+    "github.com/eyjian/simlog"
+)
+
+// ControlPath Control 的默认路径
+const ControlPath = "/debug/simlog"
+
+// ControlState 日志的可调状态，为 Control 的响应
+type ControlState struct {
+    Level  simlog.LogLevel `json:"level"`  // 日志级别
+    Trace  bool            `json:"trace"`  // 是否输出跟踪日志
+    Caller bool            `json:"caller"` // 是否记录调用者
+}
+
+// Control 以共享令牌鉴权的远程日志控制，供中心运维工具调整一批进程的日志级别等：
+//
+//	GET  /debug/simlog                          返回当前状态（JSON）
+//	POST /debug/simlog level=debug&trace=1      调整后返回新的状态，未给出的项不变
+//
+// 请求须带请求头“Authorization: Bearer <token>”，令牌为空时拒绝所有请求，以免误开放。
+// 可调的项：level（级别名）、trace 和 caller（1/0 或 true/false），其它项返回 400。
+type Control struct {
+    token   []byte
+    loggers []*simlog.SimLogger
+}
+
+// NewControl 创建控制 loggers 的 Control，loggers 同时调整，状态以第一个为准
+func NewControl(token string, loggers ...*simlog.SimLogger) *Control {
+    return &Control{token: []byte(token), loggers: loggers}
+}
+
+// 鉴权，比较令牌的耗时和令牌内容无关
+func (this *Control) authorized(r *http.Request) bool {
+    token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+    return ok && len(this.token) > 0 && subtle.ConstantTimeCompare([]byte(token), this.token) == 1
+}
+
+// ServeHTTP 返回或调整日志的状态
+func (this *Control) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if !this.authorized(r) {
+        http.Error(w, "simlog: unauthorized", http.StatusUnauthorized)
+        return
+    }
+    if len(this.loggers) == 0 {
+        http.Error(w, "simlog: no logger", http.StatusNotFound)
+        return
+    }
+
+    switch r.Method {
+    case http.MethodGet:
+    case http.MethodPost:
+        if err := r.ParseForm(); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        apply, err := parseControl(r.PostForm)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        for _, logger := range this.loggers {
+            apply(logger)
+        }
+    default:
+        w.Header().Set("Allow", "GET, POST")
+        http.Error(w, "simlog: method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+
+    logger := this.loggers[0]
+    state := ControlState{
+        Level:  simlog.LogLevel(logger.GetLogLevel()),
+        Trace:  logger.EnabledTraceLog(),
+        Caller: logger.EnabledLogCaller(),
+    }
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(state)
+}
+
+// 解析调整的项，全部合法时才返回调整函数，以免只调整了一部分
+func parseControl(form map[string][]string) (func(*simlog.SimLogger), error) {
+    var applies []func(*simlog.SimLogger)
+
+    for key, values := range form {
+        value := values[len(values)-1]
+        switch key {
+        case "level":
+            logLevel, err := simlog.ParseLogLevel(value)
+            if err != nil {
+                return nil, err
+            }
+            applies = append(applies, func(logger *simlog.SimLogger) { logger.SetLogLevel(logLevel) })
+        case "trace", "caller":
+            enabled, err := strconv.ParseBool(value)
+            if err != nil {
+                return nil, fmt.Errorf("simlog: invalid %s %q", key, value)
+            }
+            if key == "trace" {
+                applies = append(applies, func(logger *simlog.SimLogger) { logger.EnableTraceLog(enabled) })
+            } else {
+                applies = append(applies, func(logger *simlog.SimLogger) { logger.EnableLogCaller(enabled) })
+            }
+        default:
+            return nil, fmt.Errorf("simlog: unknown control %q", key)
+        }
+    }
+    return func(logger *simlog.SimLogger) {
+        for _, apply := range applies {
+            apply(logger)
+        }
+    }, nil
+}
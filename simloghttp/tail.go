@@ -0,0 +1,218 @@
+// Package simloghttp 提供 simlog 的 HTTP 组件：最近日志的查看和实时跟踪（Tail）、远程日志控制（Control），以及访问日志（AccessLog）。
+//
+// Tail 在内存环中保留最近的日志，并以 /debug/logs 提供查看和实时跟踪（server-sent events），
+// 以便开发者在没有 shell 权限时也能查看远端实例的日志：
+//
+//	tail := simloghttp.NewTail(1000)
+//	logger.Init(simlog.WithLogObserver(tail.Observer(nil)), ...)
+//	http.Handle(simloghttp.TailPath, tail)
+//
+// 查询参数：
+//
+//	level  只输出不低于该级别的日志，如 level=warning，默认输出所有级别
+//	n      最多输出最近的多少条日志，默认为环中的所有日志
+//	follow 为1时以 server-sent events 持续输出新日志（请求头 Accept 为 text/event-stream 时亦同）
+//
+// 注意日志中可能有敏感信息，应只在内部端口或鉴权之后提供。
+package simloghttp
+
+import (
+    "bufio"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+)
+import (
+    "github.com/eyjian/simlog"
+)
+
+// TailPath Tail 的默认路径
+const TailPath = "/debug/logs"
+
+// 每个跟踪者尚未发出的日志条数上限，跟踪者跟不上时丢弃，而不是阻塞写日志
+const followerBacklog = 256
+
+// 一条日志
+type tailLine struct {
+    level simlog.LogLevel
+    line  string
+}
+
+// 实时跟踪者
+type follower struct {
+    level simlog.LogLevel
+    lines chan string
+}
+
+// Tail 最近日志的内存环，可作为 http.Handler 提供查看和实时跟踪
+type Tail struct {
+    mutex     sync.Mutex
+    lines     []tailLine // 环形缓冲
+    next      int        // 下一条日志在环中的位置
+    full      bool       // 环是否已满
+    followers map[*follower]struct{}
+}
+
+// NewTail 创建保留最近 size 条日志的 Tail，size 小于等于0时为1000
+func NewTail(size int) *Tail {
+    if size <= 0 {
+        size = 1000
+    }
+    return &Tail{
+        lines:     make([]tailLine, size),
+        followers: make(map[*follower]struct{}),
+    }
+}
+
+// Observer 返回放入 Tail 的日志观察者，作为 WithLogObserver 的参数，
+// next 不为 nil 时，日志同时交给 next（SimLogger 只有一个观察者）。
+func (this *Tail) Observer(next simlog.LogObserver) simlog.LogObserver {
+    return func(logLevel simlog.LogLevel, logHeader string, logBody string) {
+        this.Add(logLevel, logHeader+logBody)
+        if next != nil {
+            next(logLevel, logHeader, logBody)
+        }
+    }
+}
+
+// Add 放入一条日志，并发给级别匹配的跟踪者
+func (this *Tail) Add(logLevel simlog.LogLevel, line string) {
+    line = strings.TrimSuffix(line, "\n")
+
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    this.lines[this.next] = tailLine{level: logLevel, line: line}
+    if this.next++; this.next == len(this.lines) {
+        this.next = 0
+        this.full = true
+    }
+    for f := range this.followers {
+        if !matchLevel(logLevel, f.level) {
+            continue
+        }
+        select {
+        case f.lines <- line:
+        default: // 跟踪者跟不上，丢弃
+        }
+    }
+}
+
+// Recent 返回不低于 minLevel 级别的最近至多 n 条日志（n 小于等于0表示所有），按时间先后排列
+func (this *Tail) Recent(minLevel simlog.LogLevel, n int) []string {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    return this.recent(minLevel, n)
+}
+
+func (this *Tail) recent(minLevel simlog.LogLevel, n int) []string {
+    var lines []string
+    count := this.next
+    if this.full {
+        count = len(this.lines)
+    }
+    // 从最新的往前取
+    for i := 1; i <= count && (n <= 0 || len(lines) < n); i++ {
+        pos := (this.next - i + len(this.lines)) % len(this.lines)
+        if matchLevel(this.lines[pos].level, minLevel) {
+            lines = append(lines, this.lines[pos].line)
+        }
+    }
+    for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+        lines[i], lines[j] = lines[j], lines[i]
+    }
+    return lines
+}
+
+// 开始跟踪，返回跟踪开始前的最近日志，两者之间不漏也不重
+func (this *Tail) follow(minLevel simlog.LogLevel, n int) ([]string, *follower) {
+    f := &follower{level: minLevel, lines: make(chan string, followerBacklog)}
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    this.followers[f] = struct{}{}
+    return this.recent(minLevel, n), f
+}
+
+// 结束跟踪
+func (this *Tail) unfollow(f *follower) {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    delete(this.followers, f)
+}
+
+// 日志级别是否不低于 minLevel，裸日志总是匹配
+func matchLevel(logLevel, minLevel simlog.LogLevel) bool {
+    return logLevel == simlog.LL_RAW || logLevel <= minLevel
+}
+
+// ServeHTTP 输出最近的日志，follow=1 或请求 text/event-stream 时持续输出新日志
+func (this *Tail) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    minLevel := simlog.LL_TRACE
+    if s := r.URL.Query().Get("level"); s != "" {
+        logLevel, err := simlog.ParseLogLevel(s)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        minLevel = logLevel
+    }
+    n := 0
+    if s := r.URL.Query().Get("n"); s != "" {
+        num, err := strconv.Atoi(s)
+        if err != nil || num < 0 {
+            http.Error(w, "simlog: invalid n "+strconv.Quote(s), http.StatusBadRequest)
+            return
+        }
+        n = num
+    }
+
+    if r.URL.Query().Get("follow") != "1" && !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+        w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+        for _, line := range this.Recent(minLevel, n) {
+            w.Write([]byte(line + "\n"))
+        }
+        return
+    }
+
+    flusher, ok := w.(http.Flusher)
+    if !ok {
+        http.Error(w, "simlog: streaming unsupported", http.StatusInternalServerError)
+        return
+    }
+    lines, f := this.follow(minLevel, n)
+    defer this.unfollow(f)
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    writer := bufio.NewWriter(w)
+    for _, line := range lines {
+        writeEvent(writer, line)
+    }
+    writer.Flush()
+    flusher.Flush()
+    for {
+        select {
+        case <-r.Context().Done():
+            return
+        case line := <-f.lines:
+            writeEvent(writer, line)
+            for len(f.lines) > 0 { // 一并发出已积压的
+                writeEvent(writer, <-f.lines)
+            }
+            if writer.Flush() != nil {
+                return
+            }
+            flusher.Flush()
+        }
+    }
+}
+
+// 写一个 server-sent event，多行日志的每行各为一个 data 字段
+func writeEvent(writer *bufio.Writer, line string) {
+    for _, data := range strings.Split(line, "\n") {
+        writer.WriteString("data: ")
+        writer.WriteString(data)
+        writer.WriteString("\n")
+    }
+    writer.WriteString("\n")
+}
@@ -0,0 +1,50 @@
+//go:build android && cgo
+
+package simlogmobile
+
+/*
+#cgo LDFLAGS: -llog
+#include <stdlib.h>
+#include <android/log.h>
+*/
+import "C"
+
+import (
+    "unsafe"
+)
+import (
+    "github.com/eyjian/simlog"
+)
+
+// Android 的 logcat
+type systemLog struct {
+    tag *C.char // 常驻，不释放
+}
+
+func newSystemLog(tag string) *systemLog {
+    return &systemLog{tag: C.CString(tag)}
+}
+
+func (this *systemLog) write(logLevel simlog.LogLevel, line string) {
+    text := C.CString(line)
+    defer C.free(unsafe.Pointer(text))
+    C.__android_log_write(C.int(logcatPriority(logLevel)), this.tag, text)
+}
+
+// simlog 的日志级别对应的 logcat 优先级
+func logcatPriority(logLevel simlog.LogLevel) C.int {
+    switch logLevel {
+    case simlog.LL_FATAL:
+        return C.ANDROID_LOG_FATAL
+    case simlog.LL_ERROR:
+        return C.ANDROID_LOG_ERROR
+    case simlog.LL_WARNING:
+        return C.ANDROID_LOG_WARN
+    case simlog.LL_DEBUG:
+        return C.ANDROID_LOG_DEBUG
+    case simlog.LL_DETAIL, simlog.LL_TRACE:
+        return C.ANDROID_LOG_VERBOSE
+    default:
+        return C.ANDROID_LOG_INFO
+    }
+}
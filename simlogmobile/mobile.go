@@ -0,0 +1,42 @@
+// Package simlogmobile 将 simlog 的日志同时输出到移动平台的系统日志：Android 的 logcat 和 iOS 的 os_log，
+// 以便通过 gomobile 绑定的、已在使用 simlog 的库在设备上可调试：
+//
+//	logger.Init(simlog.WithLogObserver(simlogmobile.Observer("mylib", nil)), ...)
+//
+// 需要 cgo（gomobile 编译时总是开启），Android 链接 liblog，iOS 使用 os_log（iOS 10 及以上）。
+// 其它平台或未开启 cgo 时不输出到系统日志，以便共享代码照常编译运行。
+//
+// 日志级别的对应关系：
+//
+//	simlog          logcat    os_log
+//	FATAL           FATAL     FAULT
+//	ERROR           ERROR     ERROR
+//	WARNING         WARN      DEFAULT
+//	NOTICE、INFO    INFO      DEFAULT、INFO
+//	DEBUG           DEBUG     DEBUG
+//	DETAIL、TRACE   VERBOSE   DEBUG
+//	RAW             INFO      DEFAULT
+package simlogmobile
+
+import (
+    "strings"
+)
+import (
+    "github.com/eyjian/simlog"
+)
+
+// Observer 返回输出到系统日志的日志观察者，作为 WithLogObserver 的参数，
+// tag 为 logcat 的标签或 os_log 的 subsystem，
+// next 不为 nil 时，日志同时交给 next（SimLogger 只有一个观察者）。
+func Observer(tag string, next simlog.LogObserver) simlog.LogObserver {
+    sink := newSystemLog(tag)
+    return func(logLevel simlog.LogLevel, logHeader string, logBody string) {
+        if sink != nil {
+            // 系统日志自带时间和级别，但日志头中还有标签和调用者等，所以整行输出
+            sink.write(logLevel, strings.TrimSuffix(logHeader+logBody, "\n"))
+        }
+        if next != nil {
+            next(logLevel, logHeader, logBody)
+        }
+    }
+}
@@ -0,0 +1,56 @@
+//go:build ios && cgo
+
+package simlogmobile
+
+/*
+#include <stdlib.h>
+#include <os/log.h>
+
+// os_log_with_type 是宏且格式串须为字面量，所以包一层；%{public}s 使日志在设备日志中不被隐去
+static void simlog_os_log(os_log_t log, os_log_type_t type, const char *text) {
+    os_log_with_type(log, type, "%{public}s", text);
+}
+*/
+import "C"
+
+import (
+    "unsafe"
+)
+import (
+    "github.com/eyjian/simlog"
+)
+
+// iOS 的 os_log
+type systemLog struct {
+    log C.os_log_t
+}
+
+func newSystemLog(tag string) *systemLog {
+    subsystem := C.CString(tag)
+    defer C.free(unsafe.Pointer(subsystem))
+    category := C.CString("simlog")
+    defer C.free(unsafe.Pointer(category))
+    return &systemLog{log: C.os_log_create(subsystem, category)}
+}
+
+func (this *systemLog) write(logLevel simlog.LogLevel, line string) {
+    text := C.CString(line)
+    defer C.free(unsafe.Pointer(text))
+    C.simlog_os_log(this.log, osLogType(logLevel), text)
+}
+
+// simlog 的日志级别对应的 os_log 类型
+func osLogType(logLevel simlog.LogLevel) C.os_log_type_t {
+    switch logLevel {
+    case simlog.LL_FATAL:
+        return C.OS_LOG_TYPE_FAULT
+    case simlog.LL_ERROR:
+        return C.OS_LOG_TYPE_ERROR
+    case simlog.LL_INFO:
+        return C.OS_LOG_TYPE_INFO
+    case simlog.LL_DEBUG, simlog.LL_DETAIL, simlog.LL_TRACE:
+        return C.OS_LOG_TYPE_DEBUG
+    default:
+        return C.OS_LOG_TYPE_DEFAULT
+    }
+}
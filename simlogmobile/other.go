@@ -0,0 +1,17 @@
+//go:build !((android || ios) && cgo)
+
+package simlogmobile
+
+import (
+    "github.com/eyjian/simlog"
+)
+
+// 其它平台没有系统日志，newSystemLog 返回 nil
+type systemLog struct{}
+
+func newSystemLog(tag string) *systemLog {
+    return nil
+}
+
+func (this *systemLog) write(logLevel simlog.LogLevel, line string) {
+}
@@ -0,0 +1,24 @@
+module github.com/eyjian/simlog/simlogotlp
+
+go 1.21.0
+
+replace github.com/eyjian/simlog => ../
+
+require (
+	github.com/eyjian/simlog v0.0.5
+	go.opentelemetry.io/proto/otlp v1.1.0
+	google.golang.org/grpc v1.62.1
+)
+
+require (
+	github.com/gofrs/flock v0.12.1 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.19.0 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20240123012728-ef4313101c80 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240123012728-ef4313101c80 // indirect
+	google.golang.org/protobuf v1.32.0 // indirect
+)
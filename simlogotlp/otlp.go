@@ -0,0 +1,480 @@
+// Package simlogotlp 将 simlog 的日志经 OTLP/gRPC 导出到 OpenTelemetry Collector，
+// 以便日志和链路、指标汇入同一个可观测性管道：
+//
+//	sink, err := simlogotlp.NewSink("otel-collector:4317", simlogotlp.WithInsecure())
+//	logger.InitE(simlog.WithSink(sink), simlog.EnableKVExtraction(true))
+//
+// 日志级别对应为 OTLP 的 SeverityNumber（级别名为 SeverityText），日志体为 Body，
+// 服务名和版本为资源的 service.name 和 service.version，调用者为 code.filepath、code.lineno 和 code.function，
+// 标签和错误码为 simlog.tags 和 simlog.code，结构化字段（WithFields 或 EnableKVExtraction）为同名属性；
+// 字段中有 trace_id 和 span_id（十六进制，见 WithTraceFields）时作为日志记录的 TraceId 和 SpanId，以便和链路关联。
+package simlogotlp
+
+import (
+    "context"
+    "encoding/hex"
+    "fmt"
+    "os"
+    "sort"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+import (
+    "github.com/eyjian/simlog"
+    collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+    commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+    logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+    resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/metadata"
+)
+
+// 默认的队列大小、每批的最大条数、导出间隔和每次导出的超时
+const (
+    defaultQueueSize     = 10000
+    defaultBatchSize     = 512
+    defaultFlushInterval = time.Second
+    defaultTimeout       = 10 * time.Second
+)
+
+// 本包在 OTLP 中的 InstrumentationScope 名
+const scopeName = "github.com/eyjian/simlog"
+
+// Option NewSink 的选项
+type Option func(*config)
+
+type config struct {
+    insecure      bool
+    headers       map[string]string
+    resource      map[string]string
+    queueSize     int
+    batchSize     int
+    flushInterval time.Duration
+    timeout       time.Duration
+    traceKey      string
+    spanKey       string
+    errorHandler  simlog.ErrorHandler
+    dialOptions   []grpc.DialOption
+}
+
+// WithInsecure 不使用 TLS 连接（如连接本机或同一 Pod 中的 Collector）
+func WithInsecure() Option {
+    return func(c *config) {
+        c.insecure = true
+    }
+}
+
+// WithHeaders 设置每次导出时附带的 gRPC 元数据（如鉴权头）
+func WithHeaders(headers map[string]string) Option {
+    return func(c *config) {
+        c.headers = headers
+    }
+}
+
+// WithResource 设置资源的其它属性（如 host.name、deployment.environment），
+// service.name 和 service.version 默认取自日志的 WithService，在此设置时以此为准
+func WithResource(attributes map[string]string) Option {
+    return func(c *config) {
+        c.resource = attributes
+    }
+}
+
+// WithQueueSize 设置待导出的日志的队列大小（默认为10000），队列满时丢弃并返回 simlog.ErrQueueFull
+func WithQueueSize(queueSize int) Option {
+    return func(c *config) {
+        if queueSize > 0 {
+            c.queueSize = queueSize
+        }
+    }
+}
+
+// WithBatchSize 设置每次导出的最大条数（默认为512）
+func WithBatchSize(batchSize int) Option {
+    return func(c *config) {
+        if batchSize > 0 {
+            c.batchSize = batchSize
+        }
+    }
+}
+
+// WithFlushInterval 设置不足一批时的导出间隔（默认为1秒）
+func WithFlushInterval(flushInterval time.Duration) Option {
+    return func(c *config) {
+        if flushInterval > 0 {
+            c.flushInterval = flushInterval
+        }
+    }
+}
+
+// WithTimeout 设置每次导出的超时（默认为10秒），关闭时最多等待此时长导出剩余的日志
+func WithTimeout(timeout time.Duration) Option {
+    return func(c *config) {
+        if timeout > 0 {
+            c.timeout = timeout
+        }
+    }
+}
+
+// WithTraceFields 设置作为 TraceId 和 SpanId 的结构化字段名（默认为“trace_id”和“span_id”），
+// 字段值为32个和16个十六进制字符，作为 TraceId 和 SpanId 的字段不再作为属性
+func WithTraceFields(traceKey, spanKey string) Option {
+    return func(c *config) {
+        c.traceKey = traceKey
+        c.spanKey = spanKey
+    }
+}
+
+// WithErrorHandler 设置导出失败的处理函数，错误包装了 simlog.ErrSinkUnavailable，
+// 未设置时输出到标准错误（连续失败时只输出第一次）
+func WithErrorHandler(handler simlog.ErrorHandler) Option {
+    return func(c *config) {
+        c.errorHandler = handler
+    }
+}
+
+// WithDialOptions 追加建立 gRPC 连接的选项（如 TLS 证书、拦截器）
+func WithDialOptions(opts ...grpc.DialOption) Option {
+    return func(c *config) {
+        c.dialOptions = append(c.dialOptions, opts...)
+    }
+}
+
+// Sink 经 OTLP/gRPC 导出日志的 simlog.Sink
+type Sink struct {
+    dropped  int64 // 放在最前面，以保证在32位平台上原子操作时的8字节对齐
+    failing  int32 // 上次导出是否失败
+    closed   bool
+    config   config
+    conn     *grpc.ClientConn
+    client   collogspb.LogsServiceClient
+    queue    chan *pendingRecord
+    closeMux sync.RWMutex // 保护关闭 queue 和 Write 之间的竞争
+    ctx      context.Context
+    cancel   context.CancelFunc // 关闭时超时后取消尚未完成的导出
+    done     chan struct{}
+}
+
+var _ simlog.Sink = (*Sink)(nil)
+
+// 待导出的一条日志及其资源
+type pendingRecord struct {
+    service string
+    version string
+    record  *logspb.LogRecord
+}
+
+// NewSink 创建导出到 endpoint（如“localhost:4317”）的输出，连接在后台建立，
+// 日志放入队列后由导出协程按批导出，导出失败的一批被丢弃（计入 Close 返回的错误），不重试，不阻塞写日志。
+func NewSink(endpoint string, opts ...Option) (*Sink, error) {
+    c := config{
+        queueSize:     defaultQueueSize,
+        batchSize:     defaultBatchSize,
+        flushInterval: defaultFlushInterval,
+        timeout:       defaultTimeout,
+        traceKey:      "trace_id",
+        spanKey:       "span_id",
+    }
+    for _, opt := range opts {
+        opt(&c)
+    }
+    dialOptions := c.dialOptions
+    if c.insecure {
+        dialOptions = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, dialOptions...)
+    }
+    conn, err := grpc.Dial(endpoint, dialOptions...)
+    if err != nil {
+        return nil, err
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    sink := &Sink{
+        ctx:    ctx,
+        cancel: cancel,
+        config: c,
+        conn:   conn,
+        client: collogspb.NewLogsServiceClient(conn),
+        queue:  make(chan *pendingRecord, c.queueSize),
+        done:   make(chan struct{}),
+    }
+    go sink.run()
+    return sink, nil
+}
+
+// Write 转换为 OTLP 的日志记录并放入队列，队列满时返回 simlog.ErrQueueFull，关闭后返回 simlog.ErrClosed
+func (this *Sink) Write(entry simlog.Entry) error {
+    this.closeMux.RLock()
+    defer this.closeMux.RUnlock()
+    if this.closed {
+        return simlog.ErrClosed
+    }
+    pending := &pendingRecord{
+        service: entry.Service,
+        version: entry.Version,
+        record:  this.newRecord(&entry),
+    }
+    select {
+    case this.queue <- pending:
+        return nil
+    default:
+        atomic.AddInt64(&this.dropped, 1)
+        return simlog.ErrQueueFull
+    }
+}
+
+// Close 导出队列中剩余的日志（最多等待 WithTimeout 设置的时长，超时后剩余的均被丢弃）后关闭连接，
+// 返回因队列满或导出失败而丢弃的条数的错误
+func (this *Sink) Close() error {
+    this.closeMux.Lock()
+    if this.closed {
+        this.closeMux.Unlock()
+        return nil
+    }
+    this.closed = true
+    close(this.queue)
+    this.closeMux.Unlock()
+
+    timer := time.AfterFunc(this.config.timeout, this.cancel)
+    <-this.done
+    timer.Stop()
+    this.cancel()
+    err := this.conn.Close()
+    if dropped := atomic.LoadInt64(&this.dropped); dropped > 0 {
+        return fmt.Errorf("%w: %d log records to OTLP dropped", simlog.ErrQueueFull, dropped)
+    }
+    return err
+}
+
+// 导出协程：攒够一批或到了导出间隔时导出
+func (this *Sink) run() {
+    defer close(this.done)
+    ticker := time.NewTicker(this.config.flushInterval)
+    defer ticker.Stop()
+
+    batch := make([]*pendingRecord, 0, this.config.batchSize)
+    for {
+        select {
+        case pending, ok := <-this.queue:
+            if !ok {
+                this.export(batch)
+                return
+            }
+            if batch = append(batch, pending); len(batch) >= this.config.batchSize {
+                this.export(batch)
+                batch = batch[:0]
+            }
+        case <-ticker.C:
+            this.export(batch)
+            batch = batch[:0]
+        }
+    }
+}
+
+// 导出一批，失败时丢弃并通知
+func (this *Sink) export(batch []*pendingRecord) {
+    if len(batch) == 0 {
+        return
+    }
+    ctx, cancel := context.WithTimeout(this.ctx, this.config.timeout)
+    defer cancel()
+    if len(this.config.headers) > 0 {
+        ctx = metadata.NewOutgoingContext(ctx, metadata.New(this.config.headers))
+    }
+
+    _, err := this.client.Export(ctx, &collogspb.ExportLogsServiceRequest{ResourceLogs: this.resourceLogs(batch)})
+    if err == nil {
+        if atomic.CompareAndSwapInt32(&this.failing, 1, 0) && this.config.errorHandler == nil {
+            fmt.Fprintf(os.Stderr, "simlog: OTLP export recovered\n")
+        }
+        return
+    }
+    atomic.AddInt64(&this.dropped, int64(len(batch)))
+    err = fmt.Errorf("%w: OTLP export of %d log records: %w", simlog.ErrSinkUnavailable, len(batch), err)
+    if this.config.errorHandler != nil {
+        this.config.errorHandler(err)
+    } else if atomic.CompareAndSwapInt32(&this.failing, 0, 1) {
+        fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+    }
+}
+
+// 按服务名和版本将一批日志分组为 ResourceLogs
+func (this *Sink) resourceLogs(batch []*pendingRecord) []*logspb.ResourceLogs {
+    var result []*logspb.ResourceLogs
+    index := make(map[[2]string]*logspb.ScopeLogs)
+    for _, pending := range batch {
+        key := [2]string{pending.service, pending.version}
+        scopeLogs, ok := index[key]
+        if !ok {
+            scopeLogs = &logspb.ScopeLogs{Scope: &commonpb.InstrumentationScope{Name: scopeName}}
+            index[key] = scopeLogs
+            result = append(result, &logspb.ResourceLogs{
+                Resource:  &resourcepb.Resource{Attributes: this.resourceAttributes(pending.service, pending.version)},
+                ScopeLogs: []*logspb.ScopeLogs{scopeLogs},
+            })
+        }
+        scopeLogs.LogRecords = append(scopeLogs.LogRecords, pending.record)
+    }
+    return result
+}
+
+func (this *Sink) resourceAttributes(service, version string) []*commonpb.KeyValue {
+    attributes := make(map[string]string, len(this.config.resource)+2)
+    if service != "" {
+        attributes["service.name"] = service
+    }
+    if version != "" {
+        attributes["service.version"] = version
+    }
+    for key, value := range this.config.resource {
+        attributes[key] = value
+    }
+
+    keys := make([]string, 0, len(attributes))
+    for key := range attributes {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    kvs := make([]*commonpb.KeyValue, 0, len(keys))
+    for _, key := range keys {
+        kvs = append(kvs, keyValue(key, attributes[key]))
+    }
+    return kvs
+}
+
+// 转换为 OTLP 的日志记录
+func (this *Sink) newRecord(entry *simlog.Entry) *logspb.LogRecord {
+    record := &logspb.LogRecord{
+        TimeUnixNano:         uint64(entry.Time.UnixNano()),
+        ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+        SeverityNumber:       SeverityNumber(entry.Level),
+        Body:                 stringValue(entry.Body),
+    }
+    if entry.Level != simlog.LL_RAW {
+        record.SeverityText = simlog.GetLogLevelName(entry.Level)
+    }
+    if entry.Caller.File != "" {
+        record.Attributes = append(record.Attributes,
+            keyValue("code.filepath", entry.Caller.File),
+            &commonpb.KeyValue{Key: "code.lineno", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(entry.Caller.Line)}}})
+        if entry.Caller.Func != "" {
+            record.Attributes = append(record.Attributes, keyValue("code.function", entry.Caller.Func))
+        }
+    }
+    if len(entry.Tags) > 0 {
+        tags := make([]*commonpb.AnyValue, 0, len(entry.Tags))
+        for _, tag := range entry.Tags {
+            tags = append(tags, stringValue(tag))
+        }
+        record.Attributes = append(record.Attributes, &commonpb.KeyValue{
+            Key:   "simlog.tags",
+            Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: tags}}},
+        })
+    }
+    if entry.Code != "" {
+        record.Attributes = append(record.Attributes, keyValue("simlog.code", entry.Code))
+    }
+
+    keys := make([]string, 0, len(entry.Fields))
+    for key := range entry.Fields {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        value := entry.Fields[key]
+        if key == this.config.traceKey {
+            if id, ok := decodeID(value, 16); ok {
+                record.TraceId = id
+                continue
+            }
+        } else if key == this.config.spanKey {
+            if id, ok := decodeID(value, 8); ok {
+                record.SpanId = id
+                continue
+            }
+        }
+        record.Attributes = append(record.Attributes, &commonpb.KeyValue{Key: key, Value: anyValue(value)})
+    }
+    return record
+}
+
+// SeverityNumber 返回日志级别对应的 OTLP 严重性：FATAL、ERROR、WARNING、INFO、DEBUG 和 TRACE 为同名的严重性，
+// NOTICE 为 INFO2（比 INFO 严重），DETAIL 为 TRACE4（介于 DEBUG 和 TRACE 之间），裸日志为 UNSPECIFIED
+func SeverityNumber(logLevel simlog.LogLevel) logspb.SeverityNumber {
+    switch logLevel {
+    case simlog.LL_FATAL:
+        return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+    case simlog.LL_ERROR:
+        return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+    case simlog.LL_WARNING:
+        return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+    case simlog.LL_NOTICE:
+        return logspb.SeverityNumber_SEVERITY_NUMBER_INFO2
+    case simlog.LL_INFO:
+        return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+    case simlog.LL_DEBUG:
+        return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+    case simlog.LL_DETAIL:
+        return logspb.SeverityNumber_SEVERITY_NUMBER_TRACE4
+    case simlog.LL_TRACE:
+        return logspb.SeverityNumber_SEVERITY_NUMBER_TRACE
+    default:
+        return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+    }
+}
+
+// 解析十六进制的 TraceId 或 SpanId，全零的无效
+func decodeID(value interface{}, size int) ([]byte, bool) {
+    s, ok := value.(string)
+    if !ok || len(s) != size*2 {
+        return nil, false
+    }
+    id, err := hex.DecodeString(s)
+    if err != nil {
+        return nil, false
+    }
+    for _, b := range id {
+        if b != 0 {
+            return id, true
+        }
+    }
+    return nil, false
+}
+
+func keyValue(key, value string) *commonpb.KeyValue {
+    return &commonpb.KeyValue{Key: key, Value: stringValue(value)}
+}
+
+func stringValue(s string) *commonpb.AnyValue {
+    return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
+
+// 结构化字段的值转换为 AnyValue，数值和布尔值保留类型，其它的转换为字符串
+func anyValue(value interface{}) *commonpb.AnyValue {
+    switch v := value.(type) {
+    case string:
+        return stringValue(v)
+    case bool:
+        return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+    case int:
+        return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+    case int32:
+        return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+    case int64:
+        return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+    case uint32:
+        return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+    case float32:
+        return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v)}}
+    case float64:
+        return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+    case []byte:
+        return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v}}
+    case error:
+        return stringValue(v.Error())
+    case fmt.Stringer:
+        return stringValue(v.String())
+    default:
+        return stringValue(fmt.Sprint(v))
+    }
+}
@@ -0,0 +1,16 @@
+module github.com/eyjian/simlog/simlogrus
+
+go 1.21.0
+
+replace github.com/eyjian/simlog => ../
+
+require (
+	github.com/eyjian/simlog v0.0.5
+	github.com/sirupsen/logrus v1.9.4
+)
+
+require (
+	github.com/gofrs/flock v0.12.1 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+)
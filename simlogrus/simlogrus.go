@@ -0,0 +1,155 @@
+// Package simlogrus 将 logrus 的日志（包括字段）转发到 simlog，
+// 以便同时使用 logrus 和 simlog 的代码在迁移期间输出到同一个滚动的日志文件。
+//
+// 两种用法任选其一：
+// 1）作为 Hook，logrus 原有的输出不变，同时转发到 simlog：
+// logrus.AddHook(simlogrus.NewHook(&mylog))
+// 2）作为 Formatter，只转发到 simlog，logrus 自身不再输出：
+// simlogrus.Install(logrus.StandardLogger(), &mylog)
+//
+// 字段以“ key=value”的形式按 key 排序追加到日志体之后，
+// logrus 开启 ReportCaller 时使用 logrus 记录的调用者。
+package simlogrus
+
+import (
+    "fmt"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+)
+import (
+    "github.com/eyjian/simlog"
+    "github.com/sirupsen/logrus"
+)
+
+// Hook 实现 logrus.Hook，将 logrus 的日志转发到 simlog
+type Hook struct {
+    logger *simlog.SimLogger
+    levels []logrus.Level
+}
+
+// NewHook 创建转发到 logger 的 Hook，levels 为空时转发所有级别
+func NewHook(logger *simlog.SimLogger, levels ...logrus.Level) *Hook {
+    if len(levels) == 0 {
+        levels = logrus.AllLevels
+    }
+    return &Hook{logger: logger, levels: levels}
+}
+
+// Levels 实现 logrus.Hook
+func (this *Hook) Levels() []logrus.Level {
+    return this.levels
+}
+
+// Fire 实现 logrus.Hook
+func (this *Hook) Fire(entry *logrus.Entry) error {
+    _, err := forward(this.logger, entry)
+    return err
+}
+
+// Formatter 实现 logrus.Formatter，将 logrus 的日志转发到 simlog，返回空内容
+type Formatter struct {
+    logger *simlog.SimLogger
+}
+
+// NewFormatter 创建转发到 logger 的 Formatter
+func NewFormatter(logger *simlog.SimLogger) *Formatter {
+    return &Formatter{logger: logger}
+}
+
+// Format 实现 logrus.Formatter
+func (this *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+    _, err := forward(this.logger, entry)
+    return nil, err
+}
+
+// Install 让 logrusLogger 只通过 simLogger 输出：
+// 设置 Formatter 为转发到 simLogger，输出为 io.Discard，
+// 并将 logrus 的级别设为 simLogger 的级别（更低级别的日志不必再构造）。
+func Install(logrusLogger *logrus.Logger, simLogger *simlog.SimLogger) {
+    logrusLogger.SetFormatter(NewFormatter(simLogger))
+    logrusLogger.SetOutput(io.Discard)
+    logrusLogger.SetLevel(levelFromSimlog(simlog.LogLevel(simLogger.GetLogLevel())))
+}
+
+func forward(logger *simlog.SimLogger, entry *logrus.Entry) (int, error) {
+    var caller simlog.Caller
+    if entry.HasCaller() {
+        caller.File = entry.Caller.File
+        caller.Line = entry.Caller.Line
+    }
+    return logger.Output(levelToSimlog(entry.Level), caller, formatBody(entry))
+}
+
+// 日志体：消息之后按 key 排序追加字段
+func formatBody(entry *logrus.Entry) string {
+    body := strings.TrimSuffix(entry.Message, "\n")
+    if len(entry.Data) == 0 {
+        return body
+    }
+
+    keys := make([]string, 0, len(entry.Data))
+    for k := range entry.Data {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+
+    var sb strings.Builder
+    sb.WriteString(body)
+    for _, k := range keys {
+        var value string
+        switch v := entry.Data[k].(type) {
+        case error:
+            value = v.Error()
+        case string:
+            value = v
+        default:
+            value = fmt.Sprint(v)
+        }
+        if value == "" || strings.ContainsAny(value, " \t\r\n\"=") {
+            value = strconv.Quote(value)
+        }
+        sb.WriteString(" ")
+        sb.WriteString(k)
+        sb.WriteString("=")
+        sb.WriteString(value)
+    }
+    return sb.String()
+}
+
+// logrus 级别到 simlog 级别，logrus 的 TRACE 比 DEBUG 更详细，对应 simlog 的 DETAIL
+func levelToSimlog(level logrus.Level) simlog.LogLevel {
+    switch level {
+    case logrus.PanicLevel, logrus.FatalLevel:
+        return simlog.LL_FATAL
+    case logrus.ErrorLevel:
+        return simlog.LL_ERROR
+    case logrus.WarnLevel:
+        return simlog.LL_WARNING
+    case logrus.InfoLevel:
+        return simlog.LL_INFO
+    case logrus.DebugLevel:
+        return simlog.LL_DEBUG
+    default:
+        return simlog.LL_DETAIL
+    }
+}
+
+// simlog 级别到 logrus 级别
+func levelFromSimlog(logLevel simlog.LogLevel) logrus.Level {
+    switch logLevel {
+    case simlog.LL_FATAL:
+        return logrus.FatalLevel
+    case simlog.LL_ERROR:
+        return logrus.ErrorLevel
+    case simlog.LL_WARNING:
+        return logrus.WarnLevel
+    case simlog.LL_NOTICE, simlog.LL_INFO:
+        return logrus.InfoLevel
+    case simlog.LL_DEBUG:
+        return logrus.DebugLevel
+    default:
+        return logrus.TraceLevel
+    }
+}
@@ -0,0 +1,17 @@
+module github.com/eyjian/simlog/simlogsentry
+
+go 1.21.0
+
+replace github.com/eyjian/simlog => ../
+
+require (
+	github.com/eyjian/simlog v0.0.5
+	github.com/getsentry/sentry-go v0.27.0
+)
+
+require (
+	github.com/gofrs/flock v0.12.1 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+)
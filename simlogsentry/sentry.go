@@ -0,0 +1,147 @@
+// Package simlogsentry 将 simlog 的 ERROR 和 FATAL 日志作为事件转发到 Sentry，更低级别的日志仍只写日志文件：
+//
+//	sentry.Init(sentry.ClientOptions{Dsn: dsn})
+//	logger.InitE(simlog.WithSink(simlogsentry.NewSink()), simlog.EnableLogCaller(true))
+//
+// 日志体为事件的消息（异常的 Value 为第一行），错误码（WithCode）为异常的 Type 以便 Sentry 按错误码归并，
+// 未设置错误码时为级别名；服务名和版本为 Release，标签为 Tags，结构化字段为名为“fields”的 Context。
+// 记录了调用者（EnableLogCaller）时附带写日志处的调用栈（去掉 simlog 内部的栈帧）。
+package simlogsentry
+
+import (
+    "strings"
+    "time"
+)
+import (
+    "github.com/eyjian/simlog"
+    "github.com/getsentry/sentry-go"
+)
+
+// 关闭或写 FATAL 日志时等待事件发送完的默认时长
+const defaultFlushTimeout = 2 * time.Second
+
+// simlog 的包路径，这些包中的栈帧不出现在事件的调用栈中
+const simlogModule = "github.com/eyjian/simlog"
+
+// Option NewSink 的选项
+type Option func(*Sink)
+
+// WithHub 设置发送事件的 Hub（默认为 sentry.CurrentHub()）
+func WithHub(hub *sentry.Hub) Option {
+    return func(s *Sink) {
+        s.hub = hub
+    }
+}
+
+// WithFlushTimeout 设置关闭或写 FATAL 日志时等待事件发送完的时长（默认为2秒）
+func WithFlushTimeout(timeout time.Duration) Option {
+    return func(s *Sink) {
+        if timeout > 0 {
+            s.flushTimeout = timeout
+        }
+    }
+}
+
+// Sink 将 ERROR 和 FATAL 日志转发到 Sentry 的 simlog.Sink
+type Sink struct {
+    hub          *sentry.Hub
+    flushTimeout time.Duration
+}
+
+var _ simlog.Sink = (*Sink)(nil)
+
+// NewSink 创建转发到 Sentry 的输出，Sentry 的客户端须已初始化（sentry.Init 或 WithHub）。
+// 写 FATAL 日志时等待事件发送完，以免随后退出进程时丢失。
+func NewSink(opts ...Option) *Sink {
+    sink := &Sink{flushTimeout: defaultFlushTimeout}
+    for _, opt := range opts {
+        opt(sink)
+    }
+    if sink.hub == nil {
+        sink.hub = sentry.CurrentHub()
+    }
+    return sink
+}
+
+// Write 转发 ERROR 和 FATAL 日志，其它级别的忽略
+func (this *Sink) Write(entry simlog.Entry) error {
+    if entry.Level != simlog.LL_ERROR && entry.Level != simlog.LL_FATAL {
+        return nil
+    }
+    this.hub.CaptureEvent(newEvent(&entry))
+    if entry.Level == simlog.LL_FATAL {
+        this.hub.Flush(this.flushTimeout)
+    }
+    return nil
+}
+
+// Close 等待事件发送完，不关闭 Sentry 的客户端
+func (this *Sink) Close() error {
+    this.hub.Flush(this.flushTimeout)
+    return nil
+}
+
+// 转换为 Sentry 的事件
+func newEvent(entry *simlog.Entry) *sentry.Event {
+    event := sentry.NewEvent()
+    event.Timestamp = entry.Time
+    event.Logger = "simlog"
+    event.Message = strings.TrimRight(entry.Body, "\r\n")
+    if entry.Level == simlog.LL_FATAL {
+        event.Level = sentry.LevelFatal
+    } else {
+        event.Level = sentry.LevelError
+    }
+    if entry.Service != "" {
+        event.Release = entry.Service
+        if entry.Version != "" {
+            event.Release += "@" + entry.Version
+        }
+        event.Tags["service"] = entry.Service
+    }
+    if entry.Code != "" {
+        event.Tags["code"] = entry.Code
+    }
+    if len(entry.Tags) > 0 {
+        event.Tags["tags"] = strings.Join(entry.Tags, ",")
+    }
+    if len(entry.Fields) > 0 {
+        fields := make(sentry.Context, len(entry.Fields))
+        for key, value := range entry.Fields {
+            fields[key] = value
+        }
+        event.Contexts["fields"] = fields
+    }
+
+    exceptionType := entry.Code
+    if exceptionType == "" {
+        exceptionType = simlog.GetLogLevelName(entry.Level)
+    }
+    value, _, _ := strings.Cut(event.Message, "\n")
+    exception := sentry.Exception{Type: exceptionType, Value: value}
+    if entry.Caller.File != "" {
+        exception.Stacktrace = callerStacktrace()
+    }
+    event.Exception = []sentry.Exception{exception}
+    return event
+}
+
+// 写日志处的调用栈：输出在写日志的协程中同步调用，去掉 simlog 内部的栈帧即为调用者的栈
+func callerStacktrace() *sentry.Stacktrace {
+    stacktrace := sentry.NewStacktrace()
+    if stacktrace == nil {
+        return nil
+    }
+    frames := stacktrace.Frames[:0]
+    for _, frame := range stacktrace.Frames {
+        if frame.Module == simlogModule || strings.HasPrefix(frame.Module, simlogModule+"/") {
+            continue
+        }
+        frames = append(frames, frame)
+    }
+    if len(frames) == 0 {
+        return nil
+    }
+    stacktrace.Frames = frames
+    return stacktrace
+}
@@ -0,0 +1,449 @@
+// Package simlogsql 为 database/sql 的驱动加上 simlog 日志：记录执行的语句、参数（可脱敏）、耗时和错误，
+// 以便统一用 simlog 观察数据库访问：
+//
+//	sqlLogger := logger.WithLabels(map[string]string{"component": "sql"})
+//	sql.Register("mysql-logged", simlogsql.Wrap(&mysql.MySQLDriver{}, sqlLogger, simlogsql.WithSlowQuery(100*time.Millisecond)))
+//	db, err := sql.Open("mysql-logged", dsn)
+//
+// 或 db := sql.OpenDB(simlogsql.WrapConnector(connector, sqlLogger))。
+// 每条语句输出一条日志，键值对形式便于 EnableKVExtraction 提取，如：
+//
+//	sql exec duration=1.234ms rows=1 query="UPDATE user SET name=? WHERE id=?" args=[$1="bob" $2=42]
+//
+// 参数以“名=值”的形式输出（位置参数的名为“$序号”），因此日志的 WithDenyKeys 对命名参数同样有效，
+// 另可用 WithArgRedactor 自定义脱敏。默认不输出参数，见 WithArgs。
+package simlogsql
+
+import (
+    "context"
+    "database/sql/driver"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+import (
+    "github.com/eyjian/simlog"
+)
+
+// ArgRedactor 参数脱敏函数，返回要输出的值，name 为命名参数的名，位置参数为“$序号”（从1开始）
+type ArgRedactor func(query string, name string, value interface{}) interface{}
+
+// Option 日志选项
+type Option func(*options)
+
+type options struct {
+    queryLevel    simlog.LogLevel // 成功的语句的日志级别
+    slowLevel     simlog.LogLevel // 慢语句的日志级别
+    slowThreshold time.Duration   // 慢语句的耗时阈值，小于等于0表示不区分
+    errorLevel    simlog.LogLevel // 失败的语句的日志级别
+    logArgs       bool            // 是否输出参数
+    argRedactor   ArgRedactor     // 参数脱敏函数
+}
+
+// WithQueryLevel 设置成功的语句的日志级别（默认为 LL_DEBUG）
+func WithQueryLevel(logLevel simlog.LogLevel) Option {
+    return func(o *options) {
+        o.queryLevel = logLevel
+    }
+}
+
+// WithSlowQuery 耗时不少于 threshold 的语句以 LL_WARNING 级别输出（默认不区分慢语句）
+func WithSlowQuery(threshold time.Duration) Option {
+    return func(o *options) {
+        o.slowThreshold = threshold
+    }
+}
+
+// WithSlowLevel 设置慢语句的日志级别（默认为 LL_WARNING）
+func WithSlowLevel(logLevel simlog.LogLevel) Option {
+    return func(o *options) {
+        o.slowLevel = logLevel
+    }
+}
+
+// WithErrorLevel 设置失败的语句的日志级别（默认为 LL_ERROR）
+func WithErrorLevel(logLevel simlog.LogLevel) Option {
+    return func(o *options) {
+        o.errorLevel = logLevel
+    }
+}
+
+// WithArgs 是否输出语句的参数（默认为 false），参数中可能有敏感信息
+func WithArgs(enabled bool) Option {
+    return func(o *options) {
+        o.logArgs = enabled
+    }
+}
+
+// WithArgRedactor 设置参数脱敏函数，同时开启输出参数
+func WithArgRedactor(redactor ArgRedactor) Option {
+    return func(o *options) {
+        o.logArgs = true
+        o.argRedactor = redactor
+    }
+}
+
+// Wrap 返回给 d 的连接加上日志的驱动，用 sql.Register 注册后使用
+func Wrap(d driver.Driver, logger *simlog.SimLogger, opts ...Option) driver.Driver {
+    return &wrappedDriver{parent: d, logger: newLogger(logger, opts)}
+}
+
+// WrapConnector 返回给 c 的连接加上日志的 Connector，用 sql.OpenDB 打开
+func WrapConnector(c driver.Connector, logger *simlog.SimLogger, opts ...Option) driver.Connector {
+    return &wrappedConnector{parent: c, logger: newLogger(logger, opts)}
+}
+
+// 输出语句日志
+type sqlLogger struct {
+    logger *simlog.SimLogger
+    opts   options
+}
+
+func newLogger(logger *simlog.SimLogger, opts []Option) *sqlLogger {
+    l := &sqlLogger{
+        logger: logger,
+        opts: options{
+            queryLevel: simlog.LL_DEBUG,
+            slowLevel:  simlog.LL_WARNING,
+            errorLevel: simlog.LL_ERROR,
+        },
+    }
+    for _, opt := range opts {
+        opt(&l.opts)
+    }
+    return l
+}
+
+// 输出一条语句的日志，op 为操作（如 exec、query、begin），rows 小于0表示不输出
+func (this *sqlLogger) log(op string, start time.Time, query string, args []driver.NamedValue, rows int64, err error) {
+    if errors.Is(err, driver.ErrSkip) {
+        return // 驱动不支持，database/sql 会改用其它方式，不是错误
+    }
+
+    duration := time.Since(start)
+    logLevel := this.opts.queryLevel
+    if err != nil {
+        logLevel = this.opts.errorLevel
+    } else if this.opts.slowThreshold > 0 && duration >= this.opts.slowThreshold {
+        logLevel = this.opts.slowLevel
+    }
+    if !this.logger.Enabled(logLevel) {
+        return
+    }
+
+    var sb strings.Builder
+    sb.WriteString("sql ")
+    sb.WriteString(op)
+    sb.WriteString(" duration=")
+    sb.WriteString(duration.String())
+    if rows >= 0 {
+        sb.WriteString(" rows=")
+        sb.WriteString(strconv.FormatInt(rows, 10))
+    }
+    if query != "" {
+        sb.WriteString(" query=")
+        sb.WriteString(strconv.Quote(query))
+    }
+    if this.opts.logArgs && len(args) > 0 {
+        sb.WriteString(" args=[")
+        for i, arg := range args {
+            if i > 0 {
+                sb.WriteByte(' ')
+            }
+            name := arg.Name
+            if name == "" {
+                name = "$" + strconv.Itoa(arg.Ordinal)
+            }
+            value := interface{}(arg.Value)
+            if this.opts.argRedactor != nil {
+                value = this.opts.argRedactor(query, name, value)
+            }
+            sb.WriteString(name)
+            sb.WriteByte('=')
+            sb.WriteString(formatArg(value))
+        }
+        sb.WriteString("]")
+    }
+    if err != nil {
+        sb.WriteString(" err=")
+        sb.WriteString(strconv.Quote(err.Error()))
+    }
+    this.logger.Output(logLevel, simlog.Caller{}, sb.String())
+}
+
+// 格式化一个参数值，字符串和字节串加引号
+func formatArg(value interface{}) string {
+    switch v := value.(type) {
+    case nil:
+        return "NULL"
+    case string:
+        return strconv.Quote(v)
+    case []byte:
+        return strconv.Quote(string(v))
+    case time.Time:
+        return v.Format(time.RFC3339Nano)
+    default:
+        return fmt.Sprint(v)
+    }
+}
+
+// 受影响的行数，取不到时为-1
+func rowsAffected(result driver.Result) int64 {
+    if result == nil {
+        return -1
+    }
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return -1
+    }
+    return rows
+}
+
+// 将 NamedValue 转为不带名字的 Value，供不支持 context 的驱动使用
+func namedValuesToValues(named []driver.NamedValue) ([]driver.Value, error) {
+    args := make([]driver.Value, len(named))
+    for i, arg := range named {
+        if arg.Name != "" {
+            return nil, errors.New("simlogsql: driver does not support named parameters")
+        }
+        args[i] = arg.Value
+    }
+    return args, nil
+}
+
+// 将不带名字的 Value 转为 NamedValue，用于输出参数
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+    named := make([]driver.NamedValue, len(args))
+    for i, arg := range args {
+        named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+    }
+    return named
+}
+
+type wrappedDriver struct {
+    parent driver.Driver
+    logger *sqlLogger
+}
+
+func (this *wrappedDriver) Open(name string) (driver.Conn, error) {
+    start := time.Now()
+    conn, err := this.parent.Open(name)
+    if err != nil {
+        this.logger.log("connect", start, "", nil, -1, err)
+        return nil, err
+    }
+    return &wrappedConn{parent: conn, logger: this.logger}, nil
+}
+
+type wrappedConnector struct {
+    parent driver.Connector
+    logger *sqlLogger
+}
+
+func (this *wrappedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+    start := time.Now()
+    conn, err := this.parent.Connect(ctx)
+    if err != nil {
+        this.logger.log("connect", start, "", nil, -1, err)
+        return nil, err
+    }
+    return &wrappedConn{parent: conn, logger: this.logger}, nil
+}
+
+func (this *wrappedConnector) Driver() driver.Driver {
+    return &wrappedDriver{parent: this.parent.Driver(), logger: this.logger}
+}
+
+// 带日志的连接，底层连接不支持的可选接口返回 driver.ErrSkip，由 database/sql 改用其它方式
+type wrappedConn struct {
+    parent driver.Conn
+    logger *sqlLogger
+}
+
+func (this *wrappedConn) Prepare(query string) (driver.Stmt, error) {
+    return this.PrepareContext(context.Background(), query)
+}
+
+func (this *wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+    var stmt driver.Stmt
+    var err error
+
+    start := time.Now()
+    if preparer, ok := this.parent.(driver.ConnPrepareContext); ok {
+        stmt, err = preparer.PrepareContext(ctx, query)
+    } else {
+        stmt, err = this.parent.Prepare(query)
+    }
+    if err != nil {
+        this.logger.log("prepare", start, query, nil, -1, err)
+        return nil, err
+    }
+    return &wrappedStmt{parent: stmt, query: query, logger: this.logger}, nil
+}
+
+func (this *wrappedConn) Close() error {
+    return this.parent.Close()
+}
+
+func (this *wrappedConn) Begin() (driver.Tx, error) {
+    return this.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (this *wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+    var tx driver.Tx
+    var err error
+
+    start := time.Now()
+    if beginner, ok := this.parent.(driver.ConnBeginTx); ok {
+        tx, err = beginner.BeginTx(ctx, opts)
+    } else {
+        tx, err = this.parent.Begin()
+    }
+    this.logger.log("begin", start, "", nil, -1, err)
+    if err != nil {
+        return nil, err
+    }
+    return &wrappedTx{parent: tx, logger: this.logger}, nil
+}
+
+func (this *wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+    execer, ok := this.parent.(driver.ExecerContext)
+    if !ok {
+        return nil, driver.ErrSkip
+    }
+    start := time.Now()
+    result, err := execer.ExecContext(ctx, query, args)
+    this.logger.log("exec", start, query, args, rowsAffected(result), err)
+    return result, err
+}
+
+func (this *wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+    queryer, ok := this.parent.(driver.QueryerContext)
+    if !ok {
+        return nil, driver.ErrSkip
+    }
+    start := time.Now()
+    rows, err := queryer.QueryContext(ctx, query, args)
+    this.logger.log("query", start, query, args, -1, err)
+    return rows, err
+}
+
+func (this *wrappedConn) Ping(ctx context.Context) error {
+    if pinger, ok := this.parent.(driver.Pinger); ok {
+        return pinger.Ping(ctx)
+    }
+    return nil
+}
+
+func (this *wrappedConn) ResetSession(ctx context.Context) error {
+    if resetter, ok := this.parent.(driver.SessionResetter); ok {
+        return resetter.ResetSession(ctx)
+    }
+    return nil
+}
+
+func (this *wrappedConn) IsValid() bool {
+    if validator, ok := this.parent.(driver.Validator); ok {
+        return validator.IsValid()
+    }
+    return true
+}
+
+func (this *wrappedConn) CheckNamedValue(value *driver.NamedValue) error {
+    if checker, ok := this.parent.(driver.NamedValueChecker); ok {
+        return checker.CheckNamedValue(value)
+    }
+    return driver.ErrSkip
+}
+
+// 带日志的预处理语句
+type wrappedStmt struct {
+    parent driver.Stmt
+    query  string
+    logger *sqlLogger
+}
+
+func (this *wrappedStmt) Close() error {
+    return this.parent.Close()
+}
+
+func (this *wrappedStmt) NumInput() int {
+    return this.parent.NumInput()
+}
+
+func (this *wrappedStmt) Exec(args []driver.Value) (driver.Result, error) {
+    start := time.Now()
+    result, err := this.parent.Exec(args)
+    this.logger.log("exec", start, this.query, valuesToNamedValues(args), rowsAffected(result), err)
+    return result, err
+}
+
+func (this *wrappedStmt) Query(args []driver.Value) (driver.Rows, error) {
+    start := time.Now()
+    rows, err := this.parent.Query(args)
+    this.logger.log("query", start, this.query, valuesToNamedValues(args), -1, err)
+    return rows, err
+}
+
+func (this *wrappedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+    var result driver.Result
+    var err error
+
+    start := time.Now()
+    if execer, ok := this.parent.(driver.StmtExecContext); ok {
+        result, err = execer.ExecContext(ctx, args)
+    } else {
+        var values []driver.Value
+        if values, err = namedValuesToValues(args); err == nil {
+            result, err = this.parent.Exec(values)
+        }
+    }
+    this.logger.log("exec", start, this.query, args, rowsAffected(result), err)
+    return result, err
+}
+
+func (this *wrappedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+    var rows driver.Rows
+    var err error
+
+    start := time.Now()
+    if queryer, ok := this.parent.(driver.StmtQueryContext); ok {
+        rows, err = queryer.QueryContext(ctx, args)
+    } else {
+        var values []driver.Value
+        if values, err = namedValuesToValues(args); err == nil {
+            rows, err = this.parent.Query(values)
+        }
+    }
+    this.logger.log("query", start, this.query, args, -1, err)
+    return rows, err
+}
+
+func (this *wrappedStmt) CheckNamedValue(value *driver.NamedValue) error {
+    if checker, ok := this.parent.(driver.NamedValueChecker); ok {
+        return checker.CheckNamedValue(value)
+    }
+    return driver.ErrSkip
+}
+
+// 带日志的事务
+type wrappedTx struct {
+    parent driver.Tx
+    logger *sqlLogger
+}
+
+func (this *wrappedTx) Commit() error {
+    start := time.Now()
+    err := this.parent.Commit()
+    this.logger.log("commit", start, "", nil, -1, err)
+    return err
+}
+
+func (this *wrappedTx) Rollback() error {
+    start := time.Now()
+    err := this.parent.Rollback()
+    this.logger.log("rollback", start, "", nil, -1, err)
+    return err
+}
@@ -0,0 +1,160 @@
+// Package simlogtest 提供 simlog 的测试辅助工具，
+// 包括日志行格式的黄金文件（golden file），以便包装 simlog 的库和下游解析器在日志头格式演进时校验兼容性。
+package simlogtest
+
+import (
+    _ "embed"
+    "encoding/json"
+    "fmt"
+    "os"
+    "reflect"
+    "strconv"
+    "testing"
+    "time"
+)
+import (
+    "github.com/eyjian/simlog"
+)
+
+// 内置的日志行格式黄金文件
+//
+//go:embed testdata/format.golden.json
+var formatGolden []byte
+
+// GoldenEntry 黄金文件中一行日志的期望解析结果
+type GoldenEntry struct {
+    Time      string          `json:"time"`                 // 格式同日志头，如“2020-03-19 08:00:00 123456”
+    Elapsed   string          `json:"elapsed,omitempty"`    // 如“12.345678s”
+    WriteTime string          `json:"write_time,omitempty"` // 格式同 Time，见 simlog.EnableWriteTime
+    Level     simlog.LogLevel `json:"level"`
+    Service   string          `json:"service,omitempty"`
+    Version   string          `json:"version,omitempty"`
+    Tag       string          `json:"tag,omitempty"`
+    Tags      []string        `json:"tags,omitempty"`
+    Code      string          `json:"code,omitempty"`
+    File      string          `json:"file,omitempty"`
+    Line      int             `json:"line,omitempty"`
+    Body      string          `json:"body"`
+}
+
+// GoldenCase 黄金文件中的一个用例
+type GoldenCase struct {
+    Name    string      `json:"name"`              // 用例名
+    Line    string      `json:"line"`              // 日志行
+    Invalid bool        `json:"invalid,omitempty"` // 为 true 表示该行应当解析失败
+    Want    GoldenEntry `json:"want"`              // 期望的解析结果
+}
+
+// ParseFunc 日志行解析函数，simlog.ParseLine 即为一个 ParseFunc
+type ParseFunc func(line string) (simlog.Entry, error)
+
+// GoldenCases 返回内置的日志行格式黄金用例
+func GoldenCases() []GoldenCase {
+    var cases []GoldenCase
+    if err := json.Unmarshal(formatGolden, &cases); err != nil {
+        panic(fmt.Sprintf("simlogtest: invalid builtin golden file: %s", err.Error()))
+    }
+    return cases
+}
+
+// LoadGolden 从文件加载黄金用例，文件格式同内置的 testdata/format.golden.json
+func LoadGolden(path string) ([]GoldenCase, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+
+    var cases []GoldenCase
+    if err = json.Unmarshal(data, &cases); err != nil {
+        return nil, fmt.Errorf("simlogtest: parse golden file %s: %w", path, err)
+    }
+    return cases, nil
+}
+
+// CheckGolden 用 parse 解析每个用例的日志行并和期望结果比较，返回所有不一致之处，
+// parse 为 nil 时使用 simlog.ParseLine。
+func CheckGolden(cases []GoldenCase, parse ParseFunc) []error {
+    var errs []error
+
+    if parse == nil {
+        parse = simlog.ParseLine
+    }
+    for _, c := range cases {
+        entry, err := parse(c.Line)
+        if c.Invalid {
+            if err == nil {
+                errs = append(errs, fmt.Errorf("%s: expected parse error, got none", c.Name))
+            }
+            continue
+        }
+        if err != nil {
+            errs = append(errs, fmt.Errorf("%s: unexpected parse error: %s", c.Name, err.Error()))
+            continue
+        }
+
+        got := GoldenEntry{
+            Time:      entry.Time.Format("2006-01-02 15:04:05.000000"),
+            Level:     entry.Level,
+            Elapsed:   formatElapsed(entry.Elapsed),
+            WriteTime: formatWriteTime(entry.WriteTime),
+            Service:   entry.Service,
+            Version:   entry.Version,
+            Tag:       entry.Tag,
+            Tags:      entry.Tags,
+            Code:      entry.Code,
+            File:      entry.Caller.File,
+            Line:      entry.Caller.Line,
+            Body:      entry.Body,
+        }
+        // 期望的时间与日志头格式一致，秒和微秒之间为空格
+        want := c.Want
+        if len(want.Time) > 19 && want.Time[19] == ' ' {
+            want.Time = want.Time[:19] + "." + want.Time[20:]
+        }
+        if len(want.WriteTime) > 19 && want.WriteTime[19] == ' ' {
+            want.WriteTime = want.WriteTime[:19] + "." + want.WriteTime[20:]
+        }
+        if !reflect.DeepEqual(got, want) {
+            errs = append(errs, fmt.Errorf("%s: got %+v, want %+v", c.Name, got, want))
+        }
+    }
+    return errs
+}
+
+// AssertGolden 用 parse 校验内置的黄金用例，不一致时调用 t.Errorf，
+// parse 为 nil 时使用 simlog.ParseLine。
+func AssertGolden(t testing.TB, parse ParseFunc) {
+    t.Helper()
+    for _, err := range CheckGolden(GoldenCases(), parse) {
+        t.Errorf("%s", err.Error())
+    }
+}
+
+// AssertGoldenFile 用 parse 校验黄金文件中的用例，不一致时调用 t.Errorf，
+// parse 为 nil 时使用 simlog.ParseLine。
+func AssertGoldenFile(t testing.TB, path string, parse ParseFunc) {
+    t.Helper()
+    cases, err := LoadGolden(path)
+    if err != nil {
+        t.Fatalf("%s", err.Error())
+    }
+    for _, err := range CheckGolden(cases, parse) {
+        t.Errorf("%s", err.Error())
+    }
+}
+
+// 格式化写入时间，为零值时返回空
+func formatWriteTime(writeTime time.Time) string {
+    if writeTime.IsZero() {
+        return ""
+    }
+    return writeTime.Format("2006-01-02 15:04:05.000000")
+}
+
+// 格式化单调时长，为0时返回空
+func formatElapsed(elapsed time.Duration) string {
+    if elapsed == 0 {
+        return ""
+    }
+    return strconv.FormatFloat(elapsed.Seconds(), 'f', 6, 64) + "s"
+}
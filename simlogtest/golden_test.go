@@ -0,0 +1,14 @@
+package simlogtest_test
+
+import (
+    "testing"
+)
+import (
+    "github.com/eyjian/simlog"
+    "github.com/eyjian/simlog/simlogtest"
+)
+
+// 内置的黄金用例须与 simlog.ParseLine 一致，日志头格式变化时同步更新 testdata/format.golden.json
+func TestGolden(t *testing.T) {
+    simlogtest.AssertGolden(t, simlog.ParseLine)
+}
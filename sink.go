@@ -0,0 +1,133 @@
+package simlog
+
+import (
+    "io"
+    "os"
+    "sync/atomic"
+)
+
+// Sink 日志的输出，见 WithSink
+type Sink interface {
+    Write(entry Entry) error // 写一条日志，entry 的 Body 已经过脱敏和截断等处理
+    Close() error            // 关闭输出，由 SimLogger 的 Close 调用
+}
+
+// WithSink 增加日志的输出（可多次调用增加多个），每条日志在写日志文件之前依次写到各输出，
+// 可和日志文件组合使用，也可以 EnableFileOutput(false) 只写到各输出，如：
+//
+//	stdout, _ := simlog.NewStdoutSink(simlog.WithFormat(simlog.FormatJSON))
+//	logger.Init(simlog.EnableFileOutput(false), simlog.WithSink(stdout))
+//
+// 输出在写日志的协程中同步调用，需要时由输出自己缓冲（如 NewFileSink 可开启异步写），
+// 写失败计入 LogStats.SinkErrors，不影响其它输出和日志文件。关闭日志时关闭各输出。
+func WithSink(sinks ...Sink) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.sinks = append(o.sinks, sinks...)
+    })
+}
+
+// 写到各 WithSink 的输出和日志自己的输出（见 localSink），返回日志自己的输出的写结果，
+// 按标签路由（见 WithLabelRoute）时只写到路由的输出
+func (this *SimLogger) writeSinks(entry *Entry) (int, error) {
+    if sinks := this.routeLabels(); sinks != nil {
+        this.writeToSinks(sinks, entry)
+        return 0, nil
+    }
+    this.writeToSinks(this.opts.sinks, entry)
+    return localSink{logger: this}.write(entry)
+}
+
+func (this *SimLogger) writeToSinks(sinks []Sink, entry *Entry) {
+    for _, sink := range sinks {
+        if err := sink.Write(*entry); err != nil {
+            atomic.AddInt64(&this.numSinkErrors, 1)
+            this.reportCountedError(err)
+            this.recordLifecycle(LifecycleEvent{Event: LifecycleSinkFailed, Error: err.Error()})
+        }
+    }
+}
+
+// 关闭各输出，包括按标签路由的输出
+func (this *SimLogger) closeSinks() {
+    sinks := append([]Sink(nil), this.opts.sinks...)
+    for _, route := range this.opts.labelRoutes {
+        sinks = append(sinks, route.sinks...)
+    }
+    for _, sink := range sinks {
+        if err := sink.Close(); err != nil {
+            atomic.AddInt64(&this.numSinkErrors, 1)
+            this.reportCountedError(err)
+            this.recordLifecycle(LifecycleEvent{Event: LifecycleSinkFailed, Error: err.Error()})
+        }
+    }
+}
+
+// 日志自己的输出：打屏、日志文件（异步写时为其日志队列）和影子输出，
+// 和 WithSink 的输出一样经 Sink 写，不写日志文件时只打屏和写影子输出
+type localSink struct {
+    logger *SimLogger
+}
+
+func (this localSink) Write(entry Entry) error {
+    _, err := this.write(&entry)
+    return err
+}
+
+// 日志自己的输出由 SimLogger 的 Close 关闭，这里什么也不做
+func (this localSink) Close() error {
+    return nil
+}
+
+// 写 entry 的日志行，没有时（如来自其它 SimLogger 的日志）按本日志的选项构建，返回写入的字节数
+func (this localSink) write(entry *Entry) (int, error) {
+    logLine := entry.line
+    if logLine == "" {
+        logLine, _ = this.logger.buildLogLine(entry, false)
+    }
+    return this.logger.putLog(entry.Level, logLine)
+}
+
+// 以 SimLogger 实现的输出，日志行按其选项格式化和写
+type loggerSink struct {
+    logger *SimLogger
+}
+
+// NewFileSink 创建写日志文件的输出，opts 同 Init（如 WithLogdir、WithFilename、EnableAsyncWrite），
+// 日志行按 opts 格式化（如 WithFormat），时间、级别、调用者和服务等取自写到输出的日志，
+// 以便一条日志按不同的格式或滚动策略同时写到多个日志文件。
+func NewFileSink(opts ...LogOption) (Sink, error) {
+    return newLoggerSink(append([]LogOption{EnableRegistry(false)}, opts...))
+}
+
+// NewWriterSink 创建写到 w 的输出，每条日志一行，opts 可设置格式等（如 WithFormat、EnableScreenColor）
+func NewWriterSink(w io.Writer, opts ...LogOption) (Sink, error) {
+    return newLoggerSink(append([]LogOption{
+        EnableRegistry(false),
+        EnableFileOutput(false),
+        EnablePrintScreen(true),
+        WithScreenWriter(w),
+        EnableLineFeed(true),
+    }, opts...))
+}
+
+// NewStdoutSink 创建写到标准输出的输出，同 NewWriterSink(os.Stdout, opts...)
+func NewStdoutSink(opts ...LogOption) (Sink, error) {
+    return NewWriterSink(os.Stdout, opts...)
+}
+
+func newLoggerSink(opts []LogOption) (Sink, error) {
+    logger := new(SimLogger)
+    if err := logger.InitE(opts...); err != nil {
+        return nil, err
+    }
+    return &loggerSink{logger: logger}, nil
+}
+
+func (this *loggerSink) Write(entry Entry) error {
+    entry.line = "" // 按本输出的选项重新构建
+    return localSink{logger: this.logger}.Write(entry)
+}
+
+func (this *loggerSink) Close() error {
+    return this.logger.Close()
+}
@@ -0,0 +1,83 @@
+package simlog
+
+import (
+    "bytes"
+    "strings"
+    "sync"
+    "testing"
+)
+
+// 记录写入的日志体的输出
+type recordSink struct {
+    mutex  sync.Mutex
+    bodies []string
+    closed int
+}
+
+func (this *recordSink) Write(entry Entry) error {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    this.bodies = append(this.bodies, entry.Body)
+    return nil
+}
+
+func (this *recordSink) Close() error {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    this.closed++
+    return nil
+}
+
+// 日志自己的输出经 localSink 写，返回其写入的字节数，WithSink 的输出也收到每条日志
+func TestLocalSink(t *testing.T) {
+    var out bytes.Buffer
+    extra := new(recordSink)
+    var logger SimLogger
+    logger.Init(WithLogdir(t.TempDir()), EnableFileOutput(false), EnablePrintScreen(true), WithScreenWriter(&out),
+        EnableLineFeed(true), WithSink(extra))
+    n, err := logger.Info("hello")
+    if err != nil || n != out.Len() || !strings.HasSuffix(out.String(), "[INFO]hello\n") {
+        t.Errorf("Info = %d, %v, screen got %q", n, err, out.String())
+    }
+
+    // 来自其它日志的 Entry 按本日志的选项构建日志行
+    if err := (localSink{logger: &logger}).Write(Entry{Level: LL_WARNING, Body: "foreign"}); err != nil {
+        t.Fatal(err)
+    }
+    logger.Close()
+    if !strings.HasSuffix(out.String(), "[WARNING]foreign\n") {
+        t.Errorf("screen got %q", out.String())
+    }
+    if strings.Join(extra.bodies, "|") != "hello" || extra.closed != 1 {
+        t.Errorf("extra sink got %q, closed %d times", extra.bodies, extra.closed)
+    }
+}
+
+// 按标签路由的日志只写到路由的输出
+func TestLabelRoute(t *testing.T) {
+    var out bytes.Buffer
+    audit, extra := new(recordSink), new(recordSink)
+    var logger SimLogger
+    logger.Init(WithLogdir(t.TempDir()), EnableFileOutput(false), EnablePrintScreen(true), WithScreenWriter(&out),
+        EnableLineFeed(true), WithSink(extra), WithLabelRoute("audit", "true", audit))
+
+    logger.Info("normal")
+    if n, err := logger.WithLabels(map[string]string{"audit": "true"}).Info("deleted"); n != 0 || err != nil {
+        t.Errorf("routed Info = %d, %v, want 0, nil", n, err)
+    }
+    logger.WithLabels(map[string]string{"audit": "false"}).Info("not audited")
+    logger.Close()
+
+    if got := strings.Join(audit.bodies, "|"); got != "deleted" {
+        t.Errorf("audit sink got %q", got)
+    }
+    if got := strings.Join(extra.bodies, "|"); got != "normal|not audited" {
+        t.Errorf("extra sink got %q", got)
+    }
+    if strings.Contains(out.String(), "deleted") || strings.Count(out.String(), "\n") != 2 {
+        t.Errorf("screen got %q", out.String())
+    }
+    if audit.closed != 1 || extra.closed != 1 {
+        t.Errorf("sinks closed %d and %d times, want 1", audit.closed, extra.closed)
+    }
+}
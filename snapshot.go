@@ -0,0 +1,67 @@
+package simlog
+
+import (
+    "errors"
+    "io"
+    "os"
+)
+import (
+    "github.com/gofrs/flock"
+)
+
+// SnapshotTo 将当前日志文件复制到 dest（已存在时覆盖），truncate 为 true 时随后清空当前日志文件，
+// 供支持工具按需收集日志，而不必停止写日志。
+// 复制前先写出之前写的日志（同 Flush），复制和清空期间持有滚动锁（不会同时被滚动），
+// 本进程的写日志暂停（日志暂存在日志队列中），所以本进程的日志不会丢失也不会重复；
+// 但不能阻止其它进程写同一个日志文件，它们在复制和清空之间写入的日志会丢失。
+// 不用硬链接是因为硬链接和当前日志文件是同一个文件，清空时会一起被清空，不清空时也会继续增长。
+func (this *SimLogger) SnapshotTo(dest string, truncate bool) error {
+    if this.opts.noFileOutput {
+        return errors.New("simlog: no log file")
+    }
+    if err := this.Flush(); err != nil {
+        return err
+    }
+
+    this.pauseMutex.Lock()
+    defer this.pauseMutex.Unlock()
+    if !this.opts.asyncWrite && this.opts.writeBufferSize > 0 {
+        this.flushBuffered(false) // 暂停前写入的
+    }
+
+    path := this.getFilepath()
+    fileLock := flock.New(this.lockFilepath(path)) // 同滚动的文件锁，见 WithLockDir
+    if err := fileLock.Lock(); err != nil {
+        return err
+    }
+    defer fileLock.Unlock()
+
+    if err := copyFile(path, dest); err != nil {
+        return err
+    }
+    if truncate {
+        return os.Truncate(path, 0) // 写日志时以 O_APPEND 打开，清空后从头写
+    }
+    return nil
+}
+
+// 复制文件，写入磁盘后才返回
+func copyFile(src, dest string) error {
+    in, err := os.Open(src)
+    if err != nil {
+        return err
+    }
+    defer in.Close()
+
+    out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+    if err != nil {
+        return err
+    }
+    if _, err = io.Copy(out, in); err == nil {
+        err = out.Sync()
+    }
+    if e := out.Close(); err == nil {
+        err = e
+    }
+    return err
+}
@@ -0,0 +1,284 @@
+package simlog
+
+import (
+    "encoding/binary"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// 重发落盘日志的间隔
+const spoolResendInterval = time.Second
+
+// 落盘文件名的前后缀，中间为创建时间（纳秒）
+const (
+    spoolFilePrefix = "spool-"
+    spoolFileSuffix = ".log"
+)
+
+// 落盘文件由记录组成，每条记录为4字节（大端）的长度加上一次 Write 的数据，
+// 重发时每条记录也只用一次 Write 发出，以保持按报文发送的输出（如 UDP）的报文边界
+const spoolRecordHeaderSize = 4
+
+// WithSpoolDir 设置影子输出（WithShadowSink）或网络输出（NewNetworkSink 和 NewUnixSocketSink 的 opts）的落盘目录：写远端失败的日志写到 dir 下的落盘文件，
+// 由后台协程每秒尝试按先后顺序重发，连接恢复后发完即删除，期间新的日志也先落盘，以保持顺序。
+// 落盘文件在进程重启后仍会被重发，每次 Write 的数据作为一条记录落盘和重发，不会重复发送。
+// maxAge 大于0时丢弃早于 maxAge 的落盘文件，maxSize 大于0时落盘总字节数超过 maxSize 则从最早的丢弃，
+// 影子输出待重发和丢弃的字节数见 Stats 的 Spooled 和 SpoolEvicted。
+// dir 为空表示不落盘（默认），影子输出写远端失败的日志只计数（见 GetShadowErrors），网络输出的在队列中等待重连；
+// syslog 输出（NewSyslogSink）不落盘。
+// 落盘目录只能由一个日志或网络输出使用，否则会重发其它输出的落盘文件。
+func WithSpoolDir(dir string, maxAge time.Duration, maxSize int64) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.spoolDir = dir
+        o.spoolMaxAge = maxAge
+        o.spoolMaxSize = maxSize
+    })
+}
+
+// 一个落盘文件
+type spoolFile struct {
+    path    string
+    size    int64
+    created time.Time
+}
+
+// spoolWriter 写远端失败时落盘、并在后台重发的输出
+type spoolWriter struct {
+    evicted int64 // 因过期或超过上限而丢弃的字节数（放在最前面，以保证在32位平台上原子操作时的8字节对齐）
+    w       io.Writer
+    dir     string
+    maxAge  time.Duration
+    maxSize int64
+
+    mutex       sync.Mutex
+    files       []spoolFile // 待重发的落盘文件，按先后排列，最后一个可能正在追加
+    current     *os.File    // 正在追加的落盘文件（为 files 的最后一个），为nil表示需新建
+    resendMutex sync.Mutex  // 串行化 resend，重发在 mutex 之外进行
+    exit        chan struct{}
+    done        chan struct{}
+}
+
+// 创建落盘输出并启动重发协程，dir 中已有的落盘文件（如上次运行留下的）也会被重发
+func newSpoolWriter(w io.Writer, dir string, maxAge time.Duration, maxSize int64) (*spoolWriter, error) {
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return nil, err
+    }
+    this := &spoolWriter{
+        w:       w,
+        dir:     dir,
+        maxAge:  maxAge,
+        maxSize: maxSize,
+        exit:    make(chan struct{}),
+        done:    make(chan struct{}),
+    }
+
+    paths, err := filepath.Glob(filepath.Join(dir, spoolFilePrefix+"*"+spoolFileSuffix))
+    if err != nil {
+        return nil, err
+    }
+    for _, path := range paths {
+        name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), spoolFilePrefix), spoolFileSuffix)
+        nanos, err := strconv.ParseInt(name, 10, 64)
+        if err != nil {
+            continue
+        }
+        if fi, err := os.Stat(path); err == nil {
+            this.files = append(this.files, spoolFile{path: path, size: fi.Size(), created: time.Unix(0, nanos)})
+        }
+    }
+    sort.Slice(this.files, func(i, j int) bool {
+        return this.files[i].created.Before(this.files[j].created)
+    })
+
+    go this.run()
+    return this, nil
+}
+
+// 写入 p：没有待重发的落盘文件时直接写远端，否则或写失败时落盘，落盘成功即返回成功
+func (this *spoolWriter) Write(p []byte) (int, error) {
+    total := len(p)
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+
+    if len(this.files) == 0 {
+        n, err := this.w.Write(p)
+        if err == nil && n >= len(p) {
+            return total, nil
+        }
+        if n < 0 {
+            n = 0
+        }
+        p = p[n:]
+    }
+    if err := this.spool(p); err != nil {
+        return total - len(p), err
+    }
+    return total, nil
+}
+
+// 作为一条记录追加到落盘文件，调用者需持有 mutex
+func (this *spoolWriter) spool(p []byte) error {
+    if len(p) == 0 {
+        return nil
+    }
+    if this.current == nil {
+        now := time.Now()
+        path := filepath.Join(this.dir, spoolFilePrefix+strconv.FormatInt(now.UnixNano(), 10)+spoolFileSuffix)
+        f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+        if err != nil {
+            return err
+        }
+        this.current = f
+        this.files = append(this.files, spoolFile{path: path, created: now})
+    }
+    n, err := this.current.Write(appendSpoolRecord(nil, p))
+    this.files[len(this.files)-1].size += int64(n)
+    this.evict()
+    return err
+}
+
+// 丢弃过期的和超过上限的落盘文件（从最早的开始），调用者需持有 mutex
+func (this *spoolWriter) evict() {
+    var total int64
+    for _, file := range this.files {
+        total += file.size
+    }
+    for len(this.files) > 0 {
+        oldest := this.files[0]
+        expired := this.maxAge > 0 && time.Since(oldest.created) > this.maxAge
+        oversize := this.maxSize > 0 && total > this.maxSize
+        if !expired && !oversize {
+            break
+        }
+        if len(this.files) == 1 && this.current != nil {
+            this.current.Close()
+            this.current = nil
+        }
+        os.Remove(oldest.path)
+        atomic.AddInt64(&this.evicted, oldest.size)
+        total -= oldest.size
+        this.files = this.files[1:]
+    }
+}
+
+// 重发协程
+func (this *spoolWriter) run() {
+    ticker := time.NewTicker(spoolResendInterval)
+    defer ticker.Stop()
+    defer close(this.done)
+
+    for {
+        select {
+        case <-this.exit:
+            return
+        case <-ticker.C:
+            this.resend()
+        }
+    }
+}
+
+// 按先后重发落盘文件，直到全部发完或写远端失败
+func (this *spoolWriter) resend() {
+    this.resendMutex.Lock()
+    defer this.resendMutex.Unlock()
+
+    for {
+        this.mutex.Lock()
+        this.evict()
+        if len(this.files) == 0 {
+            this.mutex.Unlock()
+            return
+        }
+        if len(this.files) == 1 && this.current != nil {
+            // 停止追加正在重发的文件，此后的日志写到新的落盘文件
+            this.current.Close()
+            this.current = nil
+        }
+        file := this.files[0]
+        this.mutex.Unlock()
+
+        // 新的日志只会追加到更晚的落盘文件，所以可在锁外读和发送
+        data, err := os.ReadFile(file.path)
+        if err != nil && !os.IsNotExist(err) {
+            fmt.Fprintf(os.Stderr, "simlog: read spool file %s failed: %s\n", file.path, err.Error())
+        } else if err == nil && !this.resendFile(file, data) {
+            return
+        }
+
+        this.mutex.Lock()
+        if len(this.files) > 0 && this.files[0].path == file.path {
+            this.files = this.files[1:]
+        }
+        this.mutex.Unlock()
+        os.Remove(file.path)
+    }
+}
+
+// 逐条重发落盘文件中的记录，写远端失败时只保留未发出的部分，以免重复，返回是否发完
+func (this *spoolWriter) resendFile(file spoolFile, data []byte) bool {
+    for len(data) > 0 {
+        if len(data) < spoolRecordHeaderSize || int(binary.BigEndian.Uint32(data)) > len(data)-spoolRecordHeaderSize {
+            // 写落盘文件时进程退出等留下的不完整的记录
+            fmt.Fprintf(os.Stderr, "simlog: spool file %s has a truncated record, dropping %d bytes\n", file.path, len(data))
+            atomic.AddInt64(&this.evicted, int64(len(data)))
+            return true
+        }
+        end := spoolRecordHeaderSize + int(binary.BigEndian.Uint32(data))
+        record := data[spoolRecordHeaderSize:end]
+        n, err := this.w.Write(record)
+        if err != nil {
+            rest := data[end:]
+            if n < len(record) {
+                rest = append(appendSpoolRecord(nil, record[max(n, 0):]), rest...)
+            }
+            if os.WriteFile(file.path, rest, 0644) == nil {
+                this.mutex.Lock()
+                if len(this.files) > 0 && this.files[0].path == file.path {
+                    this.files[0].size = int64(len(rest))
+                }
+                this.mutex.Unlock()
+            }
+            return false
+        }
+        data = data[end:]
+    }
+    return true
+}
+
+// 在 buf 后追加一条记录
+func appendSpoolRecord(buf []byte, p []byte) []byte {
+    buf = binary.BigEndian.AppendUint32(buf, uint32(len(p)))
+    return append(buf, p...)
+}
+
+// 待重发的落盘字节数
+func (this *spoolWriter) spooledBytes() int64 {
+    var total int64
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    for _, file := range this.files {
+        total += file.size
+    }
+    return total
+}
+
+// 停止重发协程，未发出的落盘文件留待下次运行时重发
+func (this *spoolWriter) close() {
+    close(this.exit)
+    <-this.done
+
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    if this.current != nil {
+        this.current.Close()
+        this.current = nil
+    }
+}
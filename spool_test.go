@@ -0,0 +1,133 @@
+package simlog
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// 可模拟远端断开的输出，每次 Write 的数据作为一条报文记录下来
+type flakyWriter struct {
+    down     int32
+    mutex    sync.Mutex
+    messages []string
+}
+
+func (this *flakyWriter) Write(p []byte) (int, error) {
+    if atomic.LoadInt32(&this.down) == 1 {
+        return 0, errors.New("remote down")
+    }
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    this.messages = append(this.messages, string(p))
+    return len(p), nil
+}
+
+func (this *flakyWriter) received() []string {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    return append([]string(nil), this.messages...)
+}
+
+// 重发直到落盘文件都已发出
+func drainSpool(t *testing.T, spool *spoolWriter) {
+    t.Helper()
+    for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+        spool.resend()
+        if spool.spooledBytes() == 0 {
+            return
+        }
+        time.Sleep(time.Millisecond)
+    }
+    t.Fatalf("%d spooled bytes not resent", spool.spooledBytes())
+}
+
+// 远端时断时续，写和重发同时进行：每条报文恰好送达一次，同一写者的报文按写的顺序送达
+func TestSpoolResendConcurrent(t *testing.T) {
+    const writers, lines = 4, 300
+    remote := &flakyWriter{down: 1}
+    spool, err := newSpoolWriter(remote, t.TempDir(), 0, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer spool.close()
+
+    var wg sync.WaitGroup
+    for i := 0; i < writers; i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            for j := 0; j < lines; j++ {
+                if _, err := spool.Write([]byte(fmt.Sprintf("%d-%d\n", i, j))); err != nil {
+                    t.Errorf("Write: %s", err.Error())
+                    return
+                }
+            }
+        }(i)
+    }
+    stop := make(chan struct{})
+    var resender sync.WaitGroup
+    resender.Add(1)
+    go func() {
+        defer resender.Done()
+        for k := 0; ; k++ {
+            select {
+            case <-stop:
+                return
+            default:
+            }
+            atomic.StoreInt32(&remote.down, int32(k%2)) // 远端交替断开和恢复
+            spool.resend()
+            time.Sleep(100 * time.Microsecond)
+        }
+    }()
+    wg.Wait()
+    close(stop)
+    resender.Wait()
+    atomic.StoreInt32(&remote.down, 0)
+    drainSpool(t, spool)
+
+    got := remote.received()
+    if len(got) != writers*lines {
+        t.Fatalf("received %d messages, want %d", len(got), writers*lines)
+    }
+    next := make([]int, writers)
+    for _, message := range got {
+        var i, j int
+        if _, err := fmt.Sscanf(message, "%d-%d\n", &i, &j); err != nil || !strings.HasSuffix(message, "\n") {
+            t.Fatalf("corrupted message %q", message)
+        }
+        if j != next[i] {
+            t.Fatalf("writer %d: got message %d, want %d", i, j, next[i])
+        }
+        next[i]++
+    }
+}
+
+// 进程重启后重发上次留下的落盘文件，每条记录单独发出
+func TestSpoolResendAfterRestart(t *testing.T) {
+    dir := t.TempDir()
+    spool, err := newSpoolWriter(&flakyWriter{down: 1}, dir, 0, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, message := range []string{"first\n", "second\n", "third\n"} {
+        spool.Write([]byte(message))
+    }
+    spool.close()
+
+    remote := &flakyWriter{}
+    spool, err = newSpoolWriter(remote, dir, 0, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer spool.close()
+    drainSpool(t, spool)
+    if got := strings.Join(remote.received(), "|"); got != "first\n|second\n|third\n" {
+        t.Errorf("received %q", got)
+    }
+}
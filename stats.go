@@ -0,0 +1,100 @@
+package simlog
+
+import (
+    "errors"
+    "sync/atomic"
+)
+
+// 写协程状态
+const (
+    writerRunning    int32 = 0 // 正常运行
+    writerFailing    int32 = 1 // 打开日志文件失败，正在重试
+    writerRestarting int32 = 2 // 异常退出，等待重启
+    writerStopped    int32 = 3 // 日志队列关闭后正常退出
+)
+
+// ErrWriterDown 写协程未正常运行（打开日志文件失败正在重试，或异常退出等待重启）
+var ErrWriterDown = errors.New("simlog: log writer is down")
+
+// LogStats 日志的运行统计
+type LogStats struct {
+    Written        int64 // 已写入日志文件的日志行数
+    Dropped        int64 // 丢弃（写失败或关闭后写入）的日志行数
+    Queued         int   // 日志队列中尚未写的日志条数
+    Purged         int64 // 因 WithRetroactiveFilter 从日志队列中清除的日志条数
+    ShadowErrors   int64 // 影子输出写失败次数
+    WriterRestarts int64 // 写协程异常退出后被重启的次数
+    WriterDown     bool  // 写协程当前是否未正常运行
+    WriterError    error // 写协程最近一次的错误，正常运行时为 nil
+    Spooled        int64 // 待重发的落盘字节数，见 WithSpoolDir
+    SpoolEvicted   int64 // 因过期或超过上限而丢弃的落盘字节数
+    Fallback       int64 // 因文件系统只读而写到降级输出的日志行数，见 WithReadOnlyFallback
+    ReadOnly       bool  // 当前是否因文件系统只读而处于降级
+    Governed       int64 // 因 WithCPUBudget 限流而未输出的日志条数
+    SinkErrors     int64 // WithSink 的输出写或关闭失败次数
+    Rotations      int64 // 本进程滚动日志文件的次数
+}
+
+// Stats 返回日志的运行统计，同步写时 Queued、WriterRestarts 和 WriterDown 总是为零值
+func (this *SimLogger) Stats() LogStats {
+    stats := LogStats{
+        Written:      atomic.LoadInt64(&this.numWritten),
+        Dropped:      atomic.LoadInt64(&this.numDropped),
+        ShadowErrors: atomic.LoadInt64(&this.numShadowErrors),
+        Fallback:     atomic.LoadInt64(&this.numFallback),
+        ReadOnly:     atomic.LoadInt64(&this.readOnlyProbe) != 0,
+        Governed:     atomic.LoadInt64(&this.numGoverned),
+        SinkErrors:   atomic.LoadInt64(&this.numSinkErrors),
+        Rotations:    atomic.LoadInt64(&this.numRotations),
+    }
+    if this.spool != nil {
+        stats.Spooled = this.spool.spooledBytes()
+        stats.SpoolEvicted = atomic.LoadInt64(&this.spool.evicted)
+    }
+    if this.opts.asyncWrite {
+        stats.Queued = this.queueLen()
+        stats.Purged = atomic.LoadInt64(&this.numPurged)
+        stats.WriterRestarts = atomic.LoadInt64(&this.numWriterRestarts)
+        state := atomic.LoadInt32(&this.writerState)
+        stats.WriterDown = state == writerFailing || state == writerRestarting
+        stats.WriterError = this.getWriterError()
+    }
+    return stats
+}
+
+// Flush 等待调用之前写入的日志都被写入日志文件（包括写出写缓冲），
+// 异步写时如果写协程未正常运行则返回 ErrWriterDown（包装了写协程的错误），而不是一直等待。
+func (this *SimLogger) Flush() error {
+    return this.Barrier().Wait()
+}
+
+// 设置写协程状态和错误，并唤醒等待的 Flush
+func (this *SimLogger) setWriterState(state int32, err error) {
+    this.progressMutex.Lock()
+    atomic.StoreInt32(&this.writerState, state)
+    this.writerError = err
+    this.progressMutex.Unlock()
+    this.progressCond.Broadcast()
+}
+
+func (this *SimLogger) getWriterError() error {
+    this.progressMutex.Lock()
+    defer this.progressMutex.Unlock()
+    return this.writerError
+}
+
+// 写协程从日志队列中清除了 n 条日志
+func (this *SimLogger) addPurged(n int) {
+    if n > 0 {
+        atomic.AddInt64(&this.numPurged, int64(n))
+        this.addProcessed(n)
+    }
+}
+
+// 写协程处理（写入或丢弃）了 n 条日志，并唤醒等待的 Flush
+func (this *SimLogger) addProcessed(n int) {
+    this.progressMutex.Lock()
+    atomic.AddInt64(&this.numProcessed, int64(n))
+    this.progressMutex.Unlock()
+    this.progressCond.Broadcast()
+}
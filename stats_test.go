@@ -0,0 +1,74 @@
+package simlog
+
+import (
+    "bufio"
+    "os"
+    "path/filepath"
+    "sync"
+    "sync/atomic"
+    "testing"
+)
+
+// 日志文件的行数
+func countLines(t *testing.T, path string) int {
+    t.Helper()
+    f, err := os.Open(path)
+    if err != nil {
+        t.Fatalf("open %s: %s", path, err.Error())
+    }
+    defer f.Close()
+
+    n := 0
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        n++
+    }
+    return n
+}
+
+// 写协程在持续写日志期间 panic：被重启，重启前后的日志不会悄无声息地丢失（写入或计入丢弃）
+func TestWriterRestartWhileWriting(t *testing.T) {
+    const writers, lines = 4, 500
+    dir := t.TempDir()
+    var panicked int32
+    var logger SimLogger
+    logger.Init(
+        WithLogdir(dir),
+        WithFilename("restart.log"),
+        EnableLineFeed(true),
+        WithWriteCallback(func(lines, bytes int, err error) {
+            if atomic.CompareAndSwapInt32(&panicked, 0, 1) {
+                panic("write callback")
+            }
+        }),
+    )
+
+    var wg sync.WaitGroup
+    for i := 0; i < writers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < lines; j++ {
+                logger.Infof("line %d", j)
+                if j%100 == 0 {
+                    logger.Stats()
+                }
+            }
+        }()
+    }
+    wg.Wait()
+    if err := logger.Flush(); err != nil {
+        // 写协程可能仍在重启的退避中
+        t.Logf("Flush: %s", err.Error())
+    }
+    logger.Close()
+
+    stats := logger.Stats()
+    if stats.WriterRestarts != 1 {
+        t.Errorf("WriterRestarts = %d, want 1", stats.WriterRestarts)
+    }
+    written := countLines(t, filepath.Join(dir, "restart.log"))
+    if written > writers*lines || int64(written)+stats.Dropped < writers*lines {
+        t.Errorf("written %d + dropped %d, want %d", written, stats.Dropped, writers*lines)
+    }
+}
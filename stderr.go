@@ -0,0 +1,48 @@
+package simlog
+
+import (
+    "bufio"
+    "os"
+)
+
+// CaptureStderrPanics 将标准错误（包括 os.Stderr 和文件描述符2）重定向到 logger，
+// 每行输出以 ERROR 级别写入日志，这样运行时未恢复的 panic 栈等也会进入滚动的日志文件，而不是丢失在标准错误中。
+// 进程崩溃时读管道的协程可能来不及写日志，因此在支持的 Go 版本（1.23 及以上）会同时通过 debug.SetCrashOutput
+// 将崩溃信息直接追加到日志文件。
+// 返回的 restore 用于恢复标准错误，应在 logger 关闭之前调用。
+// 非 unix 平台只替换 os.Stderr，捕获不到运行时直接写文件描述符2的输出。
+func CaptureStderrPanics(logger *SimLogger) (restore func(), err error) {
+    r, w, err := os.Pipe()
+    if err != nil {
+        return nil, err
+    }
+    restoreFd, err := redirectStderr(w)
+    if err != nil {
+        r.Close()
+        w.Close()
+        return nil, err
+    }
+    restoreCrashOutput := setCrashOutput(logger.getFilepath())
+    stderr := os.Stderr
+    os.Stderr = w
+
+    done := make(chan struct{})
+    go func() {
+        defer close(done)
+        scanner := bufio.NewScanner(r)
+        scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+        for scanner.Scan() {
+            logger.output(LL_ERROR, Caller{}, "stderr: "+scanner.Text(), true)
+        }
+    }()
+
+    restore = func() {
+        os.Stderr = stderr
+        restoreCrashOutput()
+        restoreFd()
+        w.Close()
+        <-done
+        r.Close()
+    }
+    return restore, nil
+}
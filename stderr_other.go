@@ -0,0 +1,12 @@
+//go:build !unix
+
+package simlog
+
+import (
+    "os"
+)
+
+// 非 unix 平台不重定向文件描述符，只由调用者替换 os.Stderr
+func redirectStderr(w *os.File) (func(), error) {
+    return func() {}, nil
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package simlog
+
+import (
+    "os"
+)
+import (
+    "golang.org/x/sys/unix"
+)
+
+// 将文件描述符2重定向到 w，返回恢复函数
+func redirectStderr(w *os.File) (func(), error) {
+    saved, err := unix.Dup(unix.Stderr)
+    if err != nil {
+        return nil, err
+    }
+    if err = unix.Dup2(int(w.Fd()), unix.Stderr); err != nil {
+        unix.Close(saved)
+        return nil, err
+    }
+    return func() {
+        unix.Dup2(saved, unix.Stderr)
+        unix.Close(saved)
+    }, nil
+}
@@ -0,0 +1,90 @@
+package simlog
+
+import (
+    "log"
+    "strings"
+)
+
+// 标准库 log 写日志时，从 stdWriter.Write 到调用 log.Printf 等的代码之间的调用层数
+const stdLoggerSkip = 4
+
+// EnableLevelSniffing 是否从 Write 写入的内容中识别级别前缀（默认为 false），
+// 如“ERROR: ...”、“[WARN] ...”（不区分大小写，WARN 和 ERR 分别同 WARNING 和 ERROR），
+// 识别到时去掉前缀，以该级别带日志头写日志，未识别到时 Write 仍按裸日志写，StdLogger 按其指定的级别写。
+func EnableLevelSniffing(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.levelSniffing = enabled
+    })
+}
+
+// stdWriter 作为标准库 log.Logger 的输出，每次 Write 为一条日志
+type stdWriter struct {
+    logger   *SimLogger
+    logLevel LogLevel
+}
+
+// StdLogger 返回写到本日志的标准库 *log.Logger，以便只接受 *log.Logger 的库（如 http.Server.ErrorLog）也写到同一个日志文件，
+// 日志以 logLevel 级别带日志头写（EnableLevelSniffing 时按识别到的级别），log.Logger 自身的前缀和标志均为空。
+func (this *SimLogger) StdLogger(logLevel LogLevel) *log.Logger {
+    return log.New(&stdWriter{logger: this, logLevel: logLevel}, "", 0)
+}
+
+func (this *stdWriter) Write(p []byte) (int, error) {
+    logLevel := this.logLevel
+    logBody := strings.TrimSuffix(string(p), "\n")
+    if this.logger.opts.levelSniffing {
+        if sniffed, body, ok := sniffLevel(logBody); ok {
+            logLevel, logBody = sniffed, body
+        }
+    }
+    if !this.logger.Enabled(logLevel) {
+        return len(p), nil
+    }
+
+    caller := this.logger.getCaller(logLevel, stdLoggerSkip)
+    if _, err := this.logger.output(logLevel, caller, logBody, true); err != nil {
+        return 0, err
+    }
+    return len(p), nil
+}
+
+// Write 的慢路径：识别到级别前缀时带日志头写，返回的 ok 为 false 表示未识别到
+func (this *SimLogger) writeSniffed(p []byte) (n int, err error, ok bool) {
+    logLevel, logBody, ok := sniffLevel(strings.TrimSuffix(string(p), "\n"))
+    if !ok {
+        return 0, nil, false
+    }
+    if this.Enabled(logLevel) {
+        if _, err = this.output(logLevel, Caller{}, logBody, true); err != nil {
+            return 0, err, true
+        }
+    }
+    return len(p), nil, true
+}
+
+// 识别并去掉日志体开头的级别前缀，支持“[LEVEL]”和“LEVEL:”两种形式
+func sniffLevel(logBody string) (LogLevel, string, bool) {
+    text := strings.TrimLeft(logBody, " \t")
+    var name, rest string
+    if strings.HasPrefix(text, "[") {
+        end := strings.IndexByte(text, ']')
+        if end < 0 {
+            return LL_INFO, logBody, false
+        }
+        name, rest = text[1:end], text[end+1:]
+    } else {
+        end := strings.IndexByte(text, ':')
+        if end < 0 || end > len("WARNING") {
+            return LL_INFO, logBody, false
+        }
+        name, rest = text[:end], text[end+1:]
+    }
+    if strings.EqualFold(name, "ERR") {
+        name = "ERROR"
+    }
+    logLevel, err := ParseLogLevel(name)
+    if err != nil || logLevel == LL_RAW {
+        return LL_INFO, logBody, false
+    }
+    return logLevel, strings.TrimLeft(rest, " \t"), true
+}
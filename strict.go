@@ -0,0 +1,80 @@
+package simlog
+
+import (
+    "errors"
+    "sync/atomic"
+)
+
+// ErrClosed 日志已关闭，WithStrictErrors 时关闭后写日志返回
+var ErrClosed = errors.New("simlog: logger is closed")
+
+// WithStrictErrors 异步写时写日志的函数（如 Infof）是否等到日志写入日志文件后才返回，
+// 返回值反映实际的写结果（写入的字节数和写错误），而不是总是返回 nil 错误，
+// 写协程异常退出时返回 ErrWriterDown，日志已关闭时返回 ErrClosed。
+// 这使异步写退化为“在写协程中同步写”，只应用于必须知道写结果的日志（比如审计日志），
+// 只需确保一组日志落盘时用 Barrier 代价更小。同步写时返回值本来就反映写结果，不受影响。
+func WithStrictErrors(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.strictErrors = enabled
+    })
+}
+
+// 构建放入日志队列的日志，WithStrictErrors 时带写结果的通知
+func (this *SimLogger) newQueuedLog(logLevel LogLevel, logLine string) queuedLog {
+    queued := queuedLog{level: logLevel, line: logLine}
+    if this.opts.strictErrors {
+        queued.done = make(chan error, 1)
+    }
+    return queued
+}
+
+// 等待日志写完，writerExit 为写协程的退出信号，
+// 非 WithStrictErrors 时直接返回（日志行的字节数，nil）。
+func (this *SimLogger) waitDone(queued queuedLog, writerExit <-chan struct{}) (int, error) {
+    if queued.done == nil {
+        return len(queued.line), nil
+    }
+
+    select {
+    case err := <-queued.done:
+        if err != nil {
+            return 0, err
+        }
+        return len(queued.line), nil
+    case <-writerExit:
+        // 写协程已退出，日志可能已写入也可能被丢弃
+        select {
+        case err := <-queued.done:
+            if err != nil {
+                return 0, err
+            }
+            return len(queued.line), nil
+        default:
+            return 0, errors.Join(ErrWriterDown, this.getWriterError())
+        }
+    }
+}
+
+// 日志队列已关闭时丢弃未能放入的日志，WithStrictErrors 时和写结果一样经 done 通知 ErrClosed
+func (this *SimLogger) dropClosed(queued queuedLog) (int, error) {
+    atomic.AddInt64(&this.numQueued, -1)
+    atomic.AddInt64(&this.numDropped, 1)
+    if queued.done == nil {
+        return 0, nil
+    }
+    notifyDone(queued.done, ErrClosed)
+    return this.waitDone(queued, nil)
+}
+
+// 通知写的结果
+func notifyDone(done chan error, err error) {
+    if done != nil {
+        done <- err // 带一个缓冲，不会阻塞
+    }
+}
+
+func notifyDones(dones []chan error, err error) {
+    for _, done := range dones {
+        done <- err
+    }
+}
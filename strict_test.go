@@ -0,0 +1,97 @@
+package simlog
+
+import (
+    "errors"
+    "fmt"
+    "path/filepath"
+    "sync"
+    "testing"
+)
+
+// WithStrictErrors 时写日志的函数在日志写入日志文件后返回，返回值为写入的字节数
+func TestStrictErrorsWritten(t *testing.T) {
+    dir := t.TempDir()
+    logger := new(SimLogger)
+    if err := logger.InitE(WithLogdir(dir), WithFilename("strict.log"), WithStrictErrors(true), EnableLineFeed(true), EnableRegistry(false)); err != nil {
+        t.Fatalf("InitE: %s", err.Error())
+    }
+    defer logger.Close()
+    path := filepath.Join(dir, "strict.log")
+
+    var wg sync.WaitGroup
+    for g := 0; g < 8; g++ {
+        wg.Add(1)
+        go func(g int) {
+            defer wg.Done()
+            for i := 0; i < 20; i++ {
+                marker := fmt.Sprintf("strict-%d-%d;", g, i)
+                n, err := logger.Info(marker)
+                if err != nil || n == 0 {
+                    t.Errorf("Info = %d, %v, want n > 0, nil", n, err)
+                    return
+                }
+                if !logFileContains(t, path, marker) {
+                    t.Errorf("%s not in the log file when Info returned", marker)
+                    return
+                }
+            }
+        }(g)
+    }
+    wg.Wait()
+}
+
+// 关闭后写日志被丢弃并计数，WithStrictErrors 时返回 ErrClosed
+func TestStrictErrorsAfterClose(t *testing.T) {
+    if usageChecks {
+        t.Skip("simlogdebug panics on logging after Close")
+    }
+    for _, strict := range []bool{false, true} {
+        t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+            logger := new(SimLogger)
+            if err := logger.InitE(WithLogdir(t.TempDir()), WithStrictErrors(strict), EnableRegistry(false)); err != nil {
+                t.Fatalf("InitE: %s", err.Error())
+            }
+            logger.Close()
+
+            n, err := logger.Info("after close")
+            if n != 0 {
+                t.Errorf("Info after Close wrote %d bytes", n)
+            }
+            if strict && !errors.Is(err, ErrClosed) {
+                t.Errorf("Info after Close = %v, want ErrClosed", err)
+            } else if !strict && err != nil {
+                t.Errorf("Info after Close = %v, want nil", err)
+            }
+            if dropped := logger.Stats().Dropped; dropped != 1 {
+                t.Errorf("Dropped = %d, want 1", dropped)
+            }
+        })
+    }
+}
+
+// 共享后端已停止时写日志被丢弃并计数，WithStrictErrors 时返回 ErrClosed，Queued 不残留
+func TestStrictErrorsBackendStopped(t *testing.T) {
+    backend := NewBackend(16, 4)
+    logger := new(SimLogger)
+    if err := logger.InitE(WithBackend(backend), WithLogdir(t.TempDir()), WithStrictErrors(true), EnableRegistry(false)); err != nil {
+        t.Fatalf("InitE: %s", err.Error())
+    }
+    if _, err := logger.Info("before close"); err != nil {
+        t.Fatalf("Info: %s", err.Error())
+    }
+    backend.Close()
+    logger.Close() // 释放最后一个引用，后端停止
+    <-backend.exit
+
+    n, err := logger.Info("after close")
+    if n != 0 || !errors.Is(err, ErrClosed) {
+        t.Errorf("Info on a stopped backend = %d, %v, want 0, ErrClosed", n, err)
+    }
+    stats := logger.Stats()
+    if stats.Dropped != 1 {
+        t.Errorf("Dropped = %d, want 1", stats.Dropped)
+    }
+    if stats.Queued != 0 {
+        t.Errorf("Queued = %d, want 0", stats.Queued)
+    }
+}
@@ -0,0 +1,82 @@
+package simlog
+
+import (
+    "strconv"
+    "strings"
+)
+
+// SyslogFacility syslog 的设施（facility），见 RFC 5424
+type SyslogFacility int32
+
+const (
+    FacilityKern     SyslogFacility = 0
+    FacilityUser     SyslogFacility = 1
+    FacilityMail     SyslogFacility = 2
+    FacilityDaemon   SyslogFacility = 3
+    FacilityAuth     SyslogFacility = 4
+    FacilitySyslog   SyslogFacility = 5
+    FacilityLpr      SyslogFacility = 6
+    FacilityNews     SyslogFacility = 7
+    FacilityUucp     SyslogFacility = 8
+    FacilityCron     SyslogFacility = 9
+    FacilityAuthpriv SyslogFacility = 10
+    FacilityFtp      SyslogFacility = 11
+    FacilityLocal0   SyslogFacility = 16
+    FacilityLocal1   SyslogFacility = 17
+    FacilityLocal2   SyslogFacility = 18
+    FacilityLocal3   SyslogFacility = 19
+    FacilityLocal4   SyslogFacility = 20
+    FacilityLocal5   SyslogFacility = 21
+    FacilityLocal6   SyslogFacility = 22
+    FacilityLocal7   SyslogFacility = 23
+)
+
+// WithSyslogPriority 在每行日志（裸日志除外）的行首加上 syslog 风格的优先级“<PRI>”，
+// PRI 为 facility*8+severity，severity 由日志级别确定（见 SyslogSeverity），
+// 以便现有的 rsyslog 文件导入流程（如 imfile）无需定制解析器即可对 simlog 的日志文件分类。
+func WithSyslogPriority(facility SyslogFacility) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.syslogPriority = true
+        o.syslogFacility = facility
+    })
+}
+
+// SyslogSeverity 返回日志级别对应的 syslog 严重性：
+// FATAL 为2（crit）、ERROR 为3（err）、WARNING 为4（warning）、NOTICE 为5（notice）、
+// INFO 和裸日志为6（info），DEBUG、DETAIL 和 TRACE 为7（debug）。
+func SyslogSeverity(logLevel LogLevel) int {
+    switch logLevel {
+    case LL_FATAL:
+        return 2
+    case LL_ERROR:
+        return 3
+    case LL_WARNING:
+        return 4
+    case LL_NOTICE:
+        return 5
+    case LL_DEBUG, LL_DETAIL, LL_TRACE:
+        return 7
+    default:
+        return 6
+    }
+}
+
+// 日志级别对应的优先级前缀，如“<134>”
+func (this *SimLogger) syslogPriority(logLevel LogLevel) string {
+    return "<" + strconv.Itoa(int(this.opts.syslogFacility)*8+SyslogSeverity(logLevel)) + ">"
+}
+
+// 去掉行首的优先级前缀
+func trimSyslogPriority(line string) string {
+    if !strings.HasPrefix(line, "<") {
+        return line
+    }
+    end := strings.IndexByte(line, '>')
+    if end < 2 || end > 4 {
+        return line
+    }
+    if _, err := strconv.ParseUint(line[1:end], 10, 8); err != nil {
+        return line
+    }
+    return line[end+1:]
+}
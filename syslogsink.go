@@ -0,0 +1,156 @@
+package simlog
+
+import (
+    "fmt"
+    "net"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// 本机 syslog 的套接字，按顺序尝试
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// 连接或写 syslog 的超时
+const syslogTimeout = 5 * time.Second
+
+// 写到 syslog 的输出，见 NewSyslogSink
+type syslogSink struct {
+    mutex    sync.Mutex
+    network  string
+    raddr    string
+    conn     net.Conn
+    facility SyslogFacility
+    hostname string
+    appName  string
+    procID   string
+}
+
+// NewSyslogSink 创建写到 syslog 的输出（RFC 5424 格式），network 和 raddr 均为空时写到本机的 /dev/log，
+// 否则写到远程的 syslog 服务器，如 NewSyslogSink("udp", "10.0.0.1:514", simlog.FacilityLocal0, "myapp")，
+// network 为“tcp”时按 RFC 6587 的长度前缀分帧。日志级别按 SyslogSeverity 对应为严重性，
+// appName 为空时为程序名，日志的错误码（见 WithCode）作为 MSGID，调用者加在消息之前。
+// 写失败时重新连接一次后重试，仍失败的返回错误，不落盘（需要落盘重发时用 NewNetworkSink 和 WithSpoolDir）。
+func NewSyslogSink(network, raddr string, facility SyslogFacility, appName string) (Sink, error) {
+    hostname, _ := os.Hostname()
+    if appName == "" {
+        appName = filepath.Base(os.Args[0])
+    }
+    sink := &syslogSink{
+        network:  network,
+        raddr:    raddr,
+        facility: facility,
+        hostname: syslogField(hostname),
+        appName:  syslogField(appName),
+        procID:   strconv.Itoa(os.Getpid()),
+    }
+    if err := sink.connect(); err != nil {
+        return nil, err
+    }
+    return sink, nil
+}
+
+// 连接 syslog，调用者需持有 mutex
+func (this *syslogSink) connect() error {
+    if this.network != "" || this.raddr != "" {
+        conn, err := net.DialTimeout(this.network, this.raddr, syslogTimeout)
+        if err != nil {
+            return fmt.Errorf("%w: %w", ErrSinkUnavailable, err)
+        }
+        this.conn = conn
+        return nil
+    }
+
+    for _, network := range []string{"unixgram", "unix"} {
+        for _, path := range localSyslogPaths {
+            if conn, err := net.DialTimeout(network, path, syslogTimeout); err == nil {
+                this.conn = conn
+                return nil
+            }
+        }
+    }
+    return fmt.Errorf("%w: local syslog not found", ErrSinkUnavailable)
+}
+
+func (this *syslogSink) Write(entry Entry) error {
+    msg := this.format(&entry)
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+
+    if this.conn != nil {
+        if err := this.send(msg); err == nil {
+            return nil
+        }
+        this.conn.Close()
+        this.conn = nil
+    }
+    if err := this.connect(); err != nil {
+        return err
+    }
+    return this.send(msg)
+}
+
+// 发送一条消息，调用者需持有 mutex
+func (this *syslogSink) send(msg string) error {
+    this.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+    switch this.conn.(type) {
+    case *net.TCPConn:
+        msg = strconv.Itoa(len(msg)) + " " + msg // RFC 6587 octet counting
+    case *net.UnixConn:
+        if this.conn.LocalAddr().Network() == "unix" {
+            msg += "\n" // 流式套接字按换行分隔
+        }
+    }
+    _, err := this.conn.Write([]byte(msg))
+    return err
+}
+
+func (this *syslogSink) Close() error {
+    this.mutex.Lock()
+    defer this.mutex.Unlock()
+    if this.conn == nil {
+        return nil
+    }
+    err := this.conn.Close()
+    this.conn = nil
+    return err
+}
+
+// 格式化为 RFC 5424 的消息：<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID - MSG
+func (this *syslogSink) format(entry *Entry) string {
+    var sb strings.Builder
+    sb.WriteByte('<')
+    sb.WriteString(strconv.Itoa(int(this.facility)*8 + SyslogSeverity(entry.Level)))
+    sb.WriteString(">1 ")
+    sb.WriteString(entry.Time.Format("2006-01-02T15:04:05.000000Z07:00"))
+    sb.WriteByte(' ')
+    sb.WriteString(this.hostname)
+    sb.WriteByte(' ')
+    sb.WriteString(this.appName)
+    sb.WriteByte(' ')
+    sb.WriteString(this.procID)
+    sb.WriteByte(' ')
+    sb.WriteString(syslogField(entry.Code))
+    sb.WriteString(" - ")
+    if !entry.Caller.empty() {
+        sb.WriteString("[" + filepath.Base(entry.Caller.File) + ":" + strconv.Itoa(entry.Caller.Line) + "] ")
+    }
+    sb.WriteString(strings.TrimRight(entry.Body, "\r\n"))
+    return sb.String()
+}
+
+// RFC 5424 头中的字段：空为“-”，只能是可打印的 ASCII 字符且不含空格
+func syslogField(s string) string {
+    if s == "" {
+        return "-"
+    }
+    return strings.Map(func(r rune) rune {
+        if r < 33 || r > 126 {
+            return '_'
+        }
+        return r
+    }, s)
+}
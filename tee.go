@@ -0,0 +1,82 @@
+package simlog
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "sync/atomic"
+)
+
+// Tee 返回将每次写日志转发给 loggers 中每一个的 Logger，比如同时写本地日志文件和审计日志文件，
+// 各 SimLogger 按自己的日志级别等设置决定是否输出，日志体只格式化一次，记录的调用者仍为调用 Tee 返回值的代码
+// （和直接调用 SimLogger 一样按各自的 SetSkip 值确定）。
+// 返回的字节数为第一个输出了的 SimLogger 的，错误为所有 SimLogger 的错误。
+// 致命错误日志先写入所有 SimLogger，再按第一个 SimLogger 的 FatalPolicy 处理，
+// 策略为 FatalExit 时关闭所有 SimLogger 后再退出进程。
+func Tee(loggers ...*SimLogger) Logger {
+    return &teeLogger{loggers: loggers}
+}
+
+type teeLogger struct {
+    loggers []*SimLogger
+}
+
+// 转发给每个 SimLogger，调用栈的深度须和 SimLogger 的 Info 等到 getCaller 的相同，
+// 即 getCaller <- output <- Info 等 <- 调用者
+func (this *teeLogger) output(logLevel LogLevel, lineFeed bool, format *string, a []interface{}) (int, error) {
+    var n int
+    var errs []error
+    var logBody string
+    var formatted bool
+
+    for _, logger := range this.loggers {
+        enabled := logger.Enabled(logLevel)
+        capture := !enabled && logLevel == LL_DEBUG && logger.debugBuffer != nil
+        if !enabled && !capture {
+            continue
+        }
+        if !formatted {
+            if format != nil {
+                logBody = fmt.Sprintf(*format, a...)
+            } else {
+                logBody = fmt.Sprint(a...)
+            }
+            formatted = true
+        }
+
+        var caller Caller
+        if logLevel != LL_RAW {
+            caller = logger.getCaller(logLevel, logger.GetSkip())
+        }
+        if capture {
+            logger.captureDebug(caller, logBody, lineFeed)
+            continue
+        }
+        m, err := logger.output(logLevel, caller, logBody, lineFeed)
+        if n == 0 {
+            n = m
+        }
+        if err != nil {
+            errs = append(errs, err)
+        }
+    }
+    if logLevel == LL_FATAL && len(this.loggers) > 0 {
+        this.fatal(logBody)
+    }
+    return n, errors.Join(errs...)
+}
+
+// 按第一个 SimLogger 的策略处理致命错误
+func (this *teeLogger) fatal(logBody string) {
+    first := this.loggers[0]
+    switch FatalPolicy(atomic.LoadInt32(&first.opts.fatalPolicy)) {
+    case FatalPanic:
+        panic(&FatalError{Body: logBody})
+    case FatalNone:
+    default:
+        for _, logger := range this.loggers {
+            logger.Close()
+        }
+        os.Exit(int(atomic.LoadInt32(&first.opts.fatalExitCode)))
+    }
+}
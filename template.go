@@ -0,0 +1,78 @@
+package simlog
+
+import (
+    "fmt"
+    "strings"
+)
+
+// TemplateField 保存未渲染的消息模板的字段名，见 TemplateAt
+const TemplateField = "template"
+
+// Template 以 INFO 级别按消息模板写日志，见 TemplateAt
+func (this *SimLogger) Template(template string, values ...interface{}) (int, error) {
+    if !this.IsEnabledInfoLog() {
+        return 0, nil
+    }
+    return this.template(LL_INFO, this.opts.skip, template, values)
+}
+
+// TemplateAt 按消息模板写日志：模板中的命名占位符“{name}”依次以 values 渲染，
+// 同时将各占位符的值和未渲染的模板（字段名为 template）作为结构化字段（同 WithFields），
+// 以便日志聚合系统按不随参数变化的模板归类，如：
+//
+//	logger.Template("user {user} did {action}", "alice", "login")
+//
+// 日志体为“user alice did login”，字段为 template="user {user} did {action}"、user=alice 和 action=login。
+// “{{”和“}}”输出为“{”和“}”；values 少于占位符时多出的占位符原样输出，多于占位符时多出的值追加在日志体之后。
+func (this *SimLogger) TemplateAt(logLevel LogLevel, template string, values ...interface{}) (int, error) {
+    if !this.Enabled(logLevel) {
+        return 0, nil
+    }
+    return this.template(logLevel, this.opts.skip, template, values)
+}
+
+func (this *SimLogger) template(logLevel LogLevel, skip int32, template string, values []interface{}) (int, error) {
+    caller := this.getCaller(logLevel, skip)
+    logBody, fields := renderTemplate(template, values)
+    fields[TemplateField] = template
+    return this.WithFields(fields).output(logLevel, caller, logBody, false)
+}
+
+// 渲染消息模板，返回日志体和各占位符的值
+func renderTemplate(template string, values []interface{}) (string, map[string]interface{}) {
+    var sb strings.Builder
+    fields := make(map[string]interface{}, len(values)+1)
+    next := 0 // 下一个值
+
+    for i := 0; i < len(template); i++ {
+        c := template[i]
+        if (c == '{' || c == '}') && i+1 < len(template) && template[i+1] == c {
+            sb.WriteByte(c)
+            i++
+            continue
+        }
+        if c != '{' {
+            sb.WriteByte(c)
+            continue
+        }
+        end := strings.IndexByte(template[i+1:], '}')
+        if end < 0 {
+            sb.WriteString(template[i:])
+            break
+        }
+        name := template[i+1 : i+1+end]
+        if name == "" || next >= len(values) {
+            sb.WriteString(template[i : i+2+end])
+        } else {
+            fields[name] = values[next]
+            sb.WriteString(fmt.Sprint(values[next]))
+            next++
+        }
+        i += end + 1
+    }
+    for _, value := range values[next:] {
+        sb.WriteByte(' ')
+        sb.WriteString(fmt.Sprint(value))
+    }
+    return sb.String(), fields
+}
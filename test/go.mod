@@ -1,6 +1,6 @@
 module test
 
-go 1.21
+go 1.21.0
 
 replace github.com/eyjian/simlog => ../
 
@@ -8,5 +8,6 @@ require github.com/eyjian/simlog v0.0.5
 
 require (
 	github.com/gofrs/flock v0.12.1 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
 	golang.org/x/sys v0.22.0 // indirect
-)
\ No newline at end of file
+)
@@ -0,0 +1,50 @@
+package simlog
+
+import (
+    "strings"
+    "testing"
+)
+
+// NewTestLogger 创建供单元测试使用的日志：不写日志文件，日志记录在返回的 MemorySink 中以便断言，
+// 同时经 t.Log 输出（只在测试失败或 go test -v 时显示），测试结束时自动关闭。
+// 日志级别为 LL_DEBUG，记录调用者，开启跟踪日志，opts 在这些之后生效。Init 失败时 t.Fatal。
+//
+// 用法：
+// logger, sink := simlog.NewTestLogger(t)
+// doSomething(logger)
+//
+//	if !sink.Contains("order created") {
+//	    t.Error("missing log")
+//	}
+func NewTestLogger(t testing.TB, opts ...LogOption) (*SimLogger, *MemorySink) {
+    t.Helper()
+    sink := NewMemorySink()
+    testOpts := []LogOption{
+        EnableFileOutput(false),
+        EnablePrintScreen(true),
+        WithScreenWriter(testWriter{t}),
+        EnableLogCaller(true),
+        EnableTraceLog(true),
+        WithMemorySink(sink),
+    }
+
+    logger := new(SimLogger)
+    if err := logger.InitE(append(testOpts, opts...)...); err != nil {
+        t.Fatalf("simlog: init test logger failed: %s", err.Error())
+    }
+    logger.SetLogLevel(LL_DEBUG)
+    t.Cleanup(func() {
+        logger.Close()
+    })
+    return logger, sink
+}
+
+// 经 t.Log 输出打屏的日志
+type testWriter struct {
+    t testing.TB
+}
+
+func (this testWriter) Write(p []byte) (int, error) {
+    this.t.Log(strings.TrimRight(string(p), "\n"))
+    return len(p), nil
+}
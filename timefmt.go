@@ -0,0 +1,60 @@
+package simlog
+
+import (
+    "sync/atomic"
+)
+
+// TimePrecision 日志时间秒以下的精度
+type TimePrecision int32
+
+const (
+    TimeMicro TimePrecision = 0 // 微秒（默认），如“2020-03-19 08:00:00 123456”
+    TimeMilli TimePrecision = 1 // 毫秒，如“2020-03-19 08:00:00 123”，日志行更短
+    TimeNano  TimePrecision = 2 // 纳秒，如“2020-03-19 08:00:00 123456789”，用于区分同一微秒内的先后
+)
+
+// WithTimePrecision 设置日志时间秒以下的精度（默认为 TimeMicro）
+func WithTimePrecision(precision TimePrecision) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        atomic.StoreInt32(&o.timePrecision, int32(precision))
+    })
+}
+
+// 取得日志时间的精度
+func (this *SimLogger) GetTimePrecision() TimePrecision {
+    return TimePrecision(atomic.LoadInt32(&this.opts.timePrecision))
+}
+
+// 设置日志时间的精度
+func (this *SimLogger) SetTimePrecision(precision TimePrecision) {
+    this.checkInit()
+    atomic.StoreInt32(&this.opts.timePrecision, int32(precision))
+}
+
+// TimeZoneFormat 日志时间中时区的输出方式
+type TimeZoneFormat int32
+
+const (
+    TimeZoneNone   TimeZoneFormat = 0 // 不输出时区（默认）
+    TimeZoneOffset TimeZoneFormat = 1 // 输出 UTC 偏移，如“2020-03-19 08:00:00 123456 +0800”
+    TimeZoneName   TimeZoneFormat = 2 // 输出时区缩写，如“2020-03-19 08:00:00 123456 CST”
+)
+
+// WithTimeZoneFormat 设置日志时间中时区的输出方式（默认为 TimeZoneNone），
+// 汇集不同地域服务器的日志时，带上时区才能正确排序。
+func WithTimeZoneFormat(timeZone TimeZoneFormat) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        atomic.StoreInt32(&o.timeZoneFormat, int32(timeZone))
+    })
+}
+
+// 取得日志时间中时区的输出方式
+func (this *SimLogger) GetTimeZoneFormat() TimeZoneFormat {
+    return TimeZoneFormat(atomic.LoadInt32(&this.opts.timeZoneFormat))
+}
+
+// 设置日志时间中时区的输出方式
+func (this *SimLogger) SetTimeZoneFormat(timeZone TimeZoneFormat) {
+    this.checkInit()
+    atomic.StoreInt32(&this.opts.timeZoneFormat, int32(timeZone))
+}
@@ -0,0 +1,22 @@
+package simlog
+
+// WithUnixSocketSink 将格式化后的日志行写到本机的 unix 域套接字 path，
+// 同 WithSink(NewUnixSocketSink(path, datagram, opts...))，opts 无效时 Init 失败。
+func WithUnixSocketSink(path string, datagram bool, opts ...LogOption) LogOption {
+    return WithNetworkSink(unixNetwork(datagram), path, opts...)
+}
+
+// NewUnixSocketSink 创建写到 unix 域套接字 path 的输出，以便边车（sidecar）日志收集程序直接接收日志行，
+// 不必跟踪日志文件和处理滚动。datagram 为 true 时用数据报套接字（unixgram），每条日志一个数据报，
+// 超过套接字的数据报大小上限的日志被丢弃；否则用流式套接字（unix），每条一行。
+// 缓冲、重连、落盘（WithSpoolDir）和关闭同 NewNetworkSink。
+func NewUnixSocketSink(path string, datagram bool, opts ...LogOption) (Sink, error) {
+    return NewNetworkSink(unixNetwork(datagram), path, opts...)
+}
+
+func unixNetwork(datagram bool) string {
+    if datagram {
+        return "unixgram"
+    }
+    return "unix"
+}
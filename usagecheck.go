@@ -0,0 +1,73 @@
+//go:build simlogdebug
+
+package simlog
+
+import (
+    "fmt"
+    "runtime"
+    "strings"
+    "sync/atomic"
+)
+
+// 以 -tags simlogdebug 构建时，检查 SimLogger 和日志队列是否被误用（如 Init 之前设置选项、Close 之后写日志、
+// 日志队列被多个消费者同时读），发现时 panic 并给出可据以修改的提示，而不是静默地丢日志或行为错乱。
+// 检查有额外的开销，只应在开发和测试时开启，默认构建时这些检查均为空函数。
+
+// 是否检查误用
+const usageChecks = true
+
+// 检查是否已 Init
+func (this *SimLogger) checkInit() {
+    if this.loggerCore == nil {
+        panic(fmt.Sprintf("simlog: %s called before Init (call Init or InitE first)", calledAPI()))
+    }
+}
+
+// 检查是否在未关闭的日志上重复 Init
+func (this *SimLogger) checkReinit() {
+    if this.loggerCore != nil && atomic.LoadInt32(&this.closed) == 0 {
+        panic("simlog: Init called on a logger that is already initialized (call Close first, or the old log writer leaks)")
+    }
+}
+
+// 检查是否在关闭后写日志（异步写时日志队列已关闭，日志会被丢弃）
+func (this *SimLogger) checkOpen() {
+    if this.logQueue != nil && atomic.LoadInt32(&this.logQueue.closed) == 1 {
+        panic(fmt.Sprintf("simlog: %s called after Close, the log line is dropped (stop logging before Close)", calledAPI()))
+    }
+}
+
+// 日志队列的消费者检查：同时只能有一个消费者
+type consumerGuard struct {
+    active int32
+}
+
+func (this *consumerGuard) enter() {
+    if !atomic.CompareAndSwapInt32(&this.active, 0, 1) {
+        panic("simlog: log queue popped by more than one goroutine (the queue has a single consumer)")
+    }
+}
+
+func (this *consumerGuard) leave() {
+    atomic.StoreInt32(&this.active, 0)
+}
+
+// 调用栈中被调用的 simlog API，即调用者代码之前的最后一个 simlog 的函数，如“(*SimLogger).SetLogFileSize”
+func calledAPI() string {
+    const prefix = "github.com/eyjian/simlog."
+
+    pcs := make([]uintptr, 32)
+    frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+    api := "simlog API"
+    for {
+        frame, more := frames.Next()
+        if !strings.HasPrefix(frame.Function, prefix) {
+            break
+        }
+        api = strings.TrimPrefix(frame.Function, prefix)
+        if !more {
+            break
+        }
+    }
+    return api
+}
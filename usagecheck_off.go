@@ -0,0 +1,16 @@
+//go:build !simlogdebug
+
+package simlog
+
+// 默认构建时不检查误用，见 usagecheck.go（-tags simlogdebug）
+
+const usageChecks = false
+
+func (this *SimLogger) checkInit()   {}
+func (this *SimLogger) checkReinit() {}
+func (this *SimLogger) checkOpen()   {}
+
+type consumerGuard struct{}
+
+func (this *consumerGuard) enter() {}
+func (this *consumerGuard) leave() {}
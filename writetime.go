@@ -0,0 +1,69 @@
+package simlog
+
+import (
+    "strings"
+    "time"
+)
+
+// EnableWriteTime 异步写时是否在日志行中同时记录写入时间（默认为 false），
+// 日志头中的时间为调用写日志时（事件）的时间，写入时间为写协程从日志队列中取出并写日志文件时的时间，
+// 两者之差即这条日志在日志队列中的排队时长。
+// 文本格式紧跟日志时间输出，如“[2020-03-19 08:00:00 123456][write:2020-03-19 08:00:00 125000]”，格式同日志时间；
+// JSON 格式输出为 write_time。同步写时两者相同，不输出；裸日志不输出。解析后见 Entry 的 WriteTime。
+func EnableWriteTime(enabled bool) LogOption {
+    return newFuncLogOption(func(o *logOptions) {
+        o.writeTime = enabled
+    })
+}
+
+// 在日志行中插入写入时间：文本格式插在日志时间之后，JSON 格式插在最后一个字段之后，
+// 带校验和的重新计算校验和
+func (this *SimLogger) stampWriteTime(logLevel LogLevel, logLine string, now time.Time) string {
+    if logLevel == LL_RAW {
+        return logLine
+    }
+    if isJSONLine(logLine) {
+        end := strings.LastIndexByte(logLine, '}')
+        if end < 0 {
+            return logLine
+        }
+        precision := this.GetTimePrecision()
+        if precision < TimeMicro || precision > TimeNano {
+            precision = TimeMicro
+        }
+        return logLine[:end] + `,"write_time":"` + now.Format(jsonTimeLayouts[precision]) + `"` + logLine[end:]
+    }
+
+    start := 0
+    if strings.HasPrefix(logLine, "<") { // syslog 优先级
+        start = strings.IndexByte(logLine, '>') + 1
+    }
+    end := strings.IndexByte(logLine[start:], ']')
+    if end < 0 {
+        return logLine
+    }
+    end += start + 1
+    valid, hasChecksum := VerifyLine(logLine)
+    if hasChecksum && valid {
+        logLine = stripChecksum(logLine)
+    }
+    stamp := "[write:" + getLogTime(now, this.GetTimePrecision(), this.GetTimeZoneFormat())[1:]
+    logLine = logLine[:end] + stamp + logLine[end:]
+    if hasChecksum && valid {
+        logLine = appendChecksum(logLine)
+    }
+    return logLine
+}
+
+// 去掉行尾换行符之前的校验和
+func stripChecksum(logLine string) string {
+    n := len(logLine)
+    for n > 0 && logLine[n-1] == '\n' {
+        n--
+    }
+    pos := strings.LastIndex(logLine[:n], checksumMark)
+    if pos < 0 {
+        return logLine
+    }
+    return logLine[:pos] + logLine[n:]
+}
@@ -0,0 +1,17 @@
+module github.com/eyjian/simlog/zapbridge
+
+go 1.21.0
+
+replace github.com/eyjian/simlog => ../
+
+require (
+	github.com/eyjian/simlog v0.0.5
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/gofrs/flock v0.12.1 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+)
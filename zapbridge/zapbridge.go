@@ -0,0 +1,143 @@
+// Package zapbridge 将 simlog 包装为 zapcore.Core，
+// 以便从 zap 迁移的代码保留 zap 的调用方式，底层使用 simlog 的多进程按大小滚动。
+//
+// 用法：
+// logger := zapbridge.NewLogger(&mylog)
+// logger.Info("hello", zap.String("user", "alice"))
+// 或者和其它 Core 组合：
+// zap.New(zapcore.NewTee(zapbridge.NewCore(&mylog), otherCore))
+//
+// 字段以“ key=value”的形式按 key 排序追加到日志体之后，
+// zap 开启 AddCaller 时使用 zap 记录的调用者，有名字的 logger 以“logger=名字”字段输出。
+// Fatal 和 Panic 由 zap 在写日志后处理（退出进程或 panic），不经过 simlog 的 FatalPolicy。
+package zapbridge
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+)
+import (
+    "github.com/eyjian/simlog"
+    "go.uber.org/zap"
+    "go.uber.org/zap/zapcore"
+)
+
+// Core 实现 zapcore.Core，将 zap 的日志写到 simlog
+type Core struct {
+    logger *simlog.SimLogger
+    fields []zapcore.Field // With 附加的字段
+}
+
+var _ zapcore.Core = (*Core)(nil)
+
+// NewCore 创建写到 logger 的 Core，是否输出由 logger 的日志级别决定
+func NewCore(logger *simlog.SimLogger) *Core {
+    return &Core{logger: logger}
+}
+
+// NewLogger 创建写到 logger 的 zap.Logger，默认记录调用者（AddCaller）
+func NewLogger(logger *simlog.SimLogger, options ...zap.Option) *zap.Logger {
+    return zap.New(NewCore(logger), append([]zap.Option{zap.AddCaller()}, options...)...)
+}
+
+// Enabled 实现 zapcore.LevelEnabler
+func (this *Core) Enabled(level zapcore.Level) bool {
+    return this.logger.Enabled(levelToSimlog(level))
+}
+
+// With 实现 zapcore.Core
+func (this *Core) With(fields []zapcore.Field) zapcore.Core {
+    child := *this
+    child.fields = make([]zapcore.Field, 0, len(this.fields)+len(fields))
+    child.fields = append(child.fields, this.fields...)
+    child.fields = append(child.fields, fields...)
+    return &child
+}
+
+// Check 实现 zapcore.Core
+func (this *Core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+    if this.Enabled(entry.Level) {
+        return checked.AddCore(entry, this)
+    }
+    return checked
+}
+
+// Write 实现 zapcore.Core
+func (this *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+    var caller simlog.Caller
+    if entry.Caller.Defined {
+        caller.File = entry.Caller.File
+        caller.Line = entry.Caller.Line
+    }
+    _, err := this.logger.Output(levelToSimlog(entry.Level), caller, this.formatBody(entry, fields))
+    return err
+}
+
+// Sync 实现 zapcore.Core，等待已写的日志写入日志文件
+func (this *Core) Sync() error {
+    return this.logger.Flush()
+}
+
+// 日志体：消息之后按 key 排序追加字段，有调用栈时附在最后
+func (this *Core) formatBody(entry zapcore.Entry, fields []zapcore.Field) string {
+    encoder := zapcore.NewMapObjectEncoder()
+    for _, field := range this.fields {
+        field.AddTo(encoder)
+    }
+    for _, field := range fields {
+        field.AddTo(encoder)
+    }
+    if entry.LoggerName != "" {
+        encoder.Fields["logger"] = entry.LoggerName
+    }
+
+    keys := make([]string, 0, len(encoder.Fields))
+    for k := range encoder.Fields {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+
+    var sb strings.Builder
+    sb.WriteString(strings.TrimSuffix(entry.Message, "\n"))
+    for _, k := range keys {
+        var value string
+        switch v := encoder.Fields[k].(type) {
+        case error:
+            value = v.Error()
+        case string:
+            value = v
+        default:
+            value = fmt.Sprint(v)
+        }
+        if value == "" || strings.ContainsAny(value, " \t\r\n\"=") {
+            value = strconv.Quote(value)
+        }
+        sb.WriteString(" ")
+        sb.WriteString(k)
+        sb.WriteString("=")
+        sb.WriteString(value)
+    }
+    if entry.Stack != "" {
+        sb.WriteString("\n")
+        sb.WriteString(entry.Stack)
+    }
+    return sb.String()
+}
+
+// zap 级别到 simlog 级别，zap 的 DPanic、Panic 和 Fatal 均对应 simlog 的 FATAL
+func levelToSimlog(level zapcore.Level) simlog.LogLevel {
+    switch {
+    case level >= zapcore.DPanicLevel:
+        return simlog.LL_FATAL
+    case level == zapcore.ErrorLevel:
+        return simlog.LL_ERROR
+    case level == zapcore.WarnLevel:
+        return simlog.LL_WARNING
+    case level == zapcore.InfoLevel:
+        return simlog.LL_INFO
+    default:
+        return simlog.LL_DEBUG
+    }
+}